@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// DefaultDeletionPolicy keeps the hosting namespace and deletes the rest
+var DefaultDeletionPolicy = DeletionPolicy{
+	HostedCluster:    DeletionPolicyDelete,
+	Infrastructure:   DeletionPolicyDelete,
+	IAM:              DeletionPolicyDelete,
+	HostingNamespace: DeletionPolicyOrphan,
+	ManagedCluster:   DeletionPolicyDelete,
+}
+
+// OverrideDeletionPolicy returns the fields of the deletion policy set by the deprecated Override
+func OverrideDeletionPolicy(override InfraOverride) DeletionPolicy {
+	switch override {
+	case InfraOverrideDestroy:
+		return DeletionPolicy{
+			HostedCluster:  DeletionPolicyOrphan,
+			Infrastructure: DeletionPolicyOrphan,
+			IAM:            DeletionPolicyOrphan,
+		}
+	case DeleteHostingNamespace:
+		return DeletionPolicy{HostingNamespace: DeletionPolicyDelete}
+	}
+	return DeletionPolicy{}
+}
+
+// Merge returns the deletion policy with the fields that are not set taken from defaults
+func (p *DeletionPolicy) Merge(defaults DeletionPolicy) DeletionPolicy {
+	if p == nil {
+		return defaults
+	}
+
+	policy := *p
+	if len(policy.HostedCluster) == 0 {
+		policy.HostedCluster = defaults.HostedCluster
+	}
+	if len(policy.Infrastructure) == 0 {
+		policy.Infrastructure = defaults.Infrastructure
+	}
+	if len(policy.IAM) == 0 {
+		policy.IAM = defaults.IAM
+	}
+	if len(policy.HostingNamespace) == 0 {
+		policy.HostingNamespace = defaults.HostingNamespace
+	}
+	if len(policy.ManagedCluster) == 0 {
+		policy.ManagedCluster = defaults.ManagedCluster
+	}
+	return policy
+}
+
+// GetDeletionPolicy returns the policy of each kind of resource, a field of Spec.DeletionPolicy wins over the
+// deprecated Spec.Override, then the DefaultDeletionPolicy applies
+func (s *HypershiftDeploymentSpec) GetDeletionPolicy() DeletionPolicy {
+	override := OverrideDeletionPolicy(s.Override)
+	return s.DeletionPolicy.Merge(override.Merge(DefaultDeletionPolicy))
+}
+
+// GetProvisioningScope returns Spec.ProvisioningScope, when it is not set the deprecated Spec.Override INFRA-ONLY
+// stands for InfrastructureOnly
+func (s *HypershiftDeploymentSpec) GetProvisioningScope() ProvisioningScope {
+	if len(s.ProvisioningScope) != 0 {
+		return s.ProvisioningScope
+	}
+	if s.Override == InfraConfigureOnly {
+		return ProvisioningScopeInfrastructureOnly
+	}
+	return ProvisioningScopeAll
+}
+
+// IsInfrastructureOnly is true when the HostedCluster and NodePools are not applied, see GetProvisioningScope
+func (s *HypershiftDeploymentSpec) IsInfrastructureOnly() bool {
+	return s.GetProvisioningScope() == ProvisioningScopeInfrastructureOnly
+}
@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	hypv1alpha1 "github.com/openshift/hypershift/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,21 +29,46 @@ type CurrentPhase string
 
 type InfraOverride string
 
+type DeletionPolicyType string
+
+type ProvisioningScope string
+
 const (
-	ConfiguredAsExpectedReason = "ConfiguredAsExpected"
-	PlatfromDestroyReason      = "Destroying"
-	MisConfiguredReason        = "MisConfigured"
-	BeingConfiguredReason      = "BeingConfigured"
-	NotApplicableReason        = "NA"
-	RemovingReason             = "Removing"
-	AsExpectedReason           = "AsExpected"
-	NodePoolProvision          = "NodePoolsProvisioned"
+	ConfiguredAsExpectedReason  = "ConfiguredAsExpected"
+	PlatfromDestroyReason       = "Destroying"
+	MisConfiguredReason         = "MisConfigured"
+	BeingConfiguredReason       = "BeingConfigured"
+	NotApplicableReason         = "NA"
+	RemovingReason              = "Removing"
+	AsExpectedReason            = "AsExpected"
+	NodePoolProvision           = "NodePoolsProvisioned"
+	ControlPlaneUpgradingReason = "ControlPlaneUpgrading"
+	NodePoolsUpgradingReason    = "NodePoolsUpgrading"
+	UpgradePausedReason         = "UpgradePaused"
+	UpgradeCompletedReason      = "UpgradeCompleted"
+	ResourcesMissingReason      = "ResourcesMissing"
+	RepairingReason             = "Repairing"
+	VerificationFailedReason    = "VerificationFailed"
+	RetryLimitReachedReason     = "RetryLimitReached"
+	PermanentErrorReason        = "PermanentError"
+	DryRunCompletedReason       = "DryRunCompleted"
+	ReconcilePausedReason       = "ReconcilePaused"
+	WaitingForPlacementReason   = "WaitingForPlacement"
+	MigrationRolledBackReason   = "MigrationRolledBack"
+	WaitingForCapacityReason    = "WaitingForCapacity"
+	DetachedReason              = "Detached"
 
 	// PlatformConfigured indicates (if status is true) that the
 	// platform configuration specified for the platform provider has been applied
 	PlatformConfigured ConditionType = "PlatformInfrastructureConfigured"
 	// PlatformIAMConfigured indicates (if status is true) that the IAM is configured
 	PlatformIAMConfigured ConditionType = "PlatformIAMConfigured"
+	// PlatformInfrastructureDrifted indicates (if status is true) that cloud resources recorded in
+	// Status.Infrastructure no longer exist on the provider
+	PlatformInfrastructureDrifted ConditionType = "PlatformInfrastructureDrifted"
+	// InfrastructureFailed indicates (if status is true) that an infrastructure or IAM operation is no longer
+	// retried, until the spec or the cloud provider secret changes
+	InfrastructureFailed ConditionType = "InfrastructureFailed"
 	// ProviderSecretConfigured indicates the state of the secret reference
 	ProviderSecretConfigured ConditionType = "ProviderSecretConfigured"
 
@@ -71,9 +97,67 @@ const (
 	// WorkConfigured indicates the status of applying the ManifestWork
 	WorkConfigured ConditionType = "ManifestWorkConfigured"
 
+	// DryRun indicates (if status is true) that the dry-run annotation is set, the plan and the ManifestWork
+	// changes are in a ConfigMap instead of being applied
+	DryRun ConditionType = "DryRun"
+
+	// HostingClusterSelected indicates (if status is true) that a hosting cluster was chosen from the
+	// PlacementDecisions of Spec.HostingClusterPlacement
+	HostingClusterSelected ConditionType = "HostingClusterSelected"
+
+	// Paused indicates (if status is true) that Spec.Paused is set, the infrastructure and the ManifestWork
+	// are left untouched
+	Paused ConditionType = "Paused"
+
+	// UpgradeProgressing indicates (if status is true) that a release upgrade is being rolled out
+	UpgradeProgressing ConditionType = "UpgradeProgressing"
+
+	// Migrating indicates (if status is true) that the HostedCluster is being moved to a new hosting cluster,
+	// see Status.Migration
+	Migrating ConditionType = "Migrating"
+	// The steps of a migration, each one is true once the step completed
+	MigrationSourcePaused  ConditionType = "MigrationSourcePaused"
+	MigrationBackedUp      ConditionType = "MigrationBackedUp"
+	MigrationTargetApplied ConditionType = "MigrationTargetApplied"
+	MigrationRestored      ConditionType = "MigrationRestored"
+	MigrationDNSSwitched   ConditionType = "MigrationDNSSwitched"
+	MigrationSourceRemoved ConditionType = "MigrationSourceRemoved"
+
+	// HostedClusterAdopted indicates (if status is true) that the spec of the HostedCluster and NodePools of
+	// Spec.Adopt was read back from the hosting cluster
+	HostedClusterAdopted ConditionType = "HostedClusterAdopted"
+
+	// Detached indicates (if status is true) that the manifests were exported and the HostedCluster and NodePools
+	// were orphaned on the hosting cluster, they are no longer managed by the HypershiftDeployment
+	Detached ConditionType = "Detached"
+
+	// Phases of a HypershiftDeployment, computed from the conditions
+	PhasePending          CurrentPhase = "Pending"
+	PhaseConfiguringInfra CurrentPhase = "ConfiguringInfra"
+	PhaseConfiguringIAM   CurrentPhase = "ConfiguringIAM"
+	PhaseApplyingWork     CurrentPhase = "ApplyingWork"
+	PhaseProvisioning     CurrentPhase = "Provisioning"
+	PhaseAvailable        CurrentPhase = "Available"
+	PhaseUpgrading        CurrentPhase = "Upgrading"
+	PhaseDeleting         CurrentPhase = "Deleting"
+	PhaseDestroyingInfra  CurrentPhase = "DestroyingInfra"
+	PhaseFailed           CurrentPhase = "Failed"
+	PhasePaused           CurrentPhase = "Paused"
+	PhaseMigrating        CurrentPhase = "Migrating"
+	PhaseDetached         CurrentPhase = "Detached"
+
 	InfraOverrideDestroy   = "ORPHAN"
 	InfraConfigureOnly     = "INFRA-ONLY"
 	DeleteHostingNamespace = "DELETE-HOSTING-NAMESPACE"
+
+	DeletionPolicyDelete DeletionPolicyType = "Delete"
+	DeletionPolicyOrphan DeletionPolicyType = "Orphan"
+
+	// ProvisioningScopeAll configures the infrastructure when Spec.Infrastructure.Configure is true, then applies
+	// the HostedCluster and NodePools
+	ProvisioningScopeAll ProvisioningScope = "All"
+	// ProvisioningScopeInfrastructureOnly only configures the infrastructure and IAM, no ManifestWork is applied
+	ProvisioningScopeInfrastructureOnly ProvisioningScope = "InfrastructureOnly"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -96,9 +180,21 @@ type HypershiftDeploymentSpec struct {
 	//   OverrideDestroy = "ORPHAN"
 	//   InfraConfigureOnly = "INFRA-ONLY"
 	//   DeleteHostingNamespace = "DELETE-HOSTING-NAMESPACE"
+	// Deprecated: use DeletionPolicy and ProvisioningScope, a field set there wins over the Override
 	// +kubebuilder:validation:Enum=ORPHAN;INFRA-ONLY;DELETE-HOSTING-NAMESPACE
 	Override InfraOverride `json:"override,omitempty"`
 
+	// DeletionPolicy sets, per kind of resource, what is removed when the HypershiftDeployment is deleted. A field
+	// that is not set follows the Override, by default the hosting namespace is kept and the rest is deleted
+	// +optional
+	DeletionPolicy *DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// ProvisioningScope is InfrastructureOnly to only configure the infrastructure and IAM, without applying the
+	// HostedCluster and NodePools. Defaults to All, or InfrastructureOnly when the Override is INFRA-ONLY
+	// +kubebuilder:validation:Enum=All;InfrastructureOnly
+	// +optional
+	ProvisioningScope ProvisioningScope `json:"provisioningScope,omitempty"`
+
 	//HostingNamespace specify the where the children resouces(hostedcluster, nodepool)
 	//to sit in
 	//if not provided, the default is "clusters"
@@ -106,11 +202,18 @@ type HypershiftDeploymentSpec struct {
 	HostingNamespace string `json:"hostingNamespace"`
 
 	//HostingCluster only applies to ManifestWork, and specifies which managedCluster's namespace the manifestwork will be applied to.
-	//If not specified, the controller will flag an error condition.
+	//If not specified, the HostingClusterPlacement is used, without either the controller will flag an error condition.
 	//The HostingCluster would be the management cluster of the hostedcluster and nodepool generated
 	//by the hypershiftDeployment
+	// +optional
 	HostingCluster string `json:"hostingCluster"`
 
+	// HostingClusterPlacement references an OCM Placement in the HypershiftDeployment namespace, used when
+	// HostingCluster is empty. The hosting cluster is chosen from the PlacementDecisions once and recorded in
+	// Status.HostingCluster, a later decision does not move the HostedCluster
+	// +optional
+	HostingClusterPlacement *corev1.LocalObjectReference `json:"hostingClusterPlacement,omitempty"`
+
 	// HostedCluster that will be applied to the ManagementCluster by ACM, if omitted, it will be generated
 	// +optional
 	HostedClusterSpec *hypv1alpha1.HostedClusterSpec `json:"hostedClusterSpec,omitempty"`
@@ -126,11 +229,22 @@ type HypershiftDeploymentSpec struct {
 	// +optional
 	NodePools []*HypershiftNodePools `json:"nodePools,omitempty"`
 
+	// Adopt takes over a HostedCluster named after the HypershiftDeployment, and its NodePools, that already exist
+	// in HostingNamespace on the hosting cluster. Their spec is read back into HostedClusterSpec and NodePools, then
+	// the ManifestWork manages them without recreating them
+	// +optional
+	Adopt *AdoptSpec `json:"adopt,omitempty"`
+
 	// HostedManagedClusterSet is the ManagedClusterSet the hosted cluster should belong to.
 	// If omitted, the default is the hosting cluster's cluster set
 	// +optional
 	HostedManagedClusterSet string `json:"hostedManagedClusterSet,omitempty"`
 
+	// SyncKubeadminPassword copies the kubeadmin password Secret of the HostedCluster to the HypershiftDeployment
+	// namespace, with the admin kubeconfig. See Status.KubeadminPasswordSecret
+	// +optional
+	SyncKubeadminPassword bool `json:"syncKubeadminPassword,omitempty"`
+
 	// Reference to an array of NodePool resources on the HyperShift deployment namespace that will be applied
 	// to the ManagementCluster by ACM,
 	// required if InfraSpec.Configure is false
@@ -139,6 +253,56 @@ type HypershiftDeploymentSpec struct {
 
 	// Credentials are ARN's that are used for standing up the resources in the cluster.
 	Credentials *CredentialARNs `json:"credentials,omitempty"`
+
+	// Upgrade controls how a change to HostedClusterSpec.Release.Image is rolled out. When set, the control plane
+	// is upgraded first, and the NodePools are moved to the new release once the control plane has settled.
+	// If omitted, the NodePools keep the release image in their spec
+	// +optional
+	Upgrade *UpgradeSpec `json:"upgrade,omitempty"`
+
+	// Migration configures the move of the HostedCluster when HostingCluster is changed. The etcd backup
+	// settings are required when the HostedCluster uses a managed etcd
+	// +optional
+	Migration *MigrationSpec `json:"migration,omitempty"`
+
+	// Paused stops the reconciliation of the HypershiftDeployment, the infrastructure, the ManifestWork and the
+	// ManagedCluster are not changed. A deletion waits until Paused is set back to false
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+type UpgradeSpec struct {
+	// Paused stops the upgrade from moving to the next batch of NodePools, the NodePools that are already
+	// upgrading complete. Set it back to false to resume the upgrade
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// NodePoolBatchSize is the number of NodePools that are upgraded at the same time, defaults to 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NodePoolBatchSize int `json:"nodePoolBatchSize,omitempty"`
+}
+
+type MigrationSpec struct {
+	// BackupSecret is a Secret in the HypershiftDeployment namespace with pre-signed URLs of the etcd snapshot,
+	// upload-url is used to upload it from the source hosting cluster and restore-url to restore it on the target
+	// +optional
+	BackupSecret *corev1.LocalObjectReference `json:"backupSecret,omitempty"`
+
+	// BackupImage is an image with etcdctl and curl, used to back up the managed etcd. The etcd image of the
+	// release provides both
+	// +optional
+	BackupImage string `json:"backupImage,omitempty"`
+
+	// StepTimeout is how long a step can take before the migration is rolled back, defaults to 30m
+	// +optional
+	StepTimeout *metav1.Duration `json:"stepTimeout,omitempty"`
+}
+
+type AdoptSpec struct {
+	// Image is an image with kubectl, used by the Job that reads the HostedCluster and NodePools on the hosting
+	// cluster. The cli image of the release provides it
+	Image string `json:"image"`
 }
 
 type CredentialARNs struct {
@@ -157,6 +321,89 @@ type HypershiftNodePools struct {
 
 	// Spec stores the NodePoolSpec you wan to use. If omitted, it will be generated
 	Spec hypv1alpha1.NodePoolSpec `json:"spec"`
+
+	// Policy expands the NodePool into one NodePool per zone of the AWS platform, with the replicas or autoscaling
+	// limits spread across the zones. The NodePool of the first zone keeps the name, the others are named
+	// <name>-<zone>. Spec.Replicas and Spec.AutoScaling are set from the policy. Without zones a single NodePool is
+	// created
+	// +optional
+	Policy *NodePoolPolicy `json:"policy,omitempty"`
+}
+
+// NodePoolPolicy sets the size of a NodePool across the zones. One of Replicas or AutoScaling is required
+type NodePoolPolicy struct {
+	// Replicas is the total number of nodes, spread evenly across the zones. The first zones get one more
+	// node when it does not divide evenly
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// AutoScaling is the total minimum and maximum number of nodes, spread across the zones like Replicas.
+	// Each zone has at least one node
+	// +optional
+	AutoScaling *NodePoolAutoScalingPolicy `json:"autoScaling,omitempty"`
+
+	// ScaleDownWindows replace the replicas or autoscaling limits with a fixed number of nodes while they are open
+	// +optional
+	ScaleDownWindows []ScaleDownWindow `json:"scaleDownWindows,omitempty"`
+}
+
+type NodePoolAutoScalingPolicy struct {
+	// Min is the total minimum number of nodes
+	// +kubebuilder:validation:Minimum=1
+	Min int32 `json:"min"`
+
+	// Max is the total maximum number of nodes
+	// +kubebuilder:validation:Minimum=1
+	Max int32 `json:"max"`
+}
+
+// ScaleDownWindow is a recurring time window in UTC
+type ScaleDownWindow struct {
+	// Days the window opens on, Mon, Tue, Wed, Thu, Fri, Sat or Sun. If omitted, it opens every day
+	// +optional
+	Days []string `json:"days,omitempty"`
+
+	// Start is the time the window opens, HH:MM in UTC
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+
+	// End is the time the window closes, HH:MM in UTC. An End before Start closes the window the next day
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+
+	// Replicas is the total number of nodes while the window is open, spread across the zones
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
+}
+
+// DeletionPolicy sets, per kind of resource, whether it is deleted or orphaned with the HypershiftDeployment
+type DeletionPolicy struct {
+	// HostedCluster is the policy of the HostedCluster, NodePools and Secrets on the hosting cluster, defaults
+	// to Delete. The hosting namespace can not be deleted when the HostedCluster is orphaned
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	HostedCluster DeletionPolicyType `json:"hostedCluster,omitempty"`
+
+	// Infrastructure is the policy of the cloud infrastructure, the VPC, subnets, DNS zones or Azure resource
+	// group, defaults to Delete
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	Infrastructure DeletionPolicyType `json:"infrastructure,omitempty"`
+
+	// IAM is the policy of the AWS IAM roles, instance profile and OIDC provider, defaults to Delete
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	IAM DeletionPolicyType `json:"iam,omitempty"`
+
+	// HostingNamespace is the policy of the hosting namespace on the hosting cluster, defaults to Orphan
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	HostingNamespace DeletionPolicyType `json:"hostingNamespace,omitempty"`
+
+	// ManagedCluster is the policy of the ManagedCluster of the hosted cluster on the hub, defaults to Delete
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	ManagedCluster DeletionPolicyType `json:"managedCluster,omitempty"`
 }
 
 type InfraSpec struct {
@@ -174,11 +421,89 @@ type InfraSpec struct {
 
 	// CloudProvider secret, contains the Cloud credenetial, Pull Secret and Base Domain
 	CloudProvider corev1.LocalObjectReference `json:"cloudProvider,omitempty"`
+
+	// RepairDrift re-runs the infrastructure and IAM configuration when resources recorded in
+	// Status.Infrastructure are found missing on the provider. Otherwise the drift is only reported
+	// with the PlatformInfrastructureDrifted condition
+	//
+	// +optional
+	RepairDrift bool `json:"repairDrift,omitempty"`
 }
 
 type Platforms struct {
-	Azure *AzurePlatform `json:"azure,omitempty"`
-	AWS   *AWSPlatform   `json:"aws,omitempty"`
+	Azure    *AzurePlatform    `json:"azure,omitempty"`
+	AWS      *AWSPlatform      `json:"aws,omitempty"`
+	KubeVirt *KubeVirtPlatform `json:"kubevirt,omitempty"`
+	Agent    *AgentPlatform    `json:"agent,omitempty"`
+}
+
+type KubeVirtPlatform struct {
+
+	// BaseDomain is the DNS base domain of the hosted cluster. If omitted, the baseDomain
+	// of the CloudProvider secret is used
+	//
+	// +optional
+	// +immutable
+	BaseDomain string `json:"baseDomain,omitempty"`
+
+	// APIServerAddress publishes the control plane services as NodePorts on this address of the
+	// hosting cluster. If omitted, the services are published with a LoadBalancer and Routes
+	//
+	// +optional
+	// +immutable
+	APIServerAddress string `json:"apiServerAddress,omitempty"`
+
+	// Memory is the guest memory of the generated NodePool virtual machines, defaults to 4Gi
+	//
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// Cores is the number of cores of the generated NodePool virtual machines, defaults to 2
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Cores *uint32 `json:"cores,omitempty"`
+
+	// RootVolumeSize is the size of the root volume of the generated NodePool virtual machines, defaults to 16Gi
+	//
+	// +optional
+	RootVolumeSize *resource.Quantity `json:"rootVolumeSize,omitempty"`
+
+	// RootVolumeStorageClass is the storage class of the root volume, defaults to the hosting cluster default
+	//
+	// +optional
+	RootVolumeStorageClass string `json:"rootVolumeStorageClass,omitempty"`
+
+	// ContainerDiskImage is the container image with the RHCOS disk, defaults to the image of the release
+	//
+	// +optional
+	ContainerDiskImage string `json:"containerDiskImage,omitempty"`
+}
+
+type AgentPlatform struct {
+
+	// AgentNamespace is the namespace on the hosting cluster where the Agents for this cluster are found
+	//
+	// +immutable
+	AgentNamespace string `json:"agentNamespace"`
+
+	// APIServerAddress is the address of a hosting cluster node, the control plane services are published
+	// as NodePorts on this address
+	//
+	// +immutable
+	APIServerAddress string `json:"apiServerAddress"`
+
+	// BaseDomain is the DNS base domain of the hosted cluster. If omitted, the baseDomain
+	// of the CloudProvider secret is used
+	//
+	// +optional
+	// +immutable
+	BaseDomain string `json:"baseDomain,omitempty"`
+
+	// AgentLabelSelector selects the Agents used by the generated NodePools
+	//
+	// +optional
+	AgentLabelSelector *metav1.LabelSelector `json:"agentLabelSelector,omitempty"`
 }
 
 type AzurePlatform struct {
@@ -214,21 +539,353 @@ type HypershiftDeploymentStatus struct {
 	// executed as a job
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
-	//Show which phase of curation is currently being processed
+	// Phase summarizes the conditions: Pending, ConfiguringInfra, ConfiguringIAM, ApplyingWork, Provisioning,
+	// Available, Upgrading, Migrating, Deleting, DestroyingInfra, Failed, Paused or Detached
+	// +optional
 	Phase CurrentPhase `json:"phase,omitempty"`
+
+	// PhaseTransitionTime is the last time the phase changed
+	// +optional
+	PhaseTransitionTime *metav1.Time `json:"phaseTransitionTime,omitempty"`
+
+	// Upgrade tracks the release upgrade when Spec.Upgrade is set
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// Infrastructure records the cloud resources created when Spec.Infrastructure.Configure is true
+	// +optional
+	Infrastructure *InfrastructureStatus `json:"infrastructure,omitempty"`
+
+	// InfraRetry tracks the failed attempts of the current infrastructure or IAM operation
+	// +optional
+	InfraRetry *InfraRetryStatus `json:"infraRetry,omitempty"`
+
+	// CopiedSources records the resourceVersion of each Secret and ConfigMap copied from the HypershiftDeployment
+	// namespace into the ManifestWork, the ManifestWork is updated when one of them changes
+	// +optional
+	CopiedSources []CopiedSourceStatus `json:"copiedSources,omitempty"`
+
+	// HostingCluster is the ManagedCluster the ManifestWork is applied to, chosen from
+	// Spec.HostingClusterPlacement or recorded from Spec.HostingCluster. A change to Spec.HostingCluster
+	// starts a migration, HostingCluster is updated once it completed
+	// +optional
+	HostingCluster string `json:"hostingCluster,omitempty"`
+
+	// Migration tracks the move of the HostedCluster to a new hosting cluster
+	// +optional
+	Migration *MigrationStatus `json:"migration,omitempty"`
+
+	// NodePools reports each NodePool applied with the ManifestWork
+	// +optional
+	NodePools []NodePoolStatus `json:"nodePools,omitempty"`
+
+	// Kubeconfig is the Secret with the admin kubeconfig of the HostedCluster, in the hosting namespace of the
+	// hosting cluster
+	// +optional
+	Kubeconfig *corev1.LocalObjectReference `json:"kubeconfig,omitempty"`
+
+	// KubeadminPassword is the Secret with the initial kubeadmin password of the HostedCluster, in the hosting
+	// namespace of the hosting cluster
+	// +optional
+	KubeadminPassword *corev1.LocalObjectReference `json:"kubeadminPassword,omitempty"`
+
+	// AdminKubeconfigSecret is the copy of the admin kubeconfig of the HostedCluster in the HypershiftDeployment
+	// namespace, it is refreshed when the kubeconfig synced from the hosting cluster changes
+	// +optional
+	AdminKubeconfigSecret *corev1.LocalObjectReference `json:"adminKubeconfigSecret,omitempty"`
+
+	// KubeadminPasswordSecret is the copy of the kubeadmin password in the HypershiftDeployment namespace, when
+	// Spec.SyncKubeadminPassword is set
+	// +optional
+	KubeadminPasswordSecret *corev1.LocalObjectReference `json:"kubeadminPasswordSecret,omitempty"`
+
+	// APIEndpoint is the URL of the API server of the HostedCluster
+	// +optional
+	APIEndpoint string `json:"apiEndpoint,omitempty"`
+
+	// Version is the latest release version applied to the HostedCluster
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// NodePoolStatus is the status of a NodePool reported through the ManifestWork status feedback
+type NodePoolStatus struct {
+	// Name of the NodePool on the hosting cluster
+	Name string `json:"name"`
+
+	// Ready is the status of the Ready condition of the NodePool
+	// +optional
+	Ready metav1.ConditionStatus `json:"ready,omitempty"`
+
+	// Reason and Message of the Ready condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Replicas is the desired number of nodes, unset when the NodePool is autoscaled
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// AvailableReplicas is the number of nodes the NodePool reports
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+
+	// Version is the release version applied to the nodes
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// AutoScaling is the minimum and maximum number of nodes of an autoscaled NodePool
+	// +optional
+	AutoScaling *hypv1alpha1.NodePoolAutoScaling `json:"autoScaling,omitempty"`
+
+	// AutoScalingEnabled is the status of the AutoscalingEnabled condition of the NodePool
+	// +optional
+	AutoScalingEnabled metav1.ConditionStatus `json:"autoScalingEnabled,omitempty"`
+}
+
+// CopiedSourceStatus is a Secret or ConfigMap copied into the ManifestWork
+type CopiedSourceStatus struct {
+	// Kind is Secret or ConfigMap
+	Kind string `json:"kind"`
+
+	Name string `json:"name"`
+
+	// ResourceVersion of the source when it was last copied
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+type InfraErrorClass string
+
+const (
+	// InfraErrorTransient errors are retried with an exponential backoff
+	InfraErrorTransient InfraErrorClass = "Transient"
+	// InfraErrorAuthentication and InfraErrorQuota errors are not retried
+	InfraErrorAuthentication InfraErrorClass = "Authentication"
+	InfraErrorQuota          InfraErrorClass = "Quota"
+)
+
+type InfraRetryStatus struct {
+	// Operation is the failing operation: create-infra, create-iam, destroy-infra or destroy-iam
+	Operation string `json:"operation"`
+
+	// Attempts is the number of consecutive failures of the operation
+	Attempts int32 `json:"attempts"`
+
+	// NextRetryTime is when the operation is tried again, it is not set once the operation is no longer retried
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// LastErrorClass is Transient, Authentication or Quota
+	LastErrorClass InfraErrorClass `json:"lastErrorClass"`
+
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// ObservedGeneration and ProviderSecretResourceVersion are the spec and cloud provider secret the operation
+	// failed with, the attempts start over when either changes
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +optional
+	ProviderSecretResourceVersion string `json:"providerSecretResourceVersion,omitempty"`
+}
+
+type InfrastructureStatus struct {
+	// LastVerifiedTime is the last time the recorded resources were compared with the provider
+	// +optional
+	LastVerifiedTime *metav1.Time `json:"lastVerifiedTime,omitempty"`
+
+	// +optional
+	AWS *AWSInfrastructureStatus `json:"aws,omitempty"`
+
+	// +optional
+	Azure *AzureInfrastructureStatus `json:"azure,omitempty"`
+}
+
+type AWSInfrastructureStatus struct {
+	Region string `json:"region"`
+
+	// +optional
+	VPCID string `json:"vpcID,omitempty"`
+
+	// +optional
+	MachineCIDR string `json:"machineCIDR,omitempty"`
+
+	// +optional
+	SecurityGroupID string `json:"securityGroupID,omitempty"`
+
+	// Zones are the availability zones with the private subnet created in each of them
+	// +optional
+	Zones []AWSZoneStatus `json:"zones,omitempty"`
+
+	// +optional
+	BaseDomain string `json:"baseDomain,omitempty"`
+
+	// +optional
+	PublicZoneID string `json:"publicZoneID,omitempty"`
+
+	// +optional
+	PrivateZoneID string `json:"privateZoneID,omitempty"`
+
+	// +optional
+	LocalZoneID string `json:"localZoneID,omitempty"`
+
+	// +optional
+	ProxyAddr string `json:"proxyAddr,omitempty"`
+
+	// IAM is set once the OIDC provider, instance profile and roles are created
+	// +optional
+	IAM *AWSIAMStatus `json:"iam,omitempty"`
+}
+
+type AWSZoneStatus struct {
+	Name string `json:"name"`
+
+	SubnetID string `json:"subnetID"`
+}
+
+type AWSIAMStatus struct {
+	// +optional
+	IssuerURL string `json:"issuerURL,omitempty"`
+
+	// +optional
+	ProfileName string `json:"profileName,omitempty"`
+
+	// +optional
+	Roles hypv1alpha1.AWSRolesRef `json:"roles,omitempty"`
+
+	// +optional
+	KMSKeyARN string `json:"kmsKeyARN,omitempty"`
+
+	// +optional
+	KMSProviderRoleARN string `json:"kmsProviderRoleARN,omitempty"`
+}
+
+type AzureInfrastructureStatus struct {
+	Location string `json:"location"`
+
+	// +optional
+	ResourceGroupName string `json:"resourceGroupName,omitempty"`
+
+	// +optional
+	VNetID string `json:"vnetID,omitempty"`
+
+	// +optional
+	VnetName string `json:"vnetName,omitempty"`
+
+	// +optional
+	SubnetName string `json:"subnetName,omitempty"`
+
+	// +optional
+	SecurityGroupName string `json:"securityGroupName,omitempty"`
+
+	// +optional
+	MachineIdentityID string `json:"machineIdentityID,omitempty"`
+
+	// +optional
+	BootImageID string `json:"bootImageID,omitempty"`
+
+	// +optional
+	BaseDomain string `json:"baseDomain,omitempty"`
+
+	// +optional
+	PublicZoneID string `json:"publicZoneID,omitempty"`
+
+	// +optional
+	PrivateZoneID string `json:"privateZoneID,omitempty"`
+}
+
+type UpgradeStage string
+
+const (
+	UpgradeStageControlPlane UpgradeStage = "ControlPlane"
+	UpgradeStageNodePools    UpgradeStage = "NodePools"
+	UpgradeStageCompleted    UpgradeStage = "Completed"
+)
+
+type UpgradeStatus struct {
+	// TargetImage is the release image being rolled out
+	TargetImage string `json:"targetImage"`
+
+	// Stage is the part of the cluster being upgraded: ControlPlane, NodePools or Completed
+	Stage UpgradeStage `json:"stage"`
+
+	// UpdatingNodePools are the NodePools in the current batch
+	// +optional
+	UpdatingNodePools []string `json:"updatingNodePools,omitempty"`
+
+	// UpdatedNodePools are the NodePools running the target release
+	// +optional
+	UpdatedNodePools []string `json:"updatedNodePools,omitempty"`
+
+	// StartTime is when the upgrade to the target release was detected
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when all the NodePools were running the target release
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+type MigrationStep string
+
+const (
+	MigrationStepPausing      MigrationStep = "Pausing"
+	MigrationStepBackingUp    MigrationStep = "BackingUp"
+	MigrationStepApplying     MigrationStep = "Applying"
+	MigrationStepRestoring    MigrationStep = "Restoring"
+	MigrationStepSwitchingDNS MigrationStep = "SwitchingDNS"
+	MigrationStepCleaningUp   MigrationStep = "CleaningUp"
+	MigrationStepCompleted    MigrationStep = "Completed"
+	MigrationStepRollingBack  MigrationStep = "RollingBack"
+	MigrationStepRolledBack   MigrationStep = "RolledBack"
+)
+
+type MigrationStatus struct {
+	// Source is the hosting cluster the HostedCluster is moved from
+	Source string `json:"source"`
+
+	// SourceLeftovers lists the resources orphaned on the source hosting cluster once the migration completed. They
+	// must be removed there manually, after their finalizers, as deleting them destroys the cloud resources the
+	// target uses
+	// +optional
+	SourceLeftovers []string `json:"sourceLeftovers,omitempty"`
+
+	// Target is the hosting cluster the HostedCluster is moved to
+	Target string `json:"target"`
+
+	// Step is Pausing, BackingUp, Applying, Restoring, SwitchingDNS, CleaningUp, Completed, RollingBack or RolledBack
+	Step MigrationStep `json:"step"`
+
+	// StepStartTime is when the current step started, a step longer than Spec.Migration.StepTimeout is rolled back
+	StepStartTime metav1.Time `json:"stepStartTime"`
+
+	// FailedStep is the step that caused the rollback
+	// +optional
+	FailedStep MigrationStep `json:"failedStep,omitempty"`
+
+	// StartTime is when the change of Spec.HostingCluster was detected
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the migration completed or was rolled back
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=hypershiftdeployments,shortName=hd;hds,scope=Namespaced
-// +kubebuilder:storageversion
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".spec.hostedClusterSpec.platform.type",description="Infrastructure type"
+// +kubebuilder:printcolumn:name="PHASE",type="string",JSONPath=".status.phase",description="Phase"
 // +kubebuilder:printcolumn:name="INFRA",type="string",JSONPath=".status.conditions[?(@.type==\"PlatformInfrastructureConfigured\")].reason",description="Reason"
 // +kubebuilder:printcolumn:name="IAM",type="string",JSONPath=".status.conditions[?(@.type==\"PlatformIAMConfigured\")].reason",description="Reason"
 // +kubebuilder:printcolumn:name="MANIFESTWORK",type="string",JSONPath=".status.conditions[?(@.type==\"ManifestWorkConfigured\")].reason",description="Reason"
 // +kubebuilder:printcolumn:name="PROVIDER REF",type="string",JSONPath=".status.conditions[?(@.type==\"ProviderSecretConfigured\")].reason",description="Reason"
 // +kubebuilder:printcolumn:name="PROGRESS",type="string",JSONPath=".status.conditions[?(@.type==\"HostedClusterProgress\")].reason",description="Reason"
 // +kubebuilder:printcolumn:name="AVAILABLE",type="string",JSONPath=".status.conditions[?(@.type==\"HostedClusterAvailable\")].status",description="Available"
+// +kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.version",description="Version"
 
 // HypershiftDeployment is the Schema for the hypershiftDeployments API
 type HypershiftDeployment struct {
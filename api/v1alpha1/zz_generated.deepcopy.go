@@ -43,6 +43,47 @@ func (in *AWSCredentials) DeepCopy() *AWSCredentials {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSIAMStatus) DeepCopyInto(out *AWSIAMStatus) {
+	*out = *in
+	out.Roles = in.Roles
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSIAMStatus.
+func (in *AWSIAMStatus) DeepCopy() *AWSIAMStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSIAMStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSInfrastructureStatus) DeepCopyInto(out *AWSInfrastructureStatus) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]AWSZoneStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.IAM != nil {
+		in, out := &in.IAM, &out.IAM
+		*out = new(AWSIAMStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSInfrastructureStatus.
+func (in *AWSInfrastructureStatus) DeepCopy() *AWSInfrastructureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSInfrastructureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWSPlatform) DeepCopyInto(out *AWSPlatform) {
 	*out = *in
@@ -63,6 +104,71 @@ func (in *AWSPlatform) DeepCopy() *AWSPlatform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSZoneStatus) DeepCopyInto(out *AWSZoneStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSZoneStatus.
+func (in *AWSZoneStatus) DeepCopy() *AWSZoneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSZoneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdoptSpec) DeepCopyInto(out *AdoptSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdoptSpec.
+func (in *AdoptSpec) DeepCopy() *AdoptSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AdoptSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentPlatform) DeepCopyInto(out *AgentPlatform) {
+	*out = *in
+	if in.AgentLabelSelector != nil {
+		in, out := &in.AgentLabelSelector, &out.AgentLabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentPlatform.
+func (in *AgentPlatform) DeepCopy() *AgentPlatform {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentPlatform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureInfrastructureStatus) DeepCopyInto(out *AzureInfrastructureStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureInfrastructureStatus.
+func (in *AzureInfrastructureStatus) DeepCopy() *AzureInfrastructureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureInfrastructureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzurePlatform) DeepCopyInto(out *AzurePlatform) {
 	*out = *in
@@ -78,6 +184,21 @@ func (in *AzurePlatform) DeepCopy() *AzurePlatform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CopiedSourceStatus) DeepCopyInto(out *CopiedSourceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CopiedSourceStatus.
+func (in *CopiedSourceStatus) DeepCopy() *CopiedSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CopiedSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CredentialARNs) DeepCopyInto(out *CredentialARNs) {
 	*out = *in
@@ -98,6 +219,21 @@ func (in *CredentialARNs) DeepCopy() *CredentialARNs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionPolicy) DeepCopyInto(out *DeletionPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionPolicy.
+func (in *DeletionPolicy) DeepCopy() *DeletionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HypershiftDeployment) DeepCopyInto(out *HypershiftDeployment) {
 	*out = *in
@@ -161,6 +297,16 @@ func (in *HypershiftDeploymentList) DeepCopyObject() runtime.Object {
 func (in *HypershiftDeploymentSpec) DeepCopyInto(out *HypershiftDeploymentSpec) {
 	*out = *in
 	in.Infrastructure.DeepCopyInto(&out.Infrastructure)
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(DeletionPolicy)
+		**out = **in
+	}
+	if in.HostingClusterPlacement != nil {
+		in, out := &in.HostingClusterPlacement, &out.HostingClusterPlacement
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 	if in.HostedClusterSpec != nil {
 		in, out := &in.HostedClusterSpec, &out.HostedClusterSpec
 		*out = new(apiv1alpha1.HostedClusterSpec)
@@ -178,6 +324,11 @@ func (in *HypershiftDeploymentSpec) DeepCopyInto(out *HypershiftDeploymentSpec)
 			}
 		}
 	}
+	if in.Adopt != nil {
+		in, out := &in.Adopt, &out.Adopt
+		*out = new(AdoptSpec)
+		**out = **in
+	}
 	if in.NodePoolsRef != nil {
 		in, out := &in.NodePoolsRef, &out.NodePoolsRef
 		*out = make([]v1.LocalObjectReference, len(*in))
@@ -188,6 +339,16 @@ func (in *HypershiftDeploymentSpec) DeepCopyInto(out *HypershiftDeploymentSpec)
 		*out = new(CredentialARNs)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeSpec)
+		**out = **in
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(MigrationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HypershiftDeploymentSpec.
@@ -210,6 +371,62 @@ func (in *HypershiftDeploymentStatus) DeepCopyInto(out *HypershiftDeploymentStat
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PhaseTransitionTime != nil {
+		in, out := &in.PhaseTransitionTime, &out.PhaseTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Infrastructure != nil {
+		in, out := &in.Infrastructure, &out.Infrastructure
+		*out = new(InfrastructureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InfraRetry != nil {
+		in, out := &in.InfraRetry, &out.InfraRetry
+		*out = new(InfraRetryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CopiedSources != nil {
+		in, out := &in.CopiedSources, &out.CopiedSources
+		*out = make([]CopiedSourceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(MigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodePools != nil {
+		in, out := &in.NodePools, &out.NodePools
+		*out = make([]NodePoolStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Kubeconfig != nil {
+		in, out := &in.Kubeconfig, &out.Kubeconfig
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.KubeadminPassword != nil {
+		in, out := &in.KubeadminPassword, &out.KubeadminPassword
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.AdminKubeconfigSecret != nil {
+		in, out := &in.AdminKubeconfigSecret, &out.AdminKubeconfigSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.KubeadminPasswordSecret != nil {
+		in, out := &in.KubeadminPasswordSecret, &out.KubeadminPasswordSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HypershiftDeploymentStatus.
@@ -226,6 +443,11 @@ func (in *HypershiftDeploymentStatus) DeepCopy() *HypershiftDeploymentStatus {
 func (in *HypershiftNodePools) DeepCopyInto(out *HypershiftNodePools) {
 	*out = *in
 	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(NodePoolPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HypershiftNodePools.
@@ -238,6 +460,25 @@ func (in *HypershiftNodePools) DeepCopy() *HypershiftNodePools {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InfraRetryStatus) DeepCopyInto(out *InfraRetryStatus) {
+	*out = *in
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InfraRetryStatus.
+func (in *InfraRetryStatus) DeepCopy() *InfraRetryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InfraRetryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InfraSpec) DeepCopyInto(out *InfraSpec) {
 	*out = *in
@@ -259,6 +500,191 @@ func (in *InfraSpec) DeepCopy() *InfraSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InfrastructureStatus) DeepCopyInto(out *InfrastructureStatus) {
+	*out = *in
+	if in.LastVerifiedTime != nil {
+		in, out := &in.LastVerifiedTime, &out.LastVerifiedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(AWSInfrastructureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureInfrastructureStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InfrastructureStatus.
+func (in *InfrastructureStatus) DeepCopy() *InfrastructureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InfrastructureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtPlatform) DeepCopyInto(out *KubeVirtPlatform) {
+	*out = *in
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Cores != nil {
+		in, out := &in.Cores, &out.Cores
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.RootVolumeSize != nil {
+		in, out := &in.RootVolumeSize, &out.RootVolumeSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtPlatform.
+func (in *KubeVirtPlatform) DeepCopy() *KubeVirtPlatform {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtPlatform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
+	*out = *in
+	if in.BackupSecret != nil {
+		in, out := &in.BackupSecret, &out.BackupSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.StepTimeout != nil {
+		in, out := &in.StepTimeout, &out.StepTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationSpec.
+func (in *MigrationSpec) DeepCopy() *MigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
+	*out = *in
+	if in.SourceLeftovers != nil {
+		in, out := &in.SourceLeftovers, &out.SourceLeftovers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StepStartTime.DeepCopyInto(&out.StepStartTime)
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationStatus.
+func (in *MigrationStatus) DeepCopy() *MigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolAutoScalingPolicy) DeepCopyInto(out *NodePoolAutoScalingPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolAutoScalingPolicy.
+func (in *NodePoolAutoScalingPolicy) DeepCopy() *NodePoolAutoScalingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolAutoScalingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolPolicy) DeepCopyInto(out *NodePoolPolicy) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AutoScaling != nil {
+		in, out := &in.AutoScaling, &out.AutoScaling
+		*out = new(NodePoolAutoScalingPolicy)
+		**out = **in
+	}
+	if in.ScaleDownWindows != nil {
+		in, out := &in.ScaleDownWindows, &out.ScaleDownWindows
+		*out = make([]ScaleDownWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolPolicy.
+func (in *NodePoolPolicy) DeepCopy() *NodePoolPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolStatus) DeepCopyInto(out *NodePoolStatus) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AutoScaling != nil {
+		in, out := &in.AutoScaling, &out.AutoScaling
+		*out = new(apiv1alpha1.NodePoolAutoScaling)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolStatus.
+func (in *NodePoolStatus) DeepCopy() *NodePoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Platforms) DeepCopyInto(out *Platforms) {
 	*out = *in
@@ -272,6 +698,16 @@ func (in *Platforms) DeepCopyInto(out *Platforms) {
 		*out = new(AWSPlatform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.KubeVirt != nil {
+		in, out := &in.KubeVirt, &out.KubeVirt
+		*out = new(KubeVirtPlatform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Agent != nil {
+		in, out := &in.Agent, &out.Agent
+		*out = new(AgentPlatform)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Platforms.
@@ -283,3 +719,71 @@ func (in *Platforms) DeepCopy() *Platforms {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleDownWindow) DeepCopyInto(out *ScaleDownWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleDownWindow.
+func (in *ScaleDownWindow) DeepCopy() *ScaleDownWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleDownWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSpec) DeepCopyInto(out *UpgradeSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSpec.
+func (in *UpgradeSpec) DeepCopy() *UpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+	if in.UpdatingNodePools != nil {
+		in, out := &in.UpdatingNodePools, &out.UpdatingNodePools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpdatedNodePools != nil {
+		in, out := &in.UpdatedNodePools, &out.UpdatedNodePools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
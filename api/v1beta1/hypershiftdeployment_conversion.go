@@ -0,0 +1,239 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	hypv1alpha1 "github.com/openshift/hypershift/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// V1alpha1FieldsAnnotation keeps the v1alpha1 fields v1beta1 can not express, so a v1alpha1 object converted to
+// v1beta1 and back is unchanged
+const V1alpha1FieldsAnnotation = "hypershiftdeployment.cluster.open-cluster-management.io/v1alpha1-fields"
+
+// v1alpha1Fields are the v1alpha1 fields without a v1beta1 equivalent
+type v1alpha1Fields struct {
+	// Override, ProvisioningScope and DeletionPolicy are kept when the infrastructure mode and the deletion policy
+	// do not convert back to them
+	Override          v1alpha1.InfraOverride     `json:"override,omitempty"`
+	ProvisioningScope v1alpha1.ProvisioningScope `json:"provisioningScope,omitempty"`
+	DeletionPolicy    *v1alpha1.DeletionPolicy   `json:"deletionPolicy,omitempty"`
+
+	// Credentials and the platform.aws.roles of the HostedClusterSpec are superseded by the platform.aws.rolesRef,
+	// the controller moves them to the rolesRef of a new HostedCluster
+	Credentials *v1alpha1.CredentialARNs         `json:"credentials,omitempty"`
+	Roles       []hypv1alpha1.AWSRoleCredentials `json:"roles,omitempty"`
+}
+
+var _ conversion.Convertible = &HypershiftDeployment{}
+
+// ConvertTo converts the HypershiftDeployment to the v1alpha1 hub version
+func (src *HypershiftDeployment) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.HypershiftDeployment)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 HypershiftDeployment but got a %T", dstRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	fields, err := popV1alpha1Fields(&dst.ObjectMeta)
+	if err != nil {
+		return err
+	}
+
+	spec := src.Spec.DeepCopy()
+	mode := spec.Infrastructure.mode()
+	dst.Spec = v1alpha1.HypershiftDeploymentSpec{
+		Infrastructure: v1alpha1.InfraSpec{
+			Configure:     mode != InfraModeUserProvided,
+			Platform:      spec.Infrastructure.Platform,
+			CloudProvider: spec.Infrastructure.CloudProvider,
+			RepairDrift:   spec.Infrastructure.RepairDrift,
+		},
+		InfraID:                 spec.InfraID,
+		DeletionPolicy:          spec.DeletionPolicy,
+		ProvisioningScope:       provisioningScopeFor(mode),
+		HostingNamespace:        spec.HostingNamespace,
+		HostingCluster:          spec.HostingCluster,
+		HostingClusterPlacement: spec.HostingClusterPlacement,
+		HostedClusterSpec:       spec.HostedClusterSpec,
+		HostedClusterRef:        spec.HostedClusterRef,
+		NodePools:               spec.NodePools,
+		Adopt:                   spec.Adopt,
+		HostedManagedClusterSet: spec.HostedManagedClusterSet,
+		SyncKubeadminPassword:   spec.SyncKubeadminPassword,
+		NodePoolsRef:            spec.NodePoolsRef,
+		Credentials:             fields.Credentials,
+		Upgrade:                 spec.Upgrade,
+		Migration:               spec.Migration,
+		Paused:                  spec.Paused,
+	}
+
+	// The v1alpha1 fields are restored as long as the v1beta1 fields they were converted to are unchanged
+	stored := &v1alpha1.HypershiftDeploymentSpec{
+		Infrastructure:    v1alpha1.InfraSpec{Configure: dst.Spec.Infrastructure.Configure},
+		Override:          fields.Override,
+		ProvisioningScope: fields.ProvisioningScope,
+		DeletionPolicy:    fields.DeletionPolicy,
+	}
+	if hasV1alpha1Policy(stored) && infraModeFor(stored) == mode &&
+		reflect.DeepEqual(deletionPolicyFor(stored), spec.DeletionPolicy) {
+		dst.Spec.Override = stored.Override
+		dst.Spec.ProvisioningScope = stored.ProvisioningScope
+		dst.Spec.DeletionPolicy = stored.DeletionPolicy
+	}
+
+	if fields.Roles != nil && dst.Spec.HostedClusterSpec != nil && dst.Spec.HostedClusterSpec.Platform.AWS != nil {
+		dst.Spec.HostedClusterSpec.Platform.AWS.Roles = fields.Roles
+	}
+
+	src.Status.DeepCopyInto(&dst.Status)
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to this HypershiftDeployment
+func (dst *HypershiftDeployment) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.HypershiftDeployment)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 HypershiftDeployment but got a %T", srcRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	delete(dst.Annotations, V1alpha1FieldsAnnotation)
+
+	spec := src.Spec.DeepCopy()
+	fields := v1alpha1Fields{Credentials: spec.Credentials}
+	if spec.HostedClusterSpec != nil && spec.HostedClusterSpec.Platform.AWS != nil {
+		fields.Roles = spec.HostedClusterSpec.Platform.AWS.Roles
+		spec.HostedClusterSpec.Platform.AWS.Roles = nil
+	}
+
+	mode := infraModeFor(spec)
+	dst.Spec = HypershiftDeploymentSpec{
+		Infrastructure: InfraSpec{
+			Mode:          mode,
+			Platform:      spec.Infrastructure.Platform,
+			CloudProvider: spec.Infrastructure.CloudProvider,
+			RepairDrift:   spec.Infrastructure.RepairDrift,
+		},
+		InfraID:                 spec.InfraID,
+		DeletionPolicy:          deletionPolicyFor(spec),
+		HostingNamespace:        spec.HostingNamespace,
+		HostingCluster:          spec.HostingCluster,
+		HostingClusterPlacement: spec.HostingClusterPlacement,
+		HostedClusterSpec:       spec.HostedClusterSpec,
+		HostedClusterRef:        spec.HostedClusterRef,
+		NodePools:               spec.NodePools,
+		Adopt:                   spec.Adopt,
+		HostedManagedClusterSet: spec.HostedManagedClusterSet,
+		SyncKubeadminPassword:   spec.SyncKubeadminPassword,
+		NodePoolsRef:            spec.NodePoolsRef,
+		Upgrade:                 spec.Upgrade,
+		Migration:               spec.Migration,
+		Paused:                  spec.Paused,
+	}
+
+	if len(spec.Override) != 0 || spec.ProvisioningScope != provisioningScopeFor(mode) ||
+		!reflect.DeepEqual(spec.DeletionPolicy, dst.Spec.DeletionPolicy) {
+		fields.Override = spec.Override
+		fields.ProvisioningScope = spec.ProvisioningScope
+		fields.DeletionPolicy = spec.DeletionPolicy
+	}
+	if err := pushV1alpha1Fields(&dst.ObjectMeta, fields); err != nil {
+		return err
+	}
+
+	src.Status.DeepCopyInto(&dst.Status)
+	return nil
+}
+
+// mode defaults to UserProvided, like configure: false in v1alpha1
+func (s *InfraSpec) mode() InfraMode {
+	if len(s.Mode) == 0 {
+		return InfraModeUserProvided
+	}
+	return s.Mode
+}
+
+func infraModeFor(spec *v1alpha1.HypershiftDeploymentSpec) InfraMode {
+	switch {
+	case !spec.Infrastructure.Configure:
+		return InfraModeUserProvided
+	case spec.IsInfrastructureOnly():
+		return InfraModeConfigureOnly
+	}
+	return InfraModeConfigure
+}
+
+func provisioningScopeFor(mode InfraMode) v1alpha1.ProvisioningScope {
+	if mode == InfraModeConfigureOnly {
+		return v1alpha1.ProvisioningScopeInfrastructureOnly
+	}
+	return ""
+}
+
+// deletionPolicyFor folds the deprecated override into the deletion policy, the defaults are not set
+func deletionPolicyFor(spec *v1alpha1.HypershiftDeploymentSpec) *v1alpha1.DeletionPolicy {
+	policy := spec.DeletionPolicy.Merge(v1alpha1.OverrideDeletionPolicy(spec.Override))
+	if policy == (v1alpha1.DeletionPolicy{}) {
+		return nil
+	}
+	return &policy
+}
+
+func hasV1alpha1Policy(spec *v1alpha1.HypershiftDeploymentSpec) bool {
+	return len(spec.Override) != 0 || len(spec.ProvisioningScope) != 0 || spec.DeletionPolicy != nil
+}
+
+func popV1alpha1Fields(meta *metav1.ObjectMeta) (v1alpha1Fields, error) {
+	fields := v1alpha1Fields{}
+	data, ok := meta.Annotations[V1alpha1FieldsAnnotation]
+	if !ok {
+		return fields, nil
+	}
+
+	delete(meta.Annotations, V1alpha1FieldsAnnotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return fields, fmt.Errorf("invalid %s annotation, err: %w", V1alpha1FieldsAnnotation, err)
+	}
+	return fields, nil
+}
+
+func pushV1alpha1Fields(meta *metav1.ObjectMeta, fields v1alpha1Fields) error {
+	if reflect.DeepEqual(fields, v1alpha1Fields{}) {
+		return nil
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[V1alpha1FieldsAnnotation] = string(data)
+	return nil
+}
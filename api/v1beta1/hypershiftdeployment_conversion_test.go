@@ -0,0 +1,211 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	"github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+func getV1alpha1HypershiftDeployment() *v1alpha1.HypershiftDeployment {
+	replicas := int32(2)
+	return &v1alpha1.HypershiftDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hd",
+			Namespace:   "default",
+			Labels:      map[string]string{"hypershift.openshift.io/infra-id": "hd-abcde"},
+			Annotations: map[string]string{"note": "kept"},
+			Finalizers:  []string{"hypershiftdeployment.cluster.open-cluster-management.io/finalizer"},
+		},
+		Spec: v1alpha1.HypershiftDeploymentSpec{
+			Infrastructure: v1alpha1.InfraSpec{
+				Configure:     true,
+				Platform:      &v1alpha1.Platforms{AWS: &v1alpha1.AWSPlatform{Region: "us-east-1", Zones: []string{"us-east-1a"}}},
+				CloudProvider: corev1.LocalObjectReference{Name: "aws-creds"},
+				RepairDrift:   true,
+			},
+			InfraID:                 "hd-abcde",
+			HostingNamespace:        "clusters",
+			HostingCluster:          "local-cluster",
+			HostingClusterPlacement: &corev1.LocalObjectReference{Name: "hosting"},
+			HostedClusterSpec: &hyp.HostedClusterSpec{
+				Release:  hyp.Release{Image: "quay.io/openshift-release-dev/ocp-release:4.11.2-x86_64"},
+				Platform: hyp.PlatformSpec{Type: hyp.AWSPlatform, AWS: &hyp.AWSPlatformSpec{Region: "us-east-1"}},
+			},
+			NodePools: []*v1alpha1.HypershiftNodePools{{
+				Name:   "workers",
+				Spec:   hyp.NodePoolSpec{Replicas: &replicas},
+				Policy: &v1alpha1.NodePoolPolicy{Replicas: &replicas},
+			}},
+			Adopt:                   &v1alpha1.AdoptSpec{Image: "quay.io/openshift/origin-cli:4.11"},
+			HostedManagedClusterSet: "hosted",
+			SyncKubeadminPassword:   true,
+			NodePoolsRef:            []corev1.LocalObjectReference{{Name: "np"}},
+			Upgrade:                 &v1alpha1.UpgradeSpec{NodePoolBatchSize: 2},
+			Migration:               &v1alpha1.MigrationSpec{BackupImage: "etcd"},
+			Paused:                  true,
+		},
+		Status: v1alpha1.HypershiftDeploymentStatus{
+			Phase:          v1alpha1.PhaseAvailable,
+			HostingCluster: "local-cluster",
+			Version:        "4.11.2",
+			Conditions: []metav1.Condition{{
+				Type:   string(v1alpha1.HostedClusterAvailable),
+				Status: metav1.ConditionTrue,
+				Reason: v1alpha1.AsExpectedReason,
+			}},
+		},
+	}
+}
+
+func roundTrip(t *testing.T, hd *v1alpha1.HypershiftDeployment) (*HypershiftDeployment, *v1alpha1.HypershiftDeployment) {
+	beta := &HypershiftDeployment{}
+	assert.Nil(t, beta.ConvertFrom(hd.DeepCopy()))
+
+	alpha := &v1alpha1.HypershiftDeployment{}
+	assert.Nil(t, beta.DeepCopy().ConvertTo(alpha))
+	return beta, alpha
+}
+
+func TestConvertV1alpha1RoundTrip(t *testing.T) {
+	orphan := v1alpha1.DeletionPolicyOrphan
+	cases := []struct {
+		name      string
+		configure bool
+		override  v1alpha1.InfraOverride
+		scope     v1alpha1.ProvisioningScope
+		policy    *v1alpha1.DeletionPolicy
+		mode      InfraMode
+		expected  *v1alpha1.DeletionPolicy
+	}{
+		{name: "configure", configure: true, mode: InfraModeConfigure},
+		{name: "user provided", mode: InfraModeUserProvided},
+		{name: "infra only", configure: true, override: v1alpha1.InfraConfigureOnly, mode: InfraModeConfigureOnly},
+		{
+			name: "orphan", configure: true, override: v1alpha1.InfraOverrideDestroy, mode: InfraModeConfigure,
+			expected: &v1alpha1.DeletionPolicy{HostedCluster: orphan, Infrastructure: orphan, IAM: orphan},
+		},
+		{
+			name: "delete hosting namespace", override: v1alpha1.DeleteHostingNamespace, mode: InfraModeUserProvided,
+			expected: &v1alpha1.DeletionPolicy{HostingNamespace: v1alpha1.DeletionPolicyDelete},
+		},
+		{name: "infra only without configure", override: v1alpha1.InfraConfigureOnly, mode: InfraModeUserProvided},
+		{
+			name: "infrastructure only scope", configure: true, scope: v1alpha1.ProvisioningScopeInfrastructureOnly,
+			mode: InfraModeConfigureOnly,
+		},
+		{name: "all scope", configure: true, scope: v1alpha1.ProvisioningScopeAll, mode: InfraModeConfigure},
+		{
+			name: "deletion policy", configure: true, mode: InfraModeConfigure,
+			policy:   &v1alpha1.DeletionPolicy{IAM: orphan, ManagedCluster: orphan},
+			expected: &v1alpha1.DeletionPolicy{IAM: orphan, ManagedCluster: orphan},
+		},
+		{
+			name: "deletion policy wins over the override", configure: true, override: v1alpha1.InfraOverrideDestroy,
+			mode:     InfraModeConfigure,
+			policy:   &v1alpha1.DeletionPolicy{IAM: v1alpha1.DeletionPolicyDelete},
+			expected: &v1alpha1.DeletionPolicy{HostedCluster: orphan, Infrastructure: orphan, IAM: v1alpha1.DeletionPolicyDelete},
+		},
+		{
+			name: "empty deletion policy", configure: true, mode: InfraModeConfigure,
+			policy: &v1alpha1.DeletionPolicy{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hd := getV1alpha1HypershiftDeployment()
+			hd.Spec.Infrastructure.Configure = c.configure
+			hd.Spec.Override = c.override
+			hd.Spec.ProvisioningScope = c.scope
+			hd.Spec.DeletionPolicy = c.policy
+
+			beta, alpha := roundTrip(t, hd)
+			assert.Equal(t, c.mode, beta.Spec.Infrastructure.Mode)
+			assert.Equal(t, c.expected, beta.Spec.DeletionPolicy)
+			assert.Equal(t, hd, alpha, "the v1alpha1 object is unchanged")
+		})
+	}
+}
+
+func TestConvertV1alpha1Credentials(t *testing.T) {
+	hd := getV1alpha1HypershiftDeployment()
+	hd.Annotations = nil
+	hd.Spec.Credentials = &v1alpha1.CredentialARNs{AWS: &v1alpha1.AWSCredentials{
+		ControlPlaneOperatorARN: "arn:aws:iam::123456789123:role/cpo",
+		KubeCloudControllerARN:  "arn:aws:iam::123456789123:role/kcc",
+		NodePoolManagementARN:   "arn:aws:iam::123456789123:role/npm",
+	}}
+	hd.Spec.HostedClusterSpec.Platform.AWS.Roles = []hyp.AWSRoleCredentials{
+		{ARN: "arn:aws:iam::123456789123:role/test-openshift-ingress", Namespace: "openshift-ingress-operator", Name: "cloud-credentials"},
+		{ARN: "arn:aws:iam::123456789123:role/test-openshift-image-registry", Namespace: "openshift-image-registry", Name: "installer-cloud-credentials"},
+	}
+
+	beta, alpha := roundTrip(t, hd)
+	assert.Contains(t, beta.Annotations[V1alpha1FieldsAnnotation], "arn:aws:iam::123456789123:role/cpo")
+	assert.Contains(t, beta.Annotations[V1alpha1FieldsAnnotation], "arn:aws:iam::123456789123:role/test-openshift-ingress")
+	assert.Nil(t, beta.Spec.HostedClusterSpec.Platform.AWS.Roles, "the roles are only kept in the annotation")
+	assert.Equal(t, hyp.AWSRolesRef{}, beta.Spec.HostedClusterSpec.Platform.AWS.RolesRef, "the rolesRef is not changed")
+
+	expected, err := json.Marshal(hd)
+	assert.Nil(t, err)
+	actual, err := json.Marshal(alpha)
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), string(actual), "the credentials and roles are restored from the annotation")
+
+	// Without a HostedClusterSpec, only the credentials are kept
+	hd.Spec.HostedClusterSpec = nil
+	_, alpha = roundTrip(t, hd)
+	assert.Equal(t, hd, alpha)
+}
+
+func TestConvertV1beta1(t *testing.T) {
+	beta := &HypershiftDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "hd", Namespace: "default"},
+		Spec: HypershiftDeploymentSpec{
+			InfraID:        "hd-abcde",
+			Infrastructure: InfraSpec{Mode: InfraModeConfigure},
+			DeletionPolicy: &v1alpha1.DeletionPolicy{Infrastructure: v1alpha1.DeletionPolicyOrphan},
+		},
+	}
+
+	alpha := &v1alpha1.HypershiftDeployment{}
+	assert.Nil(t, beta.ConvertTo(alpha))
+	assert.True(t, alpha.Spec.Infrastructure.Configure)
+	assert.Equal(t, "hd-abcde", alpha.Spec.InfraID)
+	assert.Equal(t, beta.Spec.DeletionPolicy, alpha.Spec.DeletionPolicy)
+	assert.Empty(t, alpha.Spec.Override)
+	assert.Equal(t, v1alpha1.DeletionPolicyDelete, alpha.Spec.GetDeletionPolicy().IAM, "only the infrastructure is orphaned")
+
+	beta.Spec.Infrastructure.Mode = InfraModeConfigureOnly
+	assert.Nil(t, beta.ConvertTo(alpha))
+	assert.True(t, alpha.Spec.IsInfrastructureOnly())
+
+	// A stale override is not restored once the v1beta1 fields changed
+	beta.Spec.Infrastructure.Mode = InfraModeConfigure
+	beta.Spec.DeletionPolicy = nil
+	beta.Annotations = map[string]string{V1alpha1FieldsAnnotation: `{"override":"INFRA-ONLY"}`}
+	assert.Nil(t, beta.ConvertTo(alpha))
+	assert.Empty(t, alpha.Spec.Override)
+	assert.Nil(t, alpha.Annotations, "the annotation is internal to the conversion")
+
+	beta.Annotations = map[string]string{V1alpha1FieldsAnnotation: "{"}
+	assert.NotNil(t, beta.ConvertTo(alpha), "an invalid annotation is reported")
+}
+
+func TestIsConvertible(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, v1alpha1.AddToScheme(scheme))
+	assert.Nil(t, AddToScheme(scheme))
+
+	convertible, err := conversion.IsConvertible(scheme, &v1alpha1.HypershiftDeployment{})
+	assert.Nil(t, err)
+	assert.True(t, convertible, "the conversion webhook is registered for the HypershiftDeployment")
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	hypv1alpha1 "github.com/openshift/hypershift/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// The types that did not change from v1alpha1, the platforms, NodePools, adoption, deletion policy, upgrade,
+// migration and the status, are shared with v1alpha1
+
+type InfraMode string
+
+const (
+	// InfraModeConfigure creates the cloud infrastructure and IAM, then applies the HostedCluster
+	InfraModeConfigure InfraMode = "Configure"
+	// InfraModeConfigureOnly only creates the cloud infrastructure and IAM, no HostedCluster is applied
+	InfraModeConfigureOnly InfraMode = "ConfigureOnly"
+	// InfraModeUserProvided applies the HostedCluster on infrastructure provided by the user
+	InfraModeUserProvided InfraMode = "UserProvided"
+)
+
+// HypershiftDeploymentSpec defines the desired state of HypershiftDeployment
+type HypershiftDeploymentSpec struct {
+	// Infrastructure instructions and pointers so either ClusterDeployment generates what is needed or
+	// skips it when the user provides the infrastructure values
+	// +immutable
+	Infrastructure InfraSpec `json:"infrastructure"`
+
+	// InfraID is used to tag resources in the Cloud Provider, it will be generated if not provided
+	// +immutable
+	// +optional
+	InfraID string `json:"infraID,omitempty"`
+
+	// DeletionPolicy sets, per kind of resource, what is removed when the HypershiftDeployment is deleted. If
+	// omitted, the hosting namespace is kept and the rest is deleted
+	// +optional
+	DeletionPolicy *v1alpha1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// HostingNamespace is the namespace of the HostedCluster and NodePools on the hosting cluster, defaults to
+	// the HypershiftDeployment namespace
+	// +optional
+	HostingNamespace string `json:"hostingNamespace,omitempty"`
+
+	// HostingCluster is the ManagedCluster the ManifestWork is applied to, the management cluster of the
+	// HostedCluster and NodePools. If not specified, HostingClusterPlacement is used
+	// +optional
+	HostingCluster string `json:"hostingCluster,omitempty"`
+
+	// HostingClusterPlacement references an OCM Placement in the HypershiftDeployment namespace, used when
+	// HostingCluster is empty. The hosting cluster is chosen from the PlacementDecisions once and recorded in
+	// Status.HostingCluster, a later decision does not move the HostedCluster
+	// +optional
+	HostingClusterPlacement *corev1.LocalObjectReference `json:"hostingClusterPlacement,omitempty"`
+
+	// HostedCluster that will be applied to the ManagementCluster by ACM, if omitted, it will be generated.
+	// The AWS IAM roles are set in platform.aws.rolesRef
+	// +optional
+	HostedClusterSpec *hypv1alpha1.HostedClusterSpec `json:"hostedClusterSpec,omitempty"`
+
+	// Reference to a HostedCluster on the HyperShift deployment namespace that will be applied to the
+	// ManagementCluster by ACM, required when Infrastructure.Mode is UserProvided and HostedClusterSpec is omitted
+	// +optional
+	HostedClusterRef corev1.LocalObjectReference `json:"hostedClusterReference,omitempty"`
+
+	// NodePools is an array of NodePool resources that will be applied to the ManagementCluster by ACM,
+	// if omitted, a default NodePool will be generated
+	// +optional
+	NodePools []*v1alpha1.HypershiftNodePools `json:"nodePools,omitempty"`
+
+	// Adopt takes over a HostedCluster named after the HypershiftDeployment, and its NodePools, that already exist
+	// in HostingNamespace on the hosting cluster. Their spec is read back into HostedClusterSpec and NodePools, then
+	// the ManifestWork manages them without recreating them
+	// +optional
+	Adopt *v1alpha1.AdoptSpec `json:"adopt,omitempty"`
+
+	// HostedManagedClusterSet is the ManagedClusterSet the hosted cluster should belong to.
+	// If omitted, the default is the hosting cluster's cluster set
+	// +optional
+	HostedManagedClusterSet string `json:"hostedManagedClusterSet,omitempty"`
+
+	// SyncKubeadminPassword copies the kubeadmin password Secret of the HostedCluster to the HypershiftDeployment
+	// namespace, with the admin kubeconfig. See Status.KubeadminPasswordSecret
+	// +optional
+	SyncKubeadminPassword bool `json:"syncKubeadminPassword,omitempty"`
+
+	// Reference to an array of NodePool resources on the HyperShift deployment namespace that will be applied
+	// to the ManagementCluster by ACM, required when Infrastructure.Mode is UserProvided and NodePools is omitted
+	// +optional
+	NodePoolsRef []corev1.LocalObjectReference `json:"nodePoolReferences,omitempty"`
+
+	// Upgrade controls how a change to HostedClusterSpec.Release.Image is rolled out. When set, the control plane
+	// is upgraded first, and the NodePools are moved to the new release once the control plane has settled.
+	// If omitted, the NodePools keep the release image in their spec
+	// +optional
+	Upgrade *v1alpha1.UpgradeSpec `json:"upgrade,omitempty"`
+
+	// Migration configures the move of the HostedCluster when HostingCluster is changed. The etcd backup
+	// settings are required when the HostedCluster uses a managed etcd
+	// +optional
+	Migration *v1alpha1.MigrationSpec `json:"migration,omitempty"`
+
+	// Paused stops the reconciliation of the HypershiftDeployment, the infrastructure, the ManifestWork and the
+	// ManagedCluster are not changed. A deletion waits until Paused is set back to false
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+type InfraSpec struct {
+	// Mode is Configure to create the cloud infrastructure and IAM before the HostedCluster, ConfigureOnly to only
+	// create the cloud infrastructure and IAM, or UserProvided when the infrastructure already exists
+	// +kubebuilder:validation:Enum=Configure;ConfigureOnly;UserProvided
+	// +kubebuilder:default=UserProvided
+	// +immutable
+	// +optional
+	Mode InfraMode `json:"mode,omitempty"`
+
+	// Platform is the cloud provider the infrastructure is configured on
+	//
+	// +optional
+	// +immutable
+	Platform *v1alpha1.Platforms `json:"platform,omitempty"`
+
+	// CloudProvider secret, contains the Cloud credenetial, Pull Secret and Base Domain
+	CloudProvider corev1.LocalObjectReference `json:"cloudProvider,omitempty"`
+
+	// RepairDrift re-runs the infrastructure and IAM configuration when resources recorded in
+	// Status.Infrastructure are found missing on the provider. Otherwise the drift is only reported
+	// with the PlatformInfrastructureDrifted condition
+	//
+	// +optional
+	RepairDrift bool `json:"repairDrift,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hypershiftdeployments,shortName=hd;hds,scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".spec.hostedClusterSpec.platform.type",description="Infrastructure type"
+// +kubebuilder:printcolumn:name="PHASE",type="string",JSONPath=".status.phase",description="Phase"
+// +kubebuilder:printcolumn:name="INFRA",type="string",JSONPath=".status.conditions[?(@.type==\"PlatformInfrastructureConfigured\")].reason",description="Reason"
+// +kubebuilder:printcolumn:name="IAM",type="string",JSONPath=".status.conditions[?(@.type==\"PlatformIAMConfigured\")].reason",description="Reason"
+// +kubebuilder:printcolumn:name="MANIFESTWORK",type="string",JSONPath=".status.conditions[?(@.type==\"ManifestWorkConfigured\")].reason",description="Reason"
+// +kubebuilder:printcolumn:name="PROVIDER REF",type="string",JSONPath=".status.conditions[?(@.type==\"ProviderSecretConfigured\")].reason",description="Reason"
+// +kubebuilder:printcolumn:name="PROGRESS",type="string",JSONPath=".status.conditions[?(@.type==\"HostedClusterProgress\")].reason",description="Reason"
+// +kubebuilder:printcolumn:name="AVAILABLE",type="string",JSONPath=".status.conditions[?(@.type==\"HostedClusterAvailable\")].status",description="Available"
+// +kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.version",description="Version"
+
+// HypershiftDeployment is the Schema for the hypershiftDeployments API
+type HypershiftDeployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HypershiftDeploymentSpec            `json:"spec,omitempty"`
+	Status v1alpha1.HypershiftDeploymentStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HypershiftDeploymentList contains a list of HypershiftDeployment
+type HypershiftDeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HypershiftDeployment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HypershiftDeployment{}, &HypershiftDeploymentList{})
+}
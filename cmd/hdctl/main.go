@@ -0,0 +1,165 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// hdctl renders the ManifestWork of a HypershiftDeployment from local files
+//
+//	hdctl render -f hd.yaml [-f secrets.yaml ...]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/yaml"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/controllers"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+
+	utilruntime.Must(hypdeployment.AddToScheme(scheme))
+
+	utilruntime.Must(hyp.AddToScheme(scheme))
+
+	utilruntime.Must(workv1.AddToScheme(scheme))
+}
+
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "render" {
+		fmt.Fprintln(os.Stderr, "usage: hdctl render -f hd.yaml [-f secrets.yaml ...]")
+		os.Exit(2)
+	}
+
+	var files fileList
+	renderCmd := flag.NewFlagSet("render", flag.ExitOnError)
+	renderCmd.Var(&files, "f", "A file with the HypershiftDeployment and the Secrets, ConfigMaps, HostedCluster and NodePools "+
+		"it references, can be repeated. Use - for the standard input.")
+	_ = renderCmd.Parse(os.Args[2:])
+
+	if err := render(os.Stdout, files); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render the manifestwork: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func render(out io.Writer, files []string) error {
+	if len(files) == 0 {
+		return errors.New("at least one file is required")
+	}
+
+	var hyd *hypdeployment.HypershiftDeployment
+	objects := []runtime.Object{}
+	for _, file := range files {
+		fileObjects, err := readObjects(file)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range fileObjects {
+			if h, ok := obj.(*hypdeployment.HypershiftDeployment); ok {
+				if hyd != nil {
+					return errors.New("only one HypershiftDeployment can be rendered")
+				}
+				hyd = h
+				continue
+			}
+			objects = append(objects, obj)
+		}
+	}
+
+	if hyd == nil {
+		return errors.New("no HypershiftDeployment was found")
+	}
+
+	m, err := controllers.RenderManifestWork(context.Background(), scheme, hyd, objects)
+	if err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(b)
+	return err
+}
+
+func readObjects(file string) ([]runtime.Object, error) {
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(file) // #nosec G304
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deserializer := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+
+	objects := []runtime.Object{}
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, _, err := deserializer.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		objects = append(objects, obj)
+	}
+}
@@ -9,8 +9,12 @@ const (
 
 	ManagedClusterCleanupFinalizer = "hypershiftdeployment.cluster.open-cluster-management.io/managedcluster-cleanup"
 
+	// ReleaseImage is the default release image when it is not configured by the ReleaseImageConfigMapName
 	ReleaseImage = "quay.io/openshift-release-dev/ocp-release:4.11.2-x86_64"
 
+	// ReleaseImageConfigMapName is the controller ConfigMap with the default release images per platform and architecture
+	ReleaseImageConfigMapName = "hypershift-deployment-release-images"
+
 	// DestroyFinalizer makes sure infrastructure is cleaned up before it is removed
 	DestroyFinalizer = "hypershiftdeployment.cluster.open-cluster-management.io/finalizer"
 
@@ -26,6 +30,27 @@ const (
 	// HostingClusterMissing message
 	HostingClusterMissing = "spec.hostingCluster value is missing"
 
+	// DryRunAnnotation set to "true" on a HypershiftDeployment previews the infrastructure plan and the ManifestWork
+	// changes in the DryRunConfigMapSuffix ConfigMap, nothing is created or applied
+	DryRunAnnotation = "hypershiftdeployment.cluster.open-cluster-management.io/dry-run"
+
+	// DryRunConfigMapSuffix is appended to the HypershiftDeployment name for the ConfigMap with the dry run result
+	DryRunConfigMapSuffix = "-dry-run"
+
+	// DetachAnnotation set to "true" on a HypershiftDeployment exports the rendered manifests to the
+	// ExportConfigMapSuffix ConfigMap, and orphans the HostedCluster and NodePools on the hosting cluster
+	DetachAnnotation = "hypershiftdeployment.cluster.open-cluster-management.io/detach"
+
+	// ExportConfigMapSuffix is appended to the HypershiftDeployment name for the ConfigMap with the exported manifests
+	ExportConfigMapSuffix = "-export"
+
+	// ExportSecretSuffix is appended to the HypershiftDeployment name for the Secret with the exported Secret manifests
+	ExportSecretSuffix = "-export-secrets"
+
+	// MaxHostedClustersAnnotation on a ManagedCluster overrides the number of HostedClusters it can host, "0" stops
+	// new HostedClusters from being scheduled on it
+	MaxHostedClustersAnnotation = "hypershiftdeployment.cluster.open-cluster-management.io/max-hosted-clusters"
+
 	// CreatedByHypershiftDeployment is an annotation that is used to show ownership via infra-ids
 	CreatedByHypershiftDeployment = "hypershift-deployment.open-cluster-management.io/created-by"
 
@@ -38,4 +63,15 @@ const (
 	// Provider secret fields
 	SSHPrivateKey = "ssh-privatekey"
 	SSHPublicKey  = "ssh-publickey"
+
+	// Provider secret fields selecting how the cloud credentials are obtained
+	CredentialSource      = "credential_source"
+	AWSAccessKeyID        = "aws_access_key_id"
+	AWSSecretAccessKey    = "aws_secret_access_key" // #nosec G101
+	AWSRoleARN            = "aws_role_arn"
+	AWSExternalID         = "aws_external_id"
+	AzureServicePrincipal = "osServicePrincipal.json"
+	AzureClientID         = "azure_client_id"
+	AzureTenantID         = "azure_tenant_id"
+	AzureSubscriptionID   = "azure_subscription_id"
 )
@@ -0,0 +1,307 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	condmeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+const (
+	// adoptJobBackoffLimit is the number of retries of the Job reading the HostedCluster
+	adoptJobBackoffLimit = 2
+	adoptJobFailed       = "failed"
+
+	// The annotations of the adoption ConfigMap the Job writes the spec to, read back with the status feedback
+	adoptHostedClusterKey = "hostedcluster"
+	adoptNodePoolsKey     = "nodepools"
+)
+
+// adoptScript writes the spec of the HostedCluster, and of the NodePools of its cluster, as JSON to the
+// annotations of the adoption ConfigMap
+const adoptScript = `set -e
+spec=$(kubectl get hostedclusters.hypershift.openshift.io "$NAME" -n "$NAMESPACE" -o jsonpath='{.spec}')
+nodepools=$(kubectl get nodepools.hypershift.openshift.io -n "$NAMESPACE" -o jsonpath='{range .items[?(@.spec.clusterName=="'"$NAME"'")]}{"{\"name\":\""}{.metadata.name}{"\",\"spec\":"}{.spec}{"},"}{end}')
+kubectl annotate configmap "$CONFIGMAP" -n "$NAMESPACE" --overwrite ` + adoptHostedClusterKey + `="$spec" ` + adoptNodePoolsKey + `="[${nodepools%,}]"`
+
+// reconcileAdoption reads the spec of the HostedCluster and NodePools of Spec.Adopt back from the hosting cluster
+// into the HypershiftDeployment. It returns true until the spec is read, the caller returns the result without
+// applying the manifestwork
+func (r *HypershiftDeploymentReconciler) reconcileAdoption(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (ctrl.Result, bool, error) {
+	if hyd.Spec.Adopt == nil {
+		return ctrl.Result{}, false, nil
+	}
+
+	hostingCluster := helper.GetHostingClusterName(hyd)
+	if hyd.Spec.HostedClusterSpec != nil {
+		if condmeta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.HostedClusterAdopted)) {
+			return ctrl.Result{}, false, nil
+		}
+
+		// The manifestwork that read the spec is no longer needed, the HostedCluster is taken over by the manifestwork
+		if err := r.deleteAdoptManifestWork(ctx, hyd); err != nil {
+			return ctrl.Result{}, false, err
+		}
+		r.recordEvent(hyd, corev1.EventTypeNormal, HostedClusterAdoptedEvent, "Adopted HostedCluster %s/%s from %s",
+			helper.GetHostingNamespace(hyd), hyd.Name, hostingCluster)
+		return ctrl.Result{}, false, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostedClusterAdopted, metav1.ConditionTrue,
+			"Adopted the HostedCluster from "+hostingCluster, hypdeployment.AsExpectedReason)
+	}
+
+	if len(hostingCluster) == 0 {
+		return ctrl.Result{}, true, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostedClusterAdopted, metav1.ConditionFalse,
+			constant.HostingClusterMissing, hypdeployment.MisConfiguredReason)
+	}
+
+	aw := scaffoldAdoptManifestWork(hyd)
+	payload := adoptPayload(hyd)
+	cfg := adoptFeedbackConfig(hyd)
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, aw, func() error {
+		aw.Spec.Workload.Manifests = payload
+		aw.Spec.ManifestConfigs = cfg
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, true, fmt.Errorf("failed to apply the adoption manifestwork to %s, err: %w", hostingCluster, err)
+	}
+	if op == controllerutil.OperationResultCreated {
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkCreatedEvent, "Created manifestwork %s/%s", aw.Namespace, aw.Name)
+	}
+
+	hcSpec, nodePools, failed, err := adoptFeedback(aw, hyd)
+	switch {
+	case err != nil:
+		return ctrl.Result{}, true, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostedClusterAdopted, metav1.ConditionFalse,
+			"The spec read from "+hostingCluster+" is invalid: "+err.Error(), hypdeployment.MisConfiguredReason)
+	case failed > adoptJobBackoffLimit:
+		return ctrl.Result{}, true, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostedClusterAdopted, metav1.ConditionFalse,
+			fmt.Sprintf("The job reading HostedCluster %s/%s failed on %s", helper.GetHostingNamespace(hyd), hyd.Name, hostingCluster),
+			hypdeployment.MisConfiguredReason)
+	case hcSpec == nil:
+		// The manifestwork is watched, its status feedback triggers the next reconcile
+		return ctrl.Result{}, true, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostedClusterAdopted, metav1.ConditionFalse,
+			fmt.Sprintf("Reading HostedCluster %s/%s from %s", helper.GetHostingNamespace(hyd), hyd.Name, hostingCluster),
+			hypdeployment.BeingConfiguredReason)
+	}
+
+	// The manifestwork is named after the infra-id, it is deleted before the infra-id of the HostedCluster is taken
+	if len(hcSpec.InfraID) != 0 && hcSpec.InfraID != hyd.Spec.InfraID {
+		if err := r.Delete(ctx, aw); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, true, fmt.Errorf("failed to delete the adoption manifestwork %s/%s, err: %w", aw.Namespace, aw.Name, err)
+		}
+		hyd.Spec.InfraID = hcSpec.InfraID
+		if hyd.Labels == nil {
+			hyd.Labels = map[string]string{}
+		}
+		hyd.Labels[constant.InfraLabelName] = hcSpec.InfraID
+	}
+
+	r.Log.Info("Adopting HostedCluster", "hostingCluster", hostingCluster, "nodePools", len(nodePools), "infraID", hyd.Spec.InfraID)
+	hyd.Spec.HostedClusterSpec = hcSpec
+	hyd.Spec.NodePools = nodePools
+	if err := r.patchHypershiftDeploymentResource(hyd); err != nil {
+		return ctrl.Result{}, true, fmt.Errorf("failed to set the adopted HostedClusterSpec, err: %w", err)
+	}
+	return ctrl.Result{Requeue: true}, true, nil
+}
+
+func (r *HypershiftDeploymentReconciler) deleteAdoptManifestWork(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) error {
+	aw := scaffoldAdoptManifestWork(hyd)
+	if len(aw.Namespace) == 0 {
+		return nil
+	}
+	if err := r.Delete(ctx, aw); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the adoption manifestwork %s/%s, err: %w", aw.Namespace, aw.Name, err)
+	}
+	return nil
+}
+
+func adoptName(hyd *hypdeployment.HypershiftDeployment) string {
+	return hyd.Name + "-adopt"
+}
+
+func scaffoldAdoptManifestWork(hyd *hypdeployment.HypershiftDeployment) *workv1.ManifestWork {
+	return &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateManifestName(hyd) + "-adopt",
+			Namespace: helper.GetHostingClusterName(hyd),
+			Annotations: map[string]string{
+				constant.CreatedByHypershiftDeployment: fmt.Sprintf("%s%s%s",
+					hyd.GetNamespace(),
+					constant.NamespaceNameSeperator,
+					hyd.GetName()),
+			},
+		},
+	}
+}
+
+// adoptPayload is a Job in the hosting namespace that reads the HostedCluster and NodePools, it only has read
+// access to them and writes to the ConfigMap of the manifestwork
+func adoptPayload(hyd *hypdeployment.HypershiftDeployment) []workv1.Manifest {
+	namespace := helper.GetHostingNamespace(hyd)
+	name := adoptName(hyd)
+	backoffLimit := int32(adoptJobBackoffLimit)
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: corev1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+
+	serviceAccount := &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{Kind: "ServiceAccount", APIVersion: corev1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+
+	role := &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{Kind: "Role", APIVersion: rbacv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{hyp.GroupVersion.Group},
+				Resources: []string{HostedClusterResource, NodePoolResource},
+				Verbs:     []string{"get", "list"},
+			},
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"configmaps"},
+				ResourceNames: []string{name},
+				Verbs:         []string{"get", "patch"},
+			},
+		},
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{Kind: "RoleBinding", APIVersion: rbacv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}},
+	}
+
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{Kind: "Job", APIVersion: batchv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: name,
+					Containers: []corev1.Container{
+						{
+							Name:    "read",
+							Image:   hyd.Spec.Adopt.Image,
+							Command: []string{"/bin/sh", "-c", adoptScript},
+							Env: []corev1.EnvVar{
+								{Name: "NAME", Value: hyd.Name},
+								{Name: "NAMESPACE", Value: namespace},
+								{Name: "CONFIGMAP", Value: name},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return []workv1.Manifest{
+		{RawExtension: runtime.RawExtension{Object: configMap}},
+		{RawExtension: runtime.RawExtension{Object: serviceAccount}},
+		{RawExtension: runtime.RawExtension{Object: role}},
+		{RawExtension: runtime.RawExtension{Object: roleBinding}},
+		{RawExtension: runtime.RawExtension{Object: job}},
+	}
+}
+
+func adoptFeedbackConfig(hyd *hypdeployment.HypershiftDeployment) []workv1.ManifestConfigOption {
+	namespace := helper.GetHostingNamespace(hyd)
+	return []workv1.ManifestConfigOption{
+		{
+			ResourceIdentifier: workv1.ResourceIdentifier{
+				Resource:  "configmaps",
+				Name:      adoptName(hyd),
+				Namespace: namespace,
+			},
+			FeedbackRules: []workv1.FeedbackRule{
+				{
+					Type: workv1.JSONPathsType,
+					JsonPaths: []workv1.JsonPath{
+						{Name: adoptHostedClusterKey, Path: ".metadata.annotations." + adoptHostedClusterKey},
+						{Name: adoptNodePoolsKey, Path: ".metadata.annotations." + adoptNodePoolsKey},
+					},
+				},
+			},
+		},
+		{
+			ResourceIdentifier: workv1.ResourceIdentifier{
+				Group:     batchv1.GroupName,
+				Resource:  "jobs",
+				Name:      adoptName(hyd),
+				Namespace: namespace,
+			},
+			FeedbackRules: []workv1.FeedbackRule{
+				{
+					Type:      workv1.JSONPathsType,
+					JsonPaths: []workv1.JsonPath{{Name: adoptJobFailed, Path: ".status.failed"}},
+				},
+			},
+		},
+	}
+}
+
+// adoptFeedback returns the spec read by the Job, the HostedClusterSpec is nil until it is reported
+func adoptFeedback(aw *workv1.ManifestWork, hyd *hypdeployment.HypershiftDeployment) (*hyp.HostedClusterSpec, []*hypdeployment.HypershiftNodePools, int64, error) {
+	namespace := helper.GetHostingNamespace(hyd)
+	values := resourceFeedback(aw, workv1.ResourceIdentifier{Resource: "configmaps", Name: adoptName(hyd), Namespace: namespace})
+	failed, _ := feedbackInteger(
+		resourceFeedback(aw, workv1.ResourceIdentifier{Group: batchv1.GroupName, Resource: "jobs", Name: adoptName(hyd), Namespace: namespace}),
+		adoptJobFailed)
+
+	data := feedbackString(values, adoptHostedClusterKey)
+	if len(data) == 0 {
+		return nil, nil, failed, nil
+	}
+
+	hcSpec := &hyp.HostedClusterSpec{}
+	if err := json.Unmarshal([]byte(data), hcSpec); err != nil {
+		return nil, nil, failed, fmt.Errorf("HostedCluster %s: %w", hyd.Name, err)
+	}
+
+	nodePools := []*hypdeployment.HypershiftNodePools{}
+	if data := feedbackString(values, adoptNodePoolsKey); len(data) != 0 {
+		if err := json.Unmarshal([]byte(data), &nodePools); err != nil {
+			return nil, nil, failed, fmt.Errorf("NodePools: %w", err)
+		}
+	}
+	return hcSpec, nodePools, failed, nil
+}
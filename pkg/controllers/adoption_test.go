@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+func getAdoptHD() *hyd.HypershiftDeployment {
+	testHD := getHypershiftDeployment("default", "test1", false)
+	testHD.Spec.InfraID = "test1-abcde"
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.HostingNamespace = "clusters"
+	testHD.Spec.Adopt = &hyd.AdoptSpec{Image: "quay.io/openshift/origin-cli:4.11"}
+	return testHD
+}
+
+func TestAdoption(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getAdoptHD()
+	assert.Nil(t, client.Create(ctx, testHD))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+		ctx:    ctx,
+	}
+
+	adopt := func() (ctrl.Result, bool, *hyd.HypershiftDeployment) {
+		resultHD := &hyd.HypershiftDeployment{}
+		assert.Nil(t, client.Get(ctx, getNN, resultHD))
+		res, adopting, err := hdr.reconcileAdoption(ctx, resultHD)
+		assert.Nil(t, err, "err nil when the adoption was reconciled")
+
+		assert.Nil(t, client.Get(ctx, getNN, resultHD))
+		return res, adopting, resultHD
+	}
+
+	_, adopting, resultHD := adopt()
+	assert.True(t, adopting, "the manifestwork waits for the HostedCluster to be read")
+	assert.Nil(t, resultHD.Spec.HostedClusterSpec)
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.HostedClusterAdopted))
+	assert.NotNil(t, c)
+	assert.Equal(t, hyd.BeingConfiguredReason, c.Reason)
+
+	awKey := types.NamespacedName{Namespace: "local-cluster", Name: "test1-abcde-adopt"}
+	aw := &workv1.ManifestWork{}
+	assert.Nil(t, client.Get(ctx, awKey, aw))
+	assert.Len(t, aw.Spec.Workload.Manifests, 5, "configmap, serviceaccount, role, rolebinding and job")
+	assert.Len(t, aw.Spec.ManifestConfigs, 2)
+
+	setWorkApplied(t, client, awKey, workv1.ManifestCondition{
+		ResourceMeta: workv1.ManifestResourceMeta{Resource: "configmaps", Name: "test1-adopt", Namespace: "clusters"},
+		StatusFeedbacks: workv1.StatusFeedbackResult{Values: []workv1.FeedbackValue{
+			stringFeedback(adoptHostedClusterKey, `{"release":{"image":"quay.io/openshift-release-dev/ocp-release:4.11.2-x86_64"},"infraID":"test1-xyz","platform":{"type":"AWS"}}`),
+			stringFeedback(adoptNodePoolsKey, `[{"name":"test1-workers","spec":{"clusterName":"test1","replicas":2,"release":{"image":"quay.io/openshift-release-dev/ocp-release:4.11.2-x86_64"},"platform":{"type":"AWS"}}}]`),
+		}},
+	})
+
+	res, adopting, resultHD := adopt()
+	assert.True(t, adopting)
+	assert.True(t, res.Requeue, "requeued to apply the manifestwork with the adopted spec")
+	assert.NotNil(t, resultHD.Spec.HostedClusterSpec)
+	assert.Equal(t, "test1-xyz", resultHD.Spec.HostedClusterSpec.InfraID)
+	assert.Equal(t, "test1-xyz", resultHD.Spec.InfraID, "the infra-id of the HostedCluster is kept")
+	assert.Equal(t, "test1-xyz", resultHD.Labels[constant.InfraLabelName])
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, awKey, aw)), "the adoption manifestwork named after the generated infra-id is deleted")
+	assert.Len(t, resultHD.Spec.NodePools, 1)
+	assert.Equal(t, "test1-workers", resultHD.Spec.NodePools[0].Name)
+	assert.Equal(t, int32(2), *resultHD.Spec.NodePools[0].Spec.Replicas)
+
+	_, adopting, resultHD = adopt()
+	assert.False(t, adopting, "the manifestwork takes over the HostedCluster")
+	assert.True(t, meta.IsStatusConditionTrue(resultHD.Status.Conditions, string(hyd.HostedClusterAdopted)))
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, awKey, aw)), "the adoption manifestwork is deleted")
+
+	_, adopting, _ = adopt()
+	assert.False(t, adopting)
+}
+
+func TestAdoptionJobFailed(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getAdoptHD()
+	assert.Nil(t, client.Create(ctx, testHD))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+		ctx:    ctx,
+	}
+
+	_, _, err := hdr.reconcileAdoption(ctx, testHD)
+	assert.Nil(t, err)
+
+	failed := int64(adoptJobBackoffLimit + 1)
+	setWorkApplied(t, client, types.NamespacedName{Namespace: "local-cluster", Name: "test1-abcde-adopt"}, workv1.ManifestCondition{
+		ResourceMeta: workv1.ManifestResourceMeta{Group: "batch", Resource: "jobs", Name: "test1-adopt", Namespace: "clusters"},
+		StatusFeedbacks: workv1.StatusFeedbackResult{Values: []workv1.FeedbackValue{
+			{Name: adoptJobFailed, Value: workv1.FieldValue{Type: workv1.Integer, Integer: &failed}},
+		}},
+	})
+
+	_, adopting, err := hdr.reconcileAdoption(ctx, testHD)
+	assert.Nil(t, err)
+	assert.True(t, adopting)
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.HostedClusterAdopted))
+	assert.NotNil(t, c)
+	assert.Equal(t, hyd.MisConfiguredReason, c.Reason)
+	assert.Equal(t, hyd.PhaseFailed, resultHD.Status.Phase)
+}
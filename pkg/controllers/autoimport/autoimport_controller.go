@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,6 +34,7 @@ import (
 	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/metrics"
 )
 
 const DEBUG = 1
@@ -48,19 +50,34 @@ const (
 	hostingClusterName   = "import.open-cluster-management.io/hosting-cluster-name"
 )
 
+// Reasons of the events recorded on a HypershiftDeployment
+const (
+	ManagedClusterCreatedEvent      = "ManagedClusterCreated"
+	ManagedClusterCreateFailedEvent = "ManagedClusterCreateFailed"
+	ManagedClusterDeletedEvent      = "ManagedClusterDeleted"
+	ManagedClusterDeleteFailedEvent = "ManagedClusterDeleteFailed"
+	ManagedClusterOrphanedEvent     = "ManagedClusterOrphaned"
+)
+
 // Reconciler reconciles a HypershiftDeployment object to
 // import the related hypershift hosted cluster to the hub cluster.
 type Reconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+func (r *Reconciler) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	helper.RecordEvent(r.Recorder, obj, eventType, reason, messageFmt, args...)
 }
 
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=hypershiftdeployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=hypershiftdeployments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=hypershiftdeployments/finalizers,verbs=update
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=create;get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=create;delete;get;list;patch;update;watch
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters,verbs=create;delete;get;list;patch;update;watch
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclustersets/join,verbs=create
 //+kubebuilder:rbac:groups=register.open-cluster-management.io,resources=managedclusters/accept,verbs=update
@@ -87,12 +104,26 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	log.V(INFO).Info("Hypershift Deployment info", "Name", hyd.Name,
 		"hostingNamespace", hyd.Spec.HostingNamespace, "hostingCluster", hyd.Spec.HostingCluster)
 
+	// The ManagedClusterCleanupFinalizer blocks a paused deletion until the HypershiftDeployment is resumed
+	if hyd.Spec.Paused {
+		log.V(INFO).Info("Reconciliation is paused")
+		return ctrl.Result{}, nil
+	}
+
 	managedClusterName := helper.ManagedClusterName(&hyd)
 	// Delete the ManagedCluster
 	if hyd.DeletionTimestamp != nil {
 		return deleteManagedCluster(r, hyd, managedClusterName)
 	}
 
+	// The copies of the hosted cluster secrets are kept in sync, also once the ManagedCluster is created
+	if len(helper.GetHostingClusterName(&hyd)) != 0 || hyd.Spec.HostingClusterPlacement == nil {
+		if err := syncHostedClusterSecrets(ctx, r, &hyd, helper.GetHostingCluster(&hyd)); err != nil {
+			log.V(WARN).Info("Failed to copy the hosted cluster secrets", "error", err)
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Do not exit till this point when importmanagedcluster=false, so deletion will work properly if manually imported
 	if len(hyd.Annotations) > 0 {
 		aValue, found := hyd.Annotations[createManagedClusterAnnotation]
@@ -102,9 +133,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 	}
 
+	// The HypershiftDeployment controller records the hosting cluster chosen from the Placement in the status
+	if hyd.Spec.HostingClusterPlacement != nil && len(helper.GetHostingClusterName(&hyd)) == 0 {
+		log.V(INFO).Info("Wait for a hosting cluster to be selected", "placement", hyd.Spec.HostingClusterPlacement.Name)
+		return ctrl.Result{}, nil
+	}
+
 	managementClusterName := helper.GetHostingCluster(&hyd)
 	// ManagedCluster
-	managedCluster, err := ensureManagedCluster(r, req.NamespacedName, managedClusterName, hyd.Spec.HostedManagedClusterSet, managementClusterName)
+	managedCluster, err := ensureManagedCluster(r, &hyd, managedClusterName, hyd.Spec.HostedManagedClusterSet, managementClusterName)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -123,6 +160,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			log.V(INFO).Info("Wait for the hosted cluster kubeconfig to be created", "secret", secretNamespaceName.String())
 			return ctrl.Result{}, nil
 		}
+	} else {
+		joined := meta.FindStatusCondition(managedCluster.Status.Conditions, mcv1.ManagedClusterConditionJoined)
+		// Only the Joined transition is observed, not every reconcile of a joined cluster
+		metrics.ObserveTransition(metrics.AutoImportDuration, "autoimport", managedCluster.UID, joined.LastTransitionTime.Time,
+			joined.LastTransitionTime.Sub(managedCluster.CreationTimestamp.Time))
 	}
 
 	// Make sure we don't create the ManagedCluster if it is detached
@@ -138,24 +180,7 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&hypdeployment.HypershiftDeployment{}).
 		// TODO(zhujian7): After https://github.com/stolostron/hypershift-deployment-controller/pull/33 is merged,
 		// we can add a new secret controller to render the kubeConfig in status and remove the watches here.
-		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
-			an := obj.GetAnnotations()
-			if len(an) == 0 || len(an[constant.AnnoHypershiftDeployment]) == 0 {
-				return []reconcile.Request{}
-			}
-
-			res := strings.Split(an[constant.AnnoHypershiftDeployment], constant.NamespaceNameSeperator)
-			if len(res) != 2 {
-				log.Log.Error(fmt.Errorf("failed to get hypershiftDeployment"), "annotation invalid",
-					"constant.AnnoHypershiftDeployment", an[constant.AnnoHypershiftDeployment])
-				return []reconcile.Request{}
-			}
-
-			req := reconcile.Request{
-				NamespacedName: types.NamespacedName{Namespace: res[0], Name: res[1]},
-			}
-			return []reconcile.Request{req}
-		}), builder.WithPredicates(
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(enqueueForAnnotation), builder.WithPredicates(
 			predicate.Funcs{
 				GenericFunc: func(e event.GenericEvent) bool { return false },
 				CreateFunc: func(e event.CreateEvent) bool {
@@ -176,15 +201,53 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 				},
 			},
 		)).
+		// The auto-import duration is observed when the ManagedCluster joins
+		Watches(&source.Kind{Type: &mcv1.ManagedCluster{}}, handler.EnqueueRequestsFromMapFunc(enqueueForAnnotation), builder.WithPredicates(
+			predicate.Funcs{
+				GenericFunc: func(e event.GenericEvent) bool { return false },
+				CreateFunc:  func(e event.CreateEvent) bool { return false },
+				DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					new, okNew := e.ObjectNew.(*mcv1.ManagedCluster)
+					old, okOld := e.ObjectOld.(*mcv1.ManagedCluster)
+					if okNew && okOld {
+						return meta.IsStatusConditionTrue(new.Status.Conditions, mcv1.ManagedClusterConditionJoined) &&
+							!meta.IsStatusConditionTrue(old.Status.Conditions, mcv1.ManagedClusterConditionJoined)
+					}
+					return false
+				},
+			},
+		)).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 1, // This is the default
 		}).Named("hypershiftimport").Complete(r)
 }
 
-func ensureManagedCluster(r *Reconciler, hydNamespaceName types.NamespacedName,
+// enqueueForAnnotation maps a Secret or ManagedCluster to the HypershiftDeployment of its AnnoHypershiftDeployment
+func enqueueForAnnotation(obj client.Object) []reconcile.Request {
+	an := obj.GetAnnotations()
+	if len(an) == 0 || len(an[constant.AnnoHypershiftDeployment]) == 0 {
+		return []reconcile.Request{}
+	}
+
+	res := strings.Split(an[constant.AnnoHypershiftDeployment], constant.NamespaceNameSeperator)
+	if len(res) != 2 {
+		log.Log.Error(fmt.Errorf("failed to get hypershiftDeployment"), "annotation invalid",
+			"constant.AnnoHypershiftDeployment", an[constant.AnnoHypershiftDeployment])
+		return []reconcile.Request{}
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: res[0], Name: res[1]},
+	}
+	return []reconcile.Request{req}
+}
+
+func ensureManagedCluster(r *Reconciler, hyd *hypdeployment.HypershiftDeployment,
 	managedClusterName, managedClusterSetName, managementClusterName string) (*mcv1.ManagedCluster, error) {
 	log := r.Log.WithValues("managedClusterName", managedClusterName)
 	ctx := context.Background()
+	hydNamespaceName := client.ObjectKeyFromObject(hyd)
 
 	var managementCluster mcv1.ManagedCluster
 	err := r.Get(ctx, types.NamespacedName{Name: managementClusterName}, &managementCluster)
@@ -212,8 +275,11 @@ func ensureManagedCluster(r *Reconciler, hydNamespaceName types.NamespacedName,
 		ensureManagedClusterObjectMeta(&mc, hydNamespaceName, managedClusterSetName, managementClusterName)
 		if err = r.Create(ctx, &mc, &client.CreateOptions{}); err != nil {
 			log.V(ERROR).Info("Could not create ManagedCluster resource", "error", err)
+			r.recordEvent(hyd, corev1.EventTypeWarning, ManagedClusterCreateFailedEvent,
+				"Failed to create ManagedCluster %s: %v", managedClusterName, err)
 			return nil, err
 		}
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManagedClusterCreatedEvent, "Created ManagedCluster %s", managedClusterName)
 
 		return &mc, nil
 	}
@@ -258,7 +324,6 @@ func ensureManagedClusterObjectMeta(mc *mcv1.ManagedCluster, hydNamespaceName ty
 	annoChanged := false
 	annotations := map[string]string{
 		klusterletDeployMode: "Hosted",
-		hostingClusterName:   managementClusterName,
 		constant.AnnoHypershiftDeployment: fmt.Sprintf("%s%s%s",
 			hydNamespaceName.Namespace, constant.NamespaceNameSeperator, hydNamespaceName.Name),
 		// format is <name>.<namespace>.<kind>.<apiversion>, klusterlet addon controller will use this annotation to
@@ -272,6 +337,12 @@ func ensureManagedClusterObjectMeta(mc *mcv1.ManagedCluster, hydNamespaceName ty
 		}
 	}
 
+	// The hosting cluster changes when the HostedCluster is migrated
+	if mc.Annotations[hostingClusterName] != managementClusterName {
+		mc.Annotations[hostingClusterName] = managementClusterName
+		annoChanged = true
+	}
+
 	return labelChanged || annoChanged
 }
 
@@ -348,11 +419,19 @@ func deleteManagedCluster(r *Reconciler, hyd hypdeployment.HypershiftDeployment,
 		return ctrl.Result{}, removeFinalizer(r, &hyd)
 	}
 
+	if hyd.Spec.GetDeletionPolicy().ManagedCluster == hypdeployment.DeletionPolicyOrphan {
+		log.V(INFO).Info("The ManagedCluster is orphaned by the deletion policy")
+		r.recordEvent(&hyd, corev1.EventTypeNormal, ManagedClusterOrphanedEvent, "Orphaned ManagedCluster %s", name)
+		return ctrl.Result{}, removeFinalizer(r, &hyd)
+	}
+
 	err = r.Delete(ctx, &mc)
 	if err != nil {
 		log.V(WARN).Info("Error while deleting ManagedCluster resource", "error", err)
+		r.recordEvent(&hyd, corev1.EventTypeWarning, ManagedClusterDeleteFailedEvent, "Failed to delete ManagedCluster %s: %v", name, err)
 		return ctrl.Result{}, err
 	}
+	r.recordEvent(&hyd, corev1.EventTypeNormal, ManagedClusterDeletedEvent, "Deleted ManagedCluster %s", name)
 
 	log.V(INFO).Info(fmt.Sprintf("Waiting the managedCluster %s to be deleted", name))
 	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
@@ -15,10 +15,12 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mcv1 "open-cluster-management.io/api/cluster/v1"
 	mcv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
@@ -340,6 +342,40 @@ func TestReconcileDelete(t *testing.T) {
 				assert.True(t, k8serrors.IsNotFound(err), "no managed cluster found")
 			},
 		},
+		{
+			name:              "paused, managed cluster is kept",
+			managedcluster:    GetManagedCluster(helper.ManagedClusterName(hyd)),
+			managementCluster: GetManagedCluster(HYD_NAMESPACE),
+			hyd: func() *hydapi.HypershiftDeployment {
+				pausedHyd := setDeletionTimestamp(hyd.DeepCopy(), time.Now())
+				pausedHyd.Spec.Paused = true
+				return pausedHyd
+			}(),
+			validateActions: func(t *testing.T, ctx context.Context, client crclient.Client) {
+				var mc mcv1.ManagedCluster
+				mcName := helper.ManagedClusterName(hyd)
+				assert.Nil(t, client.Get(ctx, getNamespaceName("", mcName), &mc), "managed cluster is not deleted")
+			},
+		},
+		{
+			name:              "orphan managed cluster",
+			managedcluster:    GetManagedCluster(helper.ManagedClusterName(hyd)),
+			managementCluster: GetManagedCluster(HYD_NAMESPACE),
+			hyd: func() *hydapi.HypershiftDeployment {
+				orphanHyd := setDeletionTimestamp(hyd.DeepCopy(), time.Now())
+				orphanHyd.Spec.DeletionPolicy = &hydapi.DeletionPolicy{ManagedCluster: hydapi.DeletionPolicyOrphan}
+				return setFinalizerHYD(orphanHyd, []string{constant.ManagedClusterCleanupFinalizer})
+			}(),
+			validateActions: func(t *testing.T, ctx context.Context, client crclient.Client) {
+				var mc mcv1.ManagedCluster
+				mcName := helper.ManagedClusterName(hyd)
+				assert.Nil(t, client.Get(ctx, getNamespaceName("", mcName), &mc), "managed cluster is not deleted")
+
+				var inHyd hydapi.HypershiftDeployment
+				err := client.Get(ctx, getNamespaceName(HYD_NAMESPACE, HYD_NAME), &inHyd)
+				assert.True(t, k8serrors.IsNotFound(err), "the finalizer is removed, the hypershiftDeployment is deleted")
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -365,3 +401,51 @@ func TestReconcileDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileEvents(t *testing.T) {
+	ctx := context.Background()
+	hyd := GetHypershiftDeployment(HYD_NAMESPACE, HYD_NAME, "id1", HYD_NAMESPACE)
+	mcName := helper.ManagedClusterName(hyd)
+
+	air := GetAutoImportReconciler()
+	recorder := record.NewFakeRecorder(10)
+	air.Recorder = recorder
+
+	assert.Nil(t, air.Client.Create(ctx, hyd.DeepCopy(), &crclient.CreateOptions{}), "")
+	assert.Nil(t, air.Client.Create(ctx, GetManagedCluster(HYD_NAMESPACE), &crclient.CreateOptions{}), "")
+
+	_, err := air.Reconcile(ctx, getRequest())
+	assert.Nil(t, err, "reconcile was successful")
+	assert.Equal(t, "Normal "+ManagedClusterCreatedEvent+" Created ManagedCluster "+mcName, <-recorder.Events)
+
+	var inHyd hydapi.HypershiftDeployment
+	assert.Nil(t, air.Client.Get(ctx, getNamespaceName(HYD_NAMESPACE, HYD_NAME), &inHyd))
+	assert.Nil(t, air.Client.Delete(ctx, &inHyd), "the hypershiftDeployment is deleted")
+
+	_, err = air.Reconcile(ctx, getRequest())
+	assert.Nil(t, err, "reconcile was successful")
+	assert.Equal(t, "Normal "+ManagedClusterDeletedEvent+" Deleted ManagedCluster "+mcName, <-recorder.Events)
+}
+
+func TestEnsureManagedClusterObjectMetaHostingCluster(t *testing.T) {
+	mc := &mcv1.ManagedCluster{}
+	hydNamespaceName := getNamespaceName(HYD_NAMESPACE, HYD_NAME)
+
+	assert.True(t, ensureManagedClusterObjectMeta(mc, hydNamespaceName, "default", "local-cluster"))
+	assert.False(t, ensureManagedClusterObjectMeta(mc, hydNamespaceName, "default", "local-cluster"))
+
+	// The annotation follows a migrated HostedCluster
+	assert.True(t, ensureManagedClusterObjectMeta(mc, hydNamespaceName, "default", "cluster2"))
+	assert.Equal(t, "cluster2", mc.Annotations[hostingClusterName])
+}
+
+func TestEnqueueForAnnotation(t *testing.T) {
+	mc := GetManagedCluster(HYD_NAMESPACE)
+	assert.Empty(t, enqueueForAnnotation(mc), "no request without the annotation")
+
+	mc.Annotations = map[string]string{constant.AnnoHypershiftDeployment: "invalid"}
+	assert.Empty(t, enqueueForAnnotation(mc), "no request for an invalid annotation")
+
+	mc.Annotations[constant.AnnoHypershiftDeployment] = HYD_NAMESPACE + constant.NamespaceNameSeperator + HYD_NAME
+	assert.Equal(t, []reconcile.Request{{NamespacedName: getNamespaceName(HYD_NAMESPACE, HYD_NAME)}}, enqueueForAnnotation(mc))
+}
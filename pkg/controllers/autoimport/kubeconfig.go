@@ -0,0 +1,139 @@
+// Copyright Contributors to the Open Cluster Management project.
+
+package autoimport
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+// AdminKubeconfigCopyName is the copy of the admin kubeconfig in the HypershiftDeployment namespace. It does not
+// use the name of the HostedCluster Secret, which is in the same namespace when the hosting namespace is the
+// HypershiftDeployment namespace on the local-cluster
+func AdminKubeconfigCopyName(hyd *hypdeployment.HypershiftDeployment) string {
+	return hyd.Name + "-hosted-admin-kubeconfig"
+}
+
+// KubeadminPasswordCopyName is the copy of the kubeadmin password in the HypershiftDeployment namespace
+func KubeadminPasswordCopyName(hyd *hypdeployment.HypershiftDeployment) string {
+	return hyd.Name + "-hosted-kubeadmin-password"
+}
+
+// syncHostedClusterSecrets copies the admin kubeconfig, and the kubeadmin password when
+// Spec.SyncKubeadminPassword is set, from the namespace of the hosting cluster to the HypershiftDeployment
+// namespace. The copies are owned by the HypershiftDeployment and referenced from its status
+func syncHostedClusterSecrets(ctx context.Context, r *Reconciler, hyd *hypdeployment.HypershiftDeployment, managementClusterName string) error {
+	inHyd := hyd.DeepCopy()
+
+	kubeconfig, err := syncSecretCopy(ctx, r, hyd,
+		types.NamespacedName{Namespace: managementClusterName, Name: helper.HostedKubeconfigName(hyd)},
+		AdminKubeconfigCopyName(hyd))
+	if err != nil {
+		return err
+	}
+	if kubeconfig != nil {
+		hyd.Status.AdminKubeconfigSecret = kubeconfig
+	}
+
+	if hyd.Spec.SyncKubeadminPassword {
+		password, err := syncSecretCopy(ctx, r, hyd,
+			types.NamespacedName{Namespace: managementClusterName, Name: helper.HostedKubeadminPasswordName(hyd)},
+			KubeadminPasswordCopyName(hyd))
+		if err != nil {
+			return err
+		}
+		if password != nil {
+			hyd.Status.KubeadminPasswordSecret = password
+		}
+	} else if hyd.Status.KubeadminPasswordSecret != nil {
+		if err := deleteSecretCopy(ctx, r, hyd, hyd.Status.KubeadminPasswordSecret.Name); err != nil {
+			return err
+		}
+		hyd.Status.KubeadminPasswordSecret = nil
+	}
+
+	if equality.Semantic.DeepEqual(inHyd.Status, hyd.Status) {
+		return nil
+	}
+	return r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd))
+}
+
+// syncSecretCopy creates or refreshes the copy of the source Secret, it returns nil while the source Secret is not
+// synced from the hosting cluster
+func syncSecretCopy(ctx context.Context, r *Reconciler, hyd *hypdeployment.HypershiftDeployment,
+	source types.NamespacedName, name string) (*corev1.LocalObjectReference, error) {
+	log := r.Log.WithValues("secret", source.String())
+
+	var src corev1.Secret
+	if err := r.Get(ctx, source, &src); err != nil {
+		if k8serrors.IsNotFound(err) {
+			log.V(DEBUG).Info("Wait for the secret to be synced from the hosting cluster")
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var dst corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{Namespace: hyd.Namespace, Name: name}, &dst)
+	if k8serrors.IsNotFound(err) {
+		dst = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: hyd.Namespace},
+			Type:       src.Type,
+			Data:       src.Data,
+		}
+		if err := controllerutil.SetControllerReference(hyd, &dst, r.Scheme); err != nil {
+			return nil, err
+		}
+		log.V(INFO).Info("Copy the secret to the HypershiftDeployment namespace", "copy", name)
+		if err := r.Create(ctx, &dst); err != nil {
+			return nil, err
+		}
+		return &corev1.LocalObjectReference{Name: name}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !metav1.IsControlledBy(&dst, hyd) {
+		return nil, fmt.Errorf("secret %s/%s exists and is not owned by the HypershiftDeployment", hyd.Namespace, name)
+	}
+
+	if !equality.Semantic.DeepEqual(dst.Data, src.Data) {
+		patch := client.MergeFrom(dst.DeepCopy())
+		dst.Data = src.Data
+		log.V(INFO).Info("Refresh the copy of the secret", "copy", name)
+		if err := r.Patch(ctx, &dst, patch); err != nil {
+			return nil, err
+		}
+	}
+	return &corev1.LocalObjectReference{Name: name}, nil
+}
+
+// deleteSecretCopy deletes a copy owned by the HypershiftDeployment
+func deleteSecretCopy(ctx context.Context, r *Reconciler, hyd *hypdeployment.HypershiftDeployment, name string) error {
+	var dst corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{Namespace: hyd.Namespace, Name: name}, &dst)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(&dst, hyd) {
+		return nil
+	}
+	r.Log.V(INFO).Info("Delete the copy of the secret", "copy", name)
+	return client.IgnoreNotFound(r.Delete(ctx, &dst))
+}
@@ -0,0 +1,99 @@
+// Copyright Contributors to the Open Cluster Management project.
+
+package autoimport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hydapi "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+func TestSyncHostedClusterSecrets(t *testing.T) {
+	ctx := context.Background()
+	hyd := GetHypershiftDeployment(HYD_NAMESPACE, HYD_NAME, "id1", "hosting")
+	hyd.Spec.HostingNamespace = "clusters"
+	hyd.Annotations = map[string]string{createManagedClusterAnnotation: "false"}
+
+	air := GetAutoImportReconciler()
+	assert.Nil(t, air.Client.Create(ctx, hyd.DeepCopy()))
+	assert.Nil(t, air.Client.Create(ctx, GetManagedCluster("hosting")))
+	assert.Nil(t, air.Client.Create(ctx, GetHostedClusterKubeconfig("hosting", helper.HostedKubeconfigName(hyd))))
+
+	_, err := air.Reconcile(ctx, getRequest())
+	assert.Nil(t, err, "reconcile was successful")
+
+	var resultHyd hydapi.HypershiftDeployment
+	assert.Nil(t, air.Client.Get(ctx, getRequest().NamespacedName, &resultHyd))
+	if assert.NotNil(t, resultHyd.Status.AdminKubeconfigSecret) {
+		assert.Equal(t, AdminKubeconfigCopyName(hyd), resultHyd.Status.AdminKubeconfigSecret.Name)
+	}
+	assert.Nil(t, resultHyd.Status.KubeadminPasswordSecret, "the password is not synced by default")
+
+	var kubeconfig corev1.Secret
+	copyKey := getNamespaceName(HYD_NAMESPACE, AdminKubeconfigCopyName(hyd))
+	assert.Nil(t, air.Client.Get(ctx, copyKey, &kubeconfig))
+	assert.Equal(t, []byte("test"), kubeconfig.Data["kubeconfig"])
+	assert.True(t, v1.IsControlledBy(&kubeconfig, &resultHyd), "the copy is owned by the HypershiftDeployment")
+
+	// The copy is refreshed when the kubeconfig synced from the hosting cluster changes
+	var src corev1.Secret
+	assert.Nil(t, air.Client.Get(ctx, getNamespaceName("hosting", helper.HostedKubeconfigName(hyd)), &src))
+	src.Data["kubeconfig"] = []byte("rotated")
+	assert.Nil(t, air.Client.Update(ctx, &src))
+
+	// The kubeadmin password is copied once enabled
+	resultHyd.Spec.SyncKubeadminPassword = true
+	assert.Nil(t, air.Client.Update(ctx, &resultHyd))
+	password := GetHostedClusterKubeconfig("hosting", helper.HostedKubeadminPasswordName(hyd))
+	password.Data = map[string][]byte{"password": []byte("secret")}
+	assert.Nil(t, air.Client.Create(ctx, password))
+
+	_, err = air.Reconcile(ctx, getRequest())
+	assert.Nil(t, err, "reconcile was successful")
+	assert.Nil(t, air.Client.Get(ctx, copyKey, &kubeconfig))
+	assert.Equal(t, []byte("rotated"), kubeconfig.Data["kubeconfig"])
+
+	assert.Nil(t, air.Client.Get(ctx, getRequest().NamespacedName, &resultHyd))
+	if assert.NotNil(t, resultHyd.Status.KubeadminPasswordSecret) {
+		assert.Equal(t, KubeadminPasswordCopyName(hyd), resultHyd.Status.KubeadminPasswordSecret.Name)
+	}
+	var passwordCopy corev1.Secret
+	passwordKey := getNamespaceName(HYD_NAMESPACE, KubeadminPasswordCopyName(hyd))
+	assert.Nil(t, air.Client.Get(ctx, passwordKey, &passwordCopy))
+	assert.Equal(t, []byte("secret"), passwordCopy.Data["password"])
+
+	// The password copy is removed once disabled
+	resultHyd.Spec.SyncKubeadminPassword = false
+	assert.Nil(t, air.Client.Update(ctx, &resultHyd))
+
+	_, err = air.Reconcile(ctx, getRequest())
+	assert.Nil(t, err, "reconcile was successful")
+	assert.True(t, k8serrors.IsNotFound(air.Client.Get(ctx, passwordKey, &passwordCopy)))
+	assert.Nil(t, air.Client.Get(ctx, getRequest().NamespacedName, &resultHyd))
+	assert.Nil(t, resultHyd.Status.KubeadminPasswordSecret)
+}
+
+func TestSyncHostedClusterSecretsNotOwned(t *testing.T) {
+	ctx := context.Background()
+	hyd := GetHypershiftDeployment(HYD_NAMESPACE, HYD_NAME, "id1", "hosting")
+
+	air := GetAutoImportReconciler()
+	assert.Nil(t, air.Client.Create(ctx, hyd))
+	assert.Nil(t, air.Client.Create(ctx, GetHostedClusterKubeconfig("hosting", helper.HostedKubeconfigName(hyd))))
+	existing := GetHostedClusterKubeconfig(HYD_NAMESPACE, AdminKubeconfigCopyName(hyd))
+	existing.Data["kubeconfig"] = []byte("user")
+	assert.Nil(t, air.Client.Create(ctx, existing))
+
+	assert.NotNil(t, syncHostedClusterSecrets(ctx, air, hyd, "hosting"), "a Secret of the user is not overwritten")
+
+	var kubeconfig corev1.Secret
+	assert.Nil(t, air.Client.Get(ctx, getNamespaceName(HYD_NAMESPACE, AdminKubeconfigCopyName(hyd)), &kubeconfig))
+	assert.Equal(t, []byte("user"), kubeconfig.Data["kubeconfig"])
+}
@@ -32,6 +32,7 @@ import (
 	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/metrics"
 )
 
 func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, error) {
@@ -44,6 +45,12 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 		return ctrl.Result{}, r.updateMissingInfrastructureParameterCondition(hyd, "Missing value HypershiftDeployment.Spec.Infrastructure.Platform.AWS.Region")
 	}
 
+	creds, err := r.InfraHandler.AWSCredentialSource(providerSecret)
+	if err != nil {
+		log.Error(err, "could not read the AWS credentials from the cloud provider "+providerSecret.Name)
+		return ctrl.Result{}, r.updateStatusConditionsOnChange(hyd, hypdeployment.ProviderSecretConfigured, metav1.ConditionFalse, err.Error(), hypdeployment.MisConfiguredReason)
+	}
+
 	// Skip reconcile based on condition
 	// Does both INFRA and IAM, as IAM depends on zoneID's from INFRA
 	if !meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured)) ||
@@ -51,17 +58,35 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 
 		log.Info("Creating infrastructure on the provider that will be used by the HypershiftDeployment, HostedClusters & NodePools")
 		_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, "Configuring platform with infra-id: "+hyd.Spec.InfraID, hypdeployment.BeingConfiguredReason)
+		start := time.Now()
 		infraOut, err := r.InfraHandler.AwsInfraCreator(
-			string(providerSecret.Data["aws_access_key_id"]),
-			string(providerSecret.Data["aws_secret_access_key"]),
+			creds,
 			hyd.Spec.Infrastructure.Platform.AWS.Region,
 			hyd.Spec.InfraID,
 			hyd.GetName(),
 			string(providerSecret.Data["baseDomain"]),
 			hyd.Spec.Infrastructure.Platform.AWS.Zones,
 		)(ctx, log)
+		metrics.ObserveInfraOperation(metrics.PlatformAWS, metrics.OperationCreateInfra, start, err)
 		if err != nil {
 			log.Error(err, "Could not create infrastructure")
+			r.recordWarning(hyd, InfraCreateFailedEvent, err)
+
+			return r.retryInfraOperation(hyd, providerSecret, metrics.OperationCreateInfra, hypdeployment.PlatformConfigured, hypdeployment.MisConfiguredReason, err)
+		}
+
+		var previous *hypdeployment.AWSInfrastructureStatus
+		if hyd.Status.Infrastructure != nil {
+			previous = hyd.Status.Infrastructure.AWS.DeepCopy()
+		}
+		if err := r.updateInfrastructureStatus(hyd, func(s *hypdeployment.InfrastructureStatus) {
+			s.AWS = awsInfrastructureStatus(infraOut)
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.ensureReleaseImage(hyd); err != nil {
+			log.Error(err, "Could not resolve the release image")
 
 			return ctrl.Result{RequeueAfter: 1 * time.Minute, Requeue: true},
 				r.updateStatusConditionsOnChange(
@@ -74,7 +99,11 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 		// This creates the required HostedClusterSpec and NodePoolSpec(s), from scratch if not supplied
 		ScaffoldAWSHostedClusterSpec(hyd, infraOut)
 		ScaffoldAWSNodePoolSpec(hyd, infraOut)
+		updateRepairedAWSNodePools(hyd, previous, infraOut)
 
+		// The spec patch must stay: the manifestwork renders the HostedCluster and NodePools from the spec, and
+		// the user can edit the scaffolded values. Only the DNS zones, machine CIDR, VPC, subnets, security group and
+		// instance profile they consume are written, the proxy and local zone are only recorded in Status.Infrastructure
 		if err := r.patchHypershiftDeploymentResource(hyd); err != nil {
 			_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, err.Error(), hypdeployment.MisConfiguredReason)
 			return ctrl.Result{}, err
@@ -84,6 +113,7 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 			return ctrl.Result{}, err
 		}
 		log.Info("Infrastructure configured")
+		r.recordEvent(hyd, corev1.EventTypeNormal, InfraCreatedEvent, "Created AWS infrastructure with infra-id: %s", hyd.Spec.InfraID)
 
 		if err = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformIAMConfigured, metav1.ConditionFalse, "Configuring platform IAM with infra-id: "+hyd.Spec.InfraID, hypdeployment.BeingConfiguredReason); err != nil {
 			return ctrl.Result{}, err
@@ -91,9 +121,9 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 
 		oidcSPName, oidcSPRegion, iamErr := oidcDiscoveryURL(r, hyd)
 		if iamErr == nil {
+			start := time.Now()
 			iamOut, iamErr = r.InfraHandler.AwsIAMCreator(
-				string(providerSecret.Data["aws_access_key_id"]),
-				string(providerSecret.Data["aws_secret_access_key"]),
+				creds,
 				hyd.Spec.Infrastructure.Platform.AWS.Region,
 				hyd.Spec.InfraID,
 				oidcSPName,
@@ -102,15 +132,24 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 				infraOut.PublicZoneID,
 				infraOut.LocalZoneID,
 			)(ctx, r.Client)
+			metrics.ObserveInfraOperation(metrics.PlatformAWS, metrics.OperationCreateIAM, start, iamErr)
 			if iamErr != nil {
-				_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformIAMConfigured,
-					metav1.ConditionFalse,
-					iamErr.Error(),
-					hypdeployment.MisConfiguredReason)
 				log.Error(iamErr, "aws iam creator error")
-				return ctrl.Result{RequeueAfter: 1 * time.Minute, Requeue: true}, nil
+				r.recordWarning(hyd, IAMCreateFailedEvent, iamErr)
+				return r.retryInfraOperation(hyd, providerSecret, metrics.OperationCreateIAM, hypdeployment.PlatformIAMConfigured, hypdeployment.MisConfiguredReason, iamErr)
 			}
 
+			if err := r.updateInfrastructureStatus(hyd, func(s *hypdeployment.InfrastructureStatus) {
+				if s.AWS == nil {
+					s.AWS = awsInfrastructureStatus(infraOut)
+				}
+				s.AWS.IAM = awsIAMStatus(iamOut)
+			}); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			// Only what the HostedCluster consumes is copied to the spec, the instance profile name and KMS key
+			// stay in the status
 			hyd.Spec.HostedClusterSpec.IssuerURL = iamOut.IssuerURL
 			hyd.Spec.HostedClusterSpec.Platform.AWS.RolesRef.ImageRegistryARN = iamOut.Roles.ImageRegistryARN
 			hyd.Spec.HostedClusterSpec.Platform.AWS.RolesRef.IngressARN = iamOut.Roles.IngressARN
@@ -130,8 +169,14 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 				return ctrl.Result{}, err
 			}
 			log.Info("IAM configured")
+			r.recordEvent(hyd, corev1.EventTypeNormal, IAMCreatedEvent, "Created AWS IAM with infra-id: %s", hyd.Spec.InfraID)
+
+			if err := r.clearInfraRetry(hyd); err != nil {
+				return ctrl.Result{}, err
+			}
 		} else {
 			log.Error(iamErr, "oidc discovery url could not be generated")
+			r.recordWarning(hyd, IAMCreateFailedEvent, iamErr)
 			//TODO @jnpacker When Get configMap fails in oidcDiscoveryURL we should requeue
 			return ctrl.Result{},
 				r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformIAMConfigured,
@@ -147,54 +192,75 @@ func (r *HypershiftDeploymentReconciler) createAWSInfra(hyd *hypdeployment.Hyper
 func (r *HypershiftDeploymentReconciler) destroyAWSInfrastructure(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (reconcile.Result, error) {
 	log := r.Log
 	ctx := r.ctx
-	awsKey := string(providerSecret.Data["aws_access_key_id"])
-	awsSecretKey := string(providerSecret.Data["aws_secret_access_key"])
 
-	_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, "Removing AWS infrastructure with infra-id: "+hyd.Spec.InfraID, hypdeployment.PlatfromDestroyReason)
+	creds, err := r.InfraHandler.AWSCredentialSource(providerSecret)
+	if err != nil {
+		log.Error(err, "could not read the AWS credentials from the cloud provider "+providerSecret.Name)
+		return ctrl.Result{}, r.updateStatusConditionsOnChange(hyd, hypdeployment.ProviderSecretConfigured, metav1.ConditionFalse, err.Error(), hypdeployment.MisConfiguredReason)
+	}
 
-	log.Info("Deleting Infrastructure on provider")
+	policy := hyd.Spec.GetDeletionPolicy()
+	if policy.Infrastructure == hypdeployment.DeletionPolicyOrphan {
+		log.Info("Orphaning Infrastructure on provider")
+		r.recordEvent(hyd, corev1.EventTypeNormal, InfraOrphanedEvent, "Orphaned AWS infrastructure with infra-id: %s", hyd.Spec.InfraID)
+	} else {
+		_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, "Removing AWS infrastructure with infra-id: "+hyd.Spec.InfraID, hypdeployment.PlatfromDestroyReason)
 
-	if err := r.InfraHandler.AwsInfraDestroyer(
-		awsKey,
-		awsSecretKey,
-		hyd.Spec.Infrastructure.Platform.AWS.Region,
-		hyd.Spec.InfraID,
-		hyd.GetName(),
-		string(providerSecret.Data["baseDomain"]),
-	)(ctx); err != nil {
-		log.Error(err, "there was a problem destroying infrastructure on the provider, retrying in 30s")
-		return ctrl.Result{RequeueAfter: 30 * time.Second, Requeue: true},
-			r.updateStatusConditionsOnChange(
-				hyd, hypdeployment.PlatformConfigured,
-				metav1.ConditionFalse,
-				err.Error(),
-				hypdeployment.PlatfromDestroyReason)
+		log.Info("Deleting Infrastructure on provider")
+
+		start := time.Now()
+		err = r.InfraHandler.AwsInfraDestroyer(
+			creds,
+			hyd.Spec.Infrastructure.Platform.AWS.Region,
+			hyd.Spec.InfraID,
+			hyd.GetName(),
+			awsDestroyBaseDomain(hyd, providerSecret),
+		)(ctx)
+		metrics.ObserveInfraOperation(metrics.PlatformAWS, metrics.OperationDestroyInfra, start, err)
+		if err != nil {
+			log.Error(err, "there was a problem destroying infrastructure on the provider")
+			res, stErr := r.retryInfraOperation(hyd, providerSecret, metrics.OperationDestroyInfra, hypdeployment.PlatformConfigured, hypdeployment.PlatfromDestroyReason, err)
+			if res.Requeue {
+				r.recordEvent(hyd, corev1.EventTypeWarning, InfraDestroyRetryEvent, "Failed to destroy AWS infrastructure, retrying in %s: %v", res.RequeueAfter, err)
+			}
+			return res, stErr
+		}
+
+		r.recordEvent(hyd, corev1.EventTypeNormal, InfraDestroyedEvent, "Destroyed AWS infrastructure with infra-id: %s", hyd.Spec.InfraID)
+	}
+
+	if policy.IAM == hypdeployment.DeletionPolicyOrphan {
+		log.Info("Orphaning Infrastructure IAM on provider")
+		r.recordEvent(hyd, corev1.EventTypeNormal, IAMOrphanedEvent, "Orphaned AWS IAM with infra-id: %s", hyd.Spec.InfraID)
+		return ctrl.Result{}, nil
 	}
 
 	log.Info("Deleting Infrastructure IAM on provider")
 	_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformIAMConfigured, metav1.ConditionFalse, "Removing AWS IAM with infra-id: "+hyd.Spec.InfraID, hypdeployment.RemovingReason)
 
-	if err := r.InfraHandler.AwsIAMDestroyer(
-		awsKey,
-		awsSecretKey,
+	start := time.Now()
+	err = r.InfraHandler.AwsIAMDestroyer(
+		creds,
 		hyd.Spec.Infrastructure.Platform.AWS.Region,
 		hyd.Spec.InfraID,
-	)(ctx); err != nil {
+	)(ctx)
+	metrics.ObserveInfraOperation(metrics.PlatformAWS, metrics.OperationDestroyIAM, start, err)
+	if err != nil {
 		log.Error(err, "failed to delete IAM on provider")
-		return ctrl.Result{RequeueAfter: 30 * time.Second, Requeue: true},
-			r.updateStatusConditionsOnChange(
-				hyd, hypdeployment.PlatformIAMConfigured,
-				metav1.ConditionFalse,
-				err.Error(),
-				hypdeployment.RemovingReason)
+		res, stErr := r.retryInfraOperation(hyd, providerSecret, metrics.OperationDestroyIAM, hypdeployment.PlatformIAMConfigured, hypdeployment.RemovingReason, err)
+		if res.Requeue {
+			r.recordEvent(hyd, corev1.EventTypeWarning, IAMDestroyRetryEvent, "Failed to destroy AWS IAM, retrying in %s: %v", res.RequeueAfter, err)
+		}
+		return res, stErr
 	}
+	r.recordEvent(hyd, corev1.EventTypeNormal, IAMDestroyedEvent, "Destroyed AWS IAM with infra-id: %s", hyd.Spec.InfraID)
 
 	return ctrl.Result{}, nil
 }
 
 func oidcDiscoveryURL(r *HypershiftDeploymentReconciler, hyd *hypdeployment.HypershiftDeployment) (string, string, error) {
 
-	if len(hyd.Spec.HostingCluster) == 0 {
+	if len(helper.GetHostingClusterName(hyd)) == 0 {
 		return "", "", errors.New(constant.HostingClusterMissing)
 	}
 
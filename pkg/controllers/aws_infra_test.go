@@ -260,6 +260,40 @@ func TestDestroyAwsInfra(t *testing.T) {
 	assert.Equal(t, "Removing AWS IAM with infra-id: test1-abcde", c.Message)
 }
 
+func TestDestroyAwsInfraDeletionPolicy(t *testing.T) {
+	ctx := context.Background()
+	hyd := getHDforManifestWork()
+	hyd.Spec.DeletionPolicy = &hypdeployment.DeletionPolicy{
+		Infrastructure: hypdeployment.DeletionPolicyOrphan,
+		IAM:            hypdeployment.DeletionPolicyOrphan,
+	}
+
+	r := GetHypershiftDeploymentReconciler()
+
+	hydapi.AddToScheme(r.Scheme)
+	r.Client.Create(ctx, hyd)
+	defer r.Client.Delete(ctx, hyd)
+
+	// The failing destroyers are never called
+	r.InfraHandler = &FakeInfraHandlerFailure{}
+
+	res, err := r.destroyAWSInfrastructure(hyd, getProviderSecret())
+	assert.Nil(t, err, "nil, when the infrastructure and IAM are orphaned")
+	assert.False(t, res.Requeue)
+	assert.Nil(t, meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured)))
+	assert.Nil(t, meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.PlatformIAMConfigured)))
+
+	t.Log("Test the IAM is destroyed when only the infrastructure is orphaned")
+	hyd.Spec.DeletionPolicy.IAM = hypdeployment.DeletionPolicyDelete
+	_, err = r.destroyAWSInfrastructure(hyd, getProviderSecret())
+	assert.Nil(t, err, "nil, when condition is set successfully")
+	assert.Nil(t, meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured)))
+
+	c := meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.PlatformIAMConfigured))
+	assert.NotNil(t, c, "not nil, when condition is found")
+	assert.Equal(t, hypdeployment.RemovingReason, c.Reason, "reason is Removing")
+}
+
 func TestCreateAwsInfraIAMMisConfigured(t *testing.T) {
 	ctx := context.Background()
 	hyd := getHDforManifestWork()
@@ -17,7 +17,6 @@ limitations under the License.
 package controllers
 
 import (
-	"encoding/json"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -26,8 +25,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	"github.com/openshift/hypershift/api/fixtures"
 	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/metrics"
 )
 
 func (r *HypershiftDeploymentReconciler) createAzureInfra(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, error) {
@@ -39,28 +38,56 @@ func (r *HypershiftDeploymentReconciler) createAzureInfra(hyd *hypdeployment.Hyp
 
 	// Skip reconcile based on condition
 	// Does both INFRA
-	credentials, err := getAzureCloudProviderCreds(providerSecret)
+	creds, err := r.InfraHandler.AzureCredentialSource(providerSecret)
 	if err != nil {
-		log.Error(err, "could not correctly retreive the osServicePrincipal from the cloud provider "+providerSecret.Name)
+		log.Error(err, "could not read the Azure credentials from the cloud provider "+providerSecret.Name)
 		return ctrl.Result{}, r.updateStatusConditionsOnChange(
 			hyd, hypdeployment.ProviderSecretConfigured,
 			metav1.ConditionFalse,
-			"The cloud provider secret does not contain a valid osServicePrincipal.json value", hypdeployment.MisConfiguredReason)
+			err.Error(), hypdeployment.MisConfiguredReason)
 	}
 	if !meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured)) {
+		// The Hypershift Azure library only accepts a client secret, the identities of the controller can not be used
+		if _, err := azureServicePrincipal(creds); err != nil {
+			log.Error(err, "could not create infrastructure with the cloud provider "+providerSecret.Name)
+			return ctrl.Result{}, r.updateStatusConditionsOnChange(
+				hyd, hypdeployment.ProviderSecretConfigured,
+				metav1.ConditionFalse,
+				err.Error(), hypdeployment.MisConfiguredReason)
+		}
+
 		log.Info("Creating infrastructure in Azure that will be used by the HypershiftDeployment, HostedClusters & NodePools")
 		setStatusCondition(hyd, hypdeployment.PlatformIAMConfigured, metav1.ConditionTrue, "Platform IAM with infra-id: "+hyd.Spec.InfraID, hypdeployment.NotApplicableReason)
 		_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, "Configuring platform with infra-id: "+hyd.Spec.InfraID, hypdeployment.BeingConfiguredReason)
 
+		start := time.Now()
 		infraOut, err := r.InfraHandler.AzureInfraCreator(
 			hyd.GetName(),
 			string(providerSecret.Data["baseDomain"]),
 			hyd.Spec.Infrastructure.Platform.Azure.Location,
 			hyd.Spec.InfraID,
-			credentials,
+			creds,
 		)(r.ctx, r.Log)
+		metrics.ObserveInfraOperation(metrics.PlatformAzure, metrics.OperationCreateInfra, start, err)
 		if err != nil {
 			log.Error(err, "Could not create infrastructure")
+			r.recordWarning(hyd, InfraCreateFailedEvent, err)
+
+			return r.retryInfraOperation(hyd, providerSecret, metrics.OperationCreateInfra, hypdeployment.PlatformConfigured, hypdeployment.MisConfiguredReason, err)
+		}
+
+		var previous *hypdeployment.AzureInfrastructureStatus
+		if hyd.Status.Infrastructure != nil {
+			previous = hyd.Status.Infrastructure.Azure.DeepCopy()
+		}
+		if err := r.updateInfrastructureStatus(hyd, func(s *hypdeployment.InfrastructureStatus) {
+			s.Azure = azureInfrastructureStatus(infraOut)
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.ensureReleaseImage(hyd); err != nil {
+			log.Error(err, "Could not resolve the release image")
 
 			return ctrl.Result{RequeueAfter: 1 * time.Minute, Requeue: true},
 				r.updateStatusConditionsOnChange(
@@ -72,8 +99,9 @@ func (r *HypershiftDeploymentReconciler) createAzureInfra(hyd *hypdeployment.Hyp
 
 		// This creates the required HostedClusterSpec and NodePoolSpec(s), from scratch or if supplied
 		ScaffoldAzureHostedClusterSpec(hyd, infraOut)
-		hyd.Spec.HostedClusterSpec.Platform.Azure.SubscriptionID = credentials.SubscriptionID
+		hyd.Spec.HostedClusterSpec.Platform.Azure.SubscriptionID = creds.SubscriptionID()
 		ScaffoldAzureNodePoolSpec(hyd, infraOut)
+		updateRepairedAzureNodePools(hyd, previous, infraOut)
 
 		if err := r.patchHypershiftDeploymentResource(hyd); err != nil {
 			_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, err.Error(), hypdeployment.MisConfiguredReason)
@@ -84,6 +112,11 @@ func (r *HypershiftDeploymentReconciler) createAzureInfra(hyd *hypdeployment.Hyp
 			return ctrl.Result{}, err
 		}
 		log.Info("Infrastructure configured")
+		r.recordEvent(hyd, corev1.EventTypeNormal, InfraCreatedEvent, "Created Azure infrastructure with infra-id: %s", hyd.Spec.InfraID)
+
+		if err := r.clearInfraRetry(hyd); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
 	return ctrl.Result{}, nil
@@ -93,36 +126,40 @@ func (r *HypershiftDeploymentReconciler) destroyAzureInfrastructure(hyd *hypdepl
 	log := r.Log
 	ctx := r.ctx
 
-	credentials, err := getAzureCloudProviderCreds(providerSecret)
+	creds, err := r.InfraHandler.AzureCredentialSource(providerSecret)
 	if err != nil {
-		log.Error(err, "could not correctly retreive the osServicePrincipal from the cloud provider "+providerSecret.Name)
+		log.Error(err, "could not read the Azure credentials from the cloud provider "+providerSecret.Name)
 		return ctrl.Result{}, r.updateStatusConditionsOnChange(
 			hyd, hypdeployment.ProviderSecretConfigured,
 			metav1.ConditionFalse,
-			"The cloud provider secret does not contain a valid osServicePrincipal.json value", hypdeployment.MisConfiguredReason)
+			err.Error(), hypdeployment.MisConfiguredReason)
+	}
+
+	// Without a client secret the destroy can never succeed, it is not retried until the cloud provider secret changes
+	if _, err := azureServicePrincipal(creds); err != nil {
+		log.Error(err, "could not destroy the Azure infrastructure")
+		return r.retryInfraOperation(hyd, providerSecret, metrics.OperationDestroyInfra, hypdeployment.PlatformConfigured, hypdeployment.PlatfromDestroyReason, err)
 	}
 	_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, "Removing Azure infrastructure with infra-id: "+hyd.Spec.InfraID, hypdeployment.PlatfromDestroyReason)
 
 	log.Info("Deleting Infrastructure on provider")
-	if err := r.InfraHandler.AzureInfraDestroyer(
+	start := time.Now()
+	err = r.InfraHandler.AzureInfraDestroyer(
 		hyd.Name,
 		hyd.Spec.Infrastructure.Platform.Azure.Location,
 		hyd.Spec.InfraID,
-		credentials,
-	)(ctx); err != nil {
-		log.Error(err, "there was a problem destroying infrastructure on the provider, retrying in 30s")
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, r.updateStatusConditionsOnChange(
-			hyd, hypdeployment.PlatformConfigured,
-			metav1.ConditionFalse,
-			err.Error(),
-			hypdeployment.PlatfromDestroyReason)
+		creds,
+	)(ctx)
+	metrics.ObserveInfraOperation(metrics.PlatformAzure, metrics.OperationDestroyInfra, start, err)
+	if err != nil {
+		log.Error(err, "there was a problem destroying infrastructure on the provider")
+		res, stErr := r.retryInfraOperation(hyd, providerSecret, metrics.OperationDestroyInfra, hypdeployment.PlatformConfigured, hypdeployment.PlatfromDestroyReason, err)
+		if res.Requeue {
+			r.recordEvent(hyd, corev1.EventTypeWarning, InfraDestroyRetryEvent, "Failed to destroy Azure infrastructure, retrying in %s: %v", res.RequeueAfter, err)
+		}
+		return res, stErr
 	}
+	r.recordEvent(hyd, corev1.EventTypeNormal, InfraDestroyedEvent, "Destroyed Azure infrastructure with infra-id: %s", hyd.Spec.InfraID)
 
 	return ctrl.Result{}, nil
 }
-
-func getAzureCloudProviderCreds(providerSecret *corev1.Secret) (*fixtures.AzureCreds, error) {
-	credentials := &fixtures.AzureCreds{}
-	err := json.Unmarshal(providerSecret.Data["osServicePrincipal.json"], &credentials)
-	return credentials, err
-}
@@ -6,6 +6,7 @@ import (
 
 	hydapi "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
 	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -159,3 +160,50 @@ func TestDestroyAzureInfra(t *testing.T) {
 	assert.Equal(t, hypdeployment.PlatfromDestroyReason, c.Reason, "expected to be destroying")
 	assert.Equal(t, "failed to destroy azure infrastructure", c.Message, "expected message when AzureInfraDestroyer is successful")
 }
+
+func TestAzureInfraManagedIdentity(t *testing.T) {
+	ctx := context.Background()
+	hyd := getFakeAzureHD()
+	r := GetHypershiftDeploymentReconciler()
+
+	hydapi.AddToScheme(r.Scheme)
+	r.Client.Create(ctx, hyd)
+	defer r.Client.Delete(ctx, hyd)
+
+	providerSecret := getProviderSecret()
+	providerSecret.Data = map[string][]byte{
+		constant.CredentialSource:    []byte(AzureCredentialSourceManagedIdentity),
+		constant.AzureClientID:       []byte("11111111-1111-1111-1111-111111111111"),
+		constant.AzureSubscriptionID: []byte("33333333-3333-3333-3333-333333333333"),
+	}
+	r.InfraHandler = &DefaultInfraHandler{ControllerIdentities: ControllerIdentities{"default": {"11111111-1111-1111-1111-111111111111"}}}
+
+	t.Log("The infrastructure is not created without a client secret")
+	hyd.Spec.Infrastructure.Platform.Azure.Location = "centralus"
+	_, err := r.createAzureInfra(hyd, providerSecret)
+	assert.Nil(t, err, "nil, when problem condition is written correctly")
+
+	c := meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.ProviderSecretConfigured))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, metav1.ConditionFalse, c.Status)
+		assert.Equal(t, hypdeployment.MisConfiguredReason, c.Reason)
+	}
+	assert.Nil(t, meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured)), "the creation is not attempted")
+	assert.Nil(t, hyd.Status.InfraRetry, "not retried")
+
+	t.Log("The destroy is not retried, the finalizer is kept")
+	hyd.Status.Conditions = nil
+	res, err := r.destroyAzureInfrastructure(hyd, providerSecret)
+	assert.Nil(t, err)
+	assert.False(t, res.Requeue)
+	assert.True(t, infraFailed(hyd), "the InfrastructureFailed condition keeps the finalizer")
+
+	c = meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, metav1.ConditionFalse, c.Status)
+		assert.Equal(t, hypdeployment.PlatfromDestroyReason, c.Reason)
+	}
+	if assert.NotNil(t, hyd.Status.InfraRetry) {
+		assert.Equal(t, hypdeployment.InfraErrorAuthentication, hyd.Status.InfraRetry.LastErrorClass)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+// CapacityScheduler admits a new HostedCluster to a hosting cluster while it has room for it. The HostedClusters
+// of a hosting cluster are the HypershiftDeployments admitted to it, and the HypershiftDeployments with a ManifestWork
+// in its namespace
+type CapacityScheduler struct {
+	Client client.Client
+
+	// MaxPerCluster is the number of HostedClusters on a hosting cluster, 0 is unlimited. The
+	// constant.MaxHostedClustersAnnotation of a ManagedCluster overrides it
+	MaxPerCluster int
+
+	// CPUPerHostedCluster and MemoryPerHostedCluster are reserved for each HostedCluster from the allocatable
+	// resources of the ManagedCluster status, a zero quantity is not checked
+	CPUPerHostedCluster    resource.Quantity
+	MemoryPerHostedCluster resource.Quantity
+
+	// Reject fails a HypershiftDeployment that does not fit, instead of queueing it until a HostedCluster is removed
+	Reject bool
+}
+
+// Admit returns an empty message when the HostedCluster of the HypershiftDeployment fits on the hosting cluster,
+// otherwise why it does not. The HypershiftDeployments queued for the hosting cluster are admitted oldest first
+func (s *CapacityScheduler) Admit(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, cluster string) (string, error) {
+	mc := &clusterv1.ManagedCluster{}
+	if err := s.Client.Get(ctx, types.NamespacedName{Name: cluster}, mc); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The ManagedCluster is validated with the security constraints
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get ManagedCluster %s, err: %w", cluster, err)
+	}
+
+	hydList := &hypdeployment.HypershiftDeploymentList{}
+	if err := s.Client.List(ctx, hydList); err != nil {
+		return "", fmt.Errorf("failed to list the HypershiftDeployments, err: %w", err)
+	}
+
+	count, err := s.hostedClusters(ctx, hyd, cluster, hydList)
+	if err != nil {
+		return "", err
+	}
+
+	free, limit := s.freeSlots(mc, count)
+	if free < 0 {
+		return "", nil
+	}
+
+	queued := s.queuedBefore(hyd, cluster, hydList)
+
+	if free > queued {
+		return "", nil
+	}
+
+	message := fmt.Sprintf("Hosting cluster %s runs %d HostedCluster(s), %s", cluster, count, limit)
+	if queued != 0 {
+		message += fmt.Sprintf(", %d HypershiftDeployment(s) are queued before this one", queued)
+	}
+	return message, nil
+}
+
+// hostedClusters counts the other HypershiftDeployments admitted to the hosting cluster, their ManifestWork is only
+// created once the infrastructure is configured, and those with a ManifestWork on it. A migrating HostedCluster
+// counts on both hosting clusters
+func (s *CapacityScheduler) hostedClusters(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, cluster string, hydList *hypdeployment.HypershiftDeploymentList) (int, error) {
+	works := &workv1.ManifestWorkList{}
+	if err := s.Client.List(ctx, works, client.InNamespace(cluster)); err != nil {
+		return 0, fmt.Errorf("failed to list the manifestworks of hosting cluster %s, err: %w", cluster, err)
+	}
+
+	self := hyd.Namespace + constant.NamespaceNameSeperator + hyd.Name
+	owners := sets.NewString()
+	for _, w := range works.Items {
+		if owner := w.Annotations[constant.CreatedByHypershiftDeployment]; len(owner) != 0 && owner != self {
+			owners.Insert(owner)
+		}
+	}
+	for i := range hydList.Items {
+		other := &hydList.Items[i]
+		owner := other.Namespace + constant.NamespaceNameSeperator + other.Name
+		if owner != self && isAdmitted(other) && helper.GetHostingClusterName(other) == cluster {
+			owners.Insert(owner)
+		}
+	}
+	return owners.Len(), nil
+}
+
+// freeSlots is the number of HostedClusters the hosting cluster can still run and the limit reached first, the
+// number is negative when there is no limit
+func (s *CapacityScheduler) freeSlots(mc *clusterv1.ManagedCluster, count int) (int, string) {
+	free := -1
+	limit := ""
+	setLimit := func(fits int, message string) {
+		if fits < 0 {
+			fits = 0
+		}
+		if free < 0 || fits < free {
+			free = fits
+			limit = message
+		}
+	}
+
+	max := s.MaxPerCluster
+	if v, ok := mc.Annotations[constant.MaxHostedClustersAnnotation]; ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n >= 0 {
+			// 0 stops new HostedClusters from being scheduled, for example before a maintenance
+			setLimit(n-count, fmt.Sprintf("the annotation %s allows %d", constant.MaxHostedClustersAnnotation, n))
+			max = 0
+		}
+	}
+	if max > 0 {
+		setLimit(max-count, fmt.Sprintf("the limit is %d", max))
+	}
+
+	budgets := []struct {
+		name        clusterv1.ResourceName
+		perInstance resource.Quantity
+	}{
+		{clusterv1.ResourceCPU, s.CPUPerHostedCluster},
+		{clusterv1.ResourceMemory, s.MemoryPerHostedCluster},
+	}
+	for _, b := range budgets {
+		if b.perInstance.IsZero() {
+			continue
+		}
+
+		allocatable := mc.Status.Allocatable[b.name]
+		fits := int(allocatable.MilliValue() / b.perInstance.MilliValue())
+		setLimit(fits-count, fmt.Sprintf("its allocatable %s %s fits %d with %s each",
+			b.name, allocatable.String(), fits, b.perInstance.String()))
+	}
+
+	return free, limit
+}
+
+// queuedBefore counts the HypershiftDeployments waiting for the hosting cluster that were created first
+func (s *CapacityScheduler) queuedBefore(hyd *hypdeployment.HypershiftDeployment, cluster string, hydList *hypdeployment.HypershiftDeploymentList) int {
+	if s.Reject {
+		return 0
+	}
+
+	queued := 0
+	for i := range hydList.Items {
+		other := &hydList.Items[i]
+		if other.Namespace == hyd.Namespace && other.Name == hyd.Name {
+			continue
+		}
+		if !isWaitingForCapacity(other) || helper.GetHostingClusterName(other) != cluster {
+			continue
+		}
+
+		if other.CreationTimestamp.Before(&hyd.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&hyd.CreationTimestamp) &&
+				other.Namespace+constant.NamespaceNameSeperator+other.Name < hyd.Namespace+constant.NamespaceNameSeperator+hyd.Name) {
+			queued++
+		}
+	}
+	return queued
+}
+
+func isWaitingForCapacity(hyd *hypdeployment.HypershiftDeployment) bool {
+	c := meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.WorkConfigured))
+	return c != nil && c.Reason == hypdeployment.WaitingForCapacityReason
+}
+
+// isAdmitted is true once the HostedCluster was admitted to its hosting cluster, until it is deleted. A
+// HypershiftDeployment that is waiting, or was rejected or misconfigured before its ManifestWork is created, does not
+// take a slot
+func isAdmitted(hyd *hypdeployment.HypershiftDeployment) bool {
+	if hyd.Spec.IsInfrastructureOnly() {
+		return false
+	}
+	c := meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.WorkConfigured))
+	return c != nil && c.Reason != hypdeployment.WaitingForCapacityReason && c.Reason != hypdeployment.MisConfiguredReason
+}
+
+// admitHostedCluster checks the capacity of the hosting cluster before the infrastructure and the manifestwork of the
+// HypershiftDeployment are created, a HostedCluster that is already scheduled keeps running where it is
+func (r *HypershiftDeploymentReconciler) admitHostedCluster(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (ctrl.Result, bool, error) {
+	if r.CapacityScheduler == nil {
+		return ctrl.Result{}, true, nil
+	}
+
+	if err := r.Get(ctx, getManifestWorkKey(hyd), &workv1.ManifestWork{}); err == nil {
+		return ctrl.Result{}, true, nil
+	} else if !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, false, err
+	}
+
+	cluster := helper.GetHostingClusterName(hyd)
+	message, err := r.CapacityScheduler.Admit(ctx, hyd, cluster)
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	// The slot is taken while the infrastructure is configured, before the manifestwork is created
+	if len(message) == 0 {
+		if c := meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.WorkConfigured)); c != nil && !isWaitingForCapacity(hyd) {
+			return ctrl.Result{}, true, nil
+		}
+		return ctrl.Result{}, true, r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse,
+			"Admitted to hosting cluster "+cluster, hypdeployment.BeingConfiguredReason)
+	}
+
+	reason := hypdeployment.WaitingForCapacityReason
+	if r.CapacityScheduler.Reject {
+		reason = hypdeployment.MisConfiguredReason
+	}
+
+	if c := meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.WorkConfigured)); c == nil || c.Message != message {
+		r.recordEvent(hyd, corev1.EventTypeWarning, HostingClusterFullEvent, message)
+	}
+	r.Log.Info("Hosting cluster has no capacity", "message", message)
+	return ctrl.Result{RequeueAfter: time.Minute * 1}, false,
+		r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse, message, reason)
+}
@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+func getHostingManagedCluster(name string, cpu, memory string) *clusterv1.ManagedCluster {
+	mc := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	mc.Status.Allocatable = clusterv1.ResourceList{}
+	if len(cpu) != 0 {
+		mc.Status.Allocatable[clusterv1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if len(memory) != 0 {
+		mc.Status.Allocatable[clusterv1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return mc
+}
+
+// getHostedClusterWork is a manifestwork of another HypershiftDeployment on the hosting cluster
+func getHostedClusterWork(cluster, name, owner string) *workv1.ManifestWork {
+	return &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   cluster,
+			Annotations: map[string]string{constant.CreatedByHypershiftDeployment: owner},
+		},
+	}
+}
+
+func TestCapacitySchedulerAdmit(t *testing.T) {
+	cases := []struct {
+		name        string
+		scheduler   CapacityScheduler
+		annotation  string
+		cpu, memory string
+		works       int
+		admitted    bool
+	}{
+		{name: "no limit", works: 5, admitted: true},
+		{name: "below the max", scheduler: CapacityScheduler{MaxPerCluster: 3}, works: 2, admitted: true},
+		{name: "max reached", scheduler: CapacityScheduler{MaxPerCluster: 2}, works: 2},
+		{name: "annotation raises the max", scheduler: CapacityScheduler{MaxPerCluster: 2}, annotation: "3", works: 2, admitted: true},
+		{name: "annotation drains the cluster", annotation: "0"},
+		{name: "invalid annotation is ignored", scheduler: CapacityScheduler{MaxPerCluster: 3}, annotation: "many", works: 2, admitted: true},
+		{name: "cpu budget", scheduler: CapacityScheduler{CPUPerHostedCluster: resource.MustParse("4")}, cpu: "10", works: 2},
+		{name: "cpu budget left", scheduler: CapacityScheduler{CPUPerHostedCluster: resource.MustParse("4")}, cpu: "12", works: 2, admitted: true},
+		{name: "memory budget", scheduler: CapacityScheduler{MemoryPerHostedCluster: resource.MustParse("16Gi")}, memory: "32Gi", works: 2},
+		{name: "no allocatable memory", scheduler: CapacityScheduler{MemoryPerHostedCluster: resource.MustParse("16Gi")}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := initClient()
+			ctx := context.Background()
+
+			mc := getHostingManagedCluster("hosting", c.cpu, c.memory)
+			if len(c.annotation) != 0 {
+				mc.Annotations = map[string]string{constant.MaxHostedClustersAnnotation: c.annotation}
+			}
+			assert.Nil(t, client.Create(ctx, mc))
+
+			for i := 0; i < c.works; i++ {
+				owner := "other/hd" + string(rune('a'+i))
+				// A HostedCluster is counted once, whatever the number of its manifestworks
+				assert.Nil(t, client.Create(ctx, getHostedClusterWork("hosting", "infra"+string(rune('a'+i)), owner)))
+				assert.Nil(t, client.Create(ctx, getHostedClusterWork("hosting", "infra"+string(rune('a'+i))+"-etcd-backup", owner)))
+			}
+
+			testHD := getHDforManifestWork()
+			// The own manifestwork of the HypershiftDeployment is not counted
+			assert.Nil(t, client.Create(ctx, getHostedClusterWork("hosting", "own", testHD.Namespace+"/"+testHD.Name)))
+
+			c.scheduler.Client = client
+			message, err := c.scheduler.Admit(ctx, testHD, "hosting")
+			assert.Nil(t, err)
+			assert.Equal(t, c.admitted, len(message) == 0, message)
+		})
+	}
+}
+
+func TestCapacitySchedulerMissingManagedCluster(t *testing.T) {
+	s := &CapacityScheduler{Client: initClient()}
+	message, err := s.Admit(context.Background(), getHDforManifestWork(), "missing")
+	assert.Nil(t, err)
+	assert.Empty(t, message, "the security constraints report a missing ManagedCluster")
+}
+
+func TestCapacitySchedulerQueue(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	assert.Nil(t, client.Create(ctx, getHostingManagedCluster("local-cluster", "", "")))
+	assert.Nil(t, client.Create(ctx, getHostedClusterWork("local-cluster", "other", "other/hd")))
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client:            client,
+		Log:               ctrl.Log.WithName("tester"),
+		CapacityScheduler: &CapacityScheduler{Client: client, MaxPerCluster: 1},
+	}
+
+	res, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, res.RequeueAfter)
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.WorkConfigured))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, hyd.WaitingForCapacityReason, c.Reason)
+	}
+	assert.Equal(t, hyd.PhaseApplyingWork, resultHD.Status.Phase, "a queued HypershiftDeployment has not failed")
+	assert.NotNil(t, client.Get(ctx, getManifestWorkKey(resultHD), &workv1.ManifestWork{}), "no manifestwork while queued")
+
+	// An older HypershiftDeployment waiting for the same hosting cluster is admitted first
+	newer := getHDforManifestWork()
+	newer.Name = "test2"
+	newer.CreationTimestamp = metav1.NewTime(resultHD.CreationTimestamp.Add(time.Minute))
+	newer.Spec.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Delete(ctx, getHostedClusterWork("local-cluster", "other", "other/hd")))
+	message, err := hdr.CapacityScheduler.Admit(ctx, newer, "local-cluster")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, message)
+	assert.Contains(t, message, "1 HypershiftDeployment(s) are queued before this one")
+
+	_, err = hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.Nil(t, client.Get(ctx, getManifestWorkKey(resultHD), &workv1.ManifestWork{}), "the capacity was freed")
+
+	// An applied HostedCluster keeps running when the limit is lowered
+	hdr.CapacityScheduler.MaxPerCluster = 0
+	mc := &clusterv1.ManagedCluster{}
+	assert.Nil(t, client.Get(ctx, types.NamespacedName{Name: "local-cluster"}, mc))
+	mc.Annotations = map[string]string{constant.MaxHostedClustersAnnotation: "0"}
+	assert.Nil(t, client.Update(ctx, mc))
+
+	_, err = hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.NotEqual(t, hyd.WaitingForCapacityReason, meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.WorkConfigured)).Reason)
+}
+
+func TestCapacitySchedulerReject(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	mc := getHostingManagedCluster("local-cluster", "", "")
+	mc.Annotations = map[string]string{constant.MaxHostedClustersAnnotation: "0"}
+	assert.Nil(t, client.Create(ctx, mc))
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client:            client,
+		Log:               ctrl.Log.WithName("tester"),
+		CapacityScheduler: &CapacityScheduler{Client: client, Reject: true},
+	}
+
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err)
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.WorkConfigured))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, hyd.MisConfiguredReason, c.Reason)
+	}
+	assert.Equal(t, hyd.PhaseFailed, resultHD.Status.Phase)
+}
+
+func TestCapacitySchedulerBeforeInfrastructure(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	assert.Nil(t, client.Create(ctx, getHostingManagedCluster("local-cluster", "", "")))
+	assert.Nil(t, client.Create(ctx, getHostedClusterWork("local-cluster", "other", "other/hd")))
+
+	testHD := getHypershiftDeployment("default", "test1", true)
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Infrastructure.CloudProvider = corev1.LocalObjectReference{Name: getProviderSecret().Name}
+	testHD.Spec.Infrastructure.Platform = &hyd.Platforms{AWS: &hyd.AWSPlatform{Region: "us-east-1"}}
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getProviderSecret()))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client:            client,
+		Log:               ctrl.Log.WithName("tester"),
+		InfraHandler:      &FakeInfraHandler{},
+		CapacityScheduler: &CapacityScheduler{Client: client, MaxPerCluster: 1},
+	}
+
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err)
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.True(t, isWaitingForCapacity(resultHD))
+	assert.Nil(t, meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.PlatformConfigured)),
+		"the infrastructure is not created while the hosting cluster is full")
+	assert.Nil(t, resultHD.Spec.HostedClusterSpec)
+}
+
+func TestCapacitySchedulerAdmittedBeforeManifestWork(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	assert.Nil(t, client.Create(ctx, getHostingManagedCluster("local-cluster", "", "")))
+	assert.Nil(t, client.Create(ctx, getProviderSecret()))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client:            client,
+		Log:               ctrl.Log.WithName("tester"),
+		InfraHandler:      &FakeInfraHandler{},
+		CapacityScheduler: &CapacityScheduler{Client: client, MaxPerCluster: 1},
+	}
+
+	keys := []types.NamespacedName{}
+	for i, name := range []string{"test1", "test2"} {
+		testHD := getHypershiftDeployment("default", name, true)
+		testHD.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Duration(i) * time.Minute))
+		testHD.Spec.HostingCluster = "local-cluster"
+		testHD.Spec.Infrastructure.CloudProvider = corev1.LocalObjectReference{Name: getProviderSecret().Name}
+		testHD.Spec.Infrastructure.Platform = &hyd.Platforms{AWS: &hyd.AWSPlatform{Region: "us-east-1"}}
+		assert.Nil(t, client.Create(ctx, testHD))
+		keys = append(keys, types.NamespacedName{Namespace: "default", Name: name})
+	}
+
+	first := &hyd.HypershiftDeployment{}
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: keys[0]})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Get(ctx, keys[0], first))
+	assert.False(t, isWaitingForCapacity(first))
+	assert.True(t, isAdmitted(first), "the slot is taken while the infrastructure is created")
+	assert.NotNil(t, meta.FindStatusCondition(first.Status.Conditions, string(hyd.PlatformConfigured)))
+	assert.NotNil(t, client.Get(ctx, getManifestWorkKey(first), &workv1.ManifestWork{}), "no manifestwork before the IAM is configured")
+
+	second := &hyd.HypershiftDeployment{}
+	_, err = hdr.Reconcile(ctx, ctrl.Request{NamespacedName: keys[1]})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Get(ctx, keys[1], second))
+	assert.True(t, isWaitingForCapacity(second), "the only slot is taken by the first HypershiftDeployment")
+	assert.Nil(t, meta.FindStatusCondition(second.Status.Conditions, string(hyd.PlatformConfigured)))
+}
+
+func TestSelectHostingClusterWithCapacity(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingClusterPlacement = &corev1.LocalObjectReference{Name: "hosting"}
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPlacementDecision(testHD.Namespace, "hosting-decision-1", "hosting", "cluster1", "cluster2")))
+	for _, name := range []string{"cluster1", "cluster2"} {
+		assert.Nil(t, client.Create(ctx, getHostingManagedCluster(name, "", "")))
+		assert.Nil(t, client.Create(ctx, getHostedClusterWork(name, "other", "other/hd")))
+	}
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client:            client,
+		Log:               ctrl.Log.WithName("tester"),
+		ctx:               ctx,
+		CapacityScheduler: &CapacityScheduler{Client: client, MaxPerCluster: 1},
+	}
+
+	selected, err := hdr.selectHostingCluster(ctx, testHD)
+	assert.Nil(t, err)
+	assert.False(t, selected, "both clusters are full")
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hyd.HostingClusterSelected))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, hyd.WaitingForCapacityReason, c.Reason)
+	}
+
+	assert.Nil(t, client.Delete(ctx, getHostedClusterWork("cluster2", "other", "other/hd")))
+	selected, err = hdr.selectHostingCluster(ctx, testHD)
+	assert.Nil(t, err)
+	assert.True(t, selected)
+	assert.Equal(t, "cluster2", testHD.Status.HostingCluster, "the full cluster1 is skipped")
+}
@@ -65,11 +65,12 @@ func duplicateSecretWithOverride(in *corev1.Secret, ops ...override) *corev1.Sec
 	return out
 }
 
-func (r *HypershiftDeploymentReconciler) generateSecret(ctx context.Context, key types.NamespacedName, ops ...override) (*corev1.Secret, error) {
+func (r *HypershiftDeploymentReconciler) generateSecret(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, key types.NamespacedName, ops ...override) (*corev1.Secret, error) {
 	origin := &corev1.Secret{}
 	if err := r.Get(ctx, key, origin); err != nil {
 		return nil, fmt.Errorf("failed to get the pull secret %v, err: %w", key, err)
 	}
+	recordCopiedSource(hyd, sourceKindSecret, origin)
 
 	return duplicateSecretWithOverride(origin, ops...), nil
 }
@@ -84,6 +85,8 @@ func duplicateConfigMapWithOverride(in *corev1.ConfigMap, ops ...override) *core
 
 	out.SetName(in.GetName())
 	out.SetLabels(in.GetLabels())
+	out.Data = in.Data
+	out.BinaryData = in.BinaryData
 
 	for _, o := range ops {
 		o(out)
@@ -92,11 +95,12 @@ func duplicateConfigMapWithOverride(in *corev1.ConfigMap, ops ...override) *core
 	return out
 }
 
-func (r *HypershiftDeploymentReconciler) generateConfigMap(ctx context.Context, key types.NamespacedName, ops ...override) (*corev1.ConfigMap, error) {
+func (r *HypershiftDeploymentReconciler) generateConfigMap(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, key types.NamespacedName, ops ...override) (*corev1.ConfigMap, error) {
 	origin := &corev1.ConfigMap{}
 	if err := r.Get(ctx, key, origin); err != nil {
 		return nil, fmt.Errorf("failed to get the configMap, err: %w", err)
 	}
+	recordCopiedSource(hyd, sourceKindConfigMap, origin)
 
 	return duplicateConfigMapWithOverride(origin, ops...), nil
 }
@@ -217,7 +221,7 @@ func (r *HypershiftDeploymentReconciler) ensureConfiguration(ctx context.Context
 		for _, se := range secretRefs {
 			// 1. Use user provided secret
 			k := genKey(se.secretRef, hyd)
-			secret, err := r.generateSecret(ctx, k, overrideNamespace(helper.GetHostingNamespace(hyd)))
+			secret, err := r.generateSecret(ctx, hyd, k, overrideNamespace(helper.GetHostingNamespace(hyd)))
 			if err != nil && !apierrors.IsNotFound(err) {
 				r.Log.Info(fmt.Sprintf("did not find and copy secret %s: %s", k, err.Error()))
 			}
@@ -250,7 +254,7 @@ func (r *HypershiftDeploymentReconciler) ensureConfiguration(ctx context.Context
 
 		for _, cm := range configMapRefs {
 			k := genKey(cm, hyd)
-			t, err := r.generateConfigMap(ctx, k, overrideNamespace(helper.GetHostingNamespace(hyd)))
+			t, err := r.generateConfigMap(ctx, hyd, k, overrideNamespace(helper.GetHostingNamespace(hyd)))
 			if err != nil {
 				r.Log.Error(err, fmt.Sprintf("failed to copy secret %s", k))
 				allErr = append(allErr, err)
@@ -0,0 +1,403 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/openshift/hypershift/api/fixtures"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+// Values of the credential_source field of the cloud provider secret
+const (
+	AWSCredentialSourceStatic      = "Static"
+	AWSCredentialSourceAssumeRole  = "AssumeRole"
+	AWSCredentialSourceWebIdentity = "WebIdentity"
+
+	AzureCredentialSourceServicePrincipal = "ServicePrincipal"
+	AzureCredentialSourceWorkloadIdentity = "WorkloadIdentity"
+	AzureCredentialSourceManagedIdentity  = "ManagedIdentity"
+
+	credentialSessionName = "hypershift-deployment-controller"
+)
+
+// ErrCredentialSourceUnsupported is returned by the operations that can not use the credential source
+var ErrCredentialSourceUnsupported = errors.New("the credential source is not supported by this operation")
+
+// AWSCredentialSource provides the credentials of the AWS infrastructure and IAM operations
+type AWSCredentialSource interface {
+	// Type is the credential_source of the cloud provider secret
+	Type() string
+	// Retrieve returns credentials valid for at least one operation in the region
+	Retrieve(ctx context.Context, region string) (credentials.Value, error)
+}
+
+// AzureCredentialSource provides the credentials of the Azure infrastructure operations
+type AzureCredentialSource interface {
+	// Type is the credential_source of the cloud provider secret
+	Type() string
+	SubscriptionID() string
+	// Authorizer authenticates the Azure Resource Manager clients
+	Authorizer() (autorest.Authorizer, error)
+	// ServicePrincipal is the client secret required by the Hypershift Azure infrastructure library, nil when the
+	// source does not have one
+	ServicePrincipal() *fixtures.AzureCreds
+}
+
+// ControllerIdentities are the AWS role ARNs and Azure client IDs the cloud provider secrets of a namespace can use
+// with the identity of the controller, the "*" namespace applies to every namespace. Without an entry, a secret
+// could act as the controller on the cloud provider
+type ControllerIdentities map[string][]string
+
+// ParseControllerIdentities reads a comma separated list of namespace=identity, ie:
+// team-a=arn:aws:iam::123456789012:role/hypershift,*=11111111-1111-1111-1111-111111111111
+func ParseControllerIdentities(value string) (ControllerIdentities, error) {
+	identities := ControllerIdentities{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		namespace, identity, found := strings.Cut(entry, "=")
+		namespace, identity = strings.TrimSpace(namespace), strings.TrimSpace(identity)
+		if !found || len(namespace) == 0 || len(identity) == 0 {
+			return nil, fmt.Errorf("invalid controller identity %q, expected namespace=identity", entry)
+		}
+		identities[namespace] = append(identities[namespace], identity)
+	}
+	return identities, nil
+}
+
+// allow returns an error unless the identity is listed for the namespace of the cloud provider secret
+func (c ControllerIdentities) allow(providerSecret *corev1.Secret, identity string) error {
+	for _, namespace := range []string{providerSecret.Namespace, "*"} {
+		for _, allowed := range c[namespace] {
+			if allowed == identity {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s is not allowed for the cloud provider secrets of namespace %s, the controller identities are listed by --controller-identities",
+		identity, providerSecret.Namespace)
+}
+
+// AWSCredentialSource reads the credential source from the cloud provider secret, nothing is requested from the
+// provider until the credentials are retrieved
+func (h *DefaultInfraHandler) AWSCredentialSource(providerSecret *corev1.Secret) (AWSCredentialSource, error) {
+	static := &staticAWSCredentials{
+		key:       string(providerSecret.Data[constant.AWSAccessKeyID]),
+		secretKey: string(providerSecret.Data[constant.AWSSecretAccessKey]),
+	}
+	roleARN := string(providerSecret.Data[constant.AWSRoleARN])
+
+	switch sourceType := string(providerSecret.Data[constant.CredentialSource]); sourceType {
+	case "", AWSCredentialSourceStatic:
+		return static, nil
+
+	case AWSCredentialSourceAssumeRole:
+		if roleARN == "" {
+			return nil, fmt.Errorf("%s is required for the %s credential source", constant.AWSRoleARN, sourceType)
+		}
+		provider := func(sess *session.Session) credentials.Provider {
+			p := &stscreds.AssumeRoleProvider{
+				Client:          sts.New(sess),
+				RoleARN:         roleARN,
+				RoleSessionName: credentialSessionName,
+				Duration:        time.Hour,
+			}
+			if externalID := string(providerSecret.Data[constant.AWSExternalID]); externalID != "" {
+				p.ExternalID = awssdk.String(externalID)
+			}
+			return p
+		}
+		// Without keys, the role is assumed with the credentials of the controller, so the role must be allowed for
+		// the namespace and its trust policy must check the external ID
+		var base *credentials.Credentials
+		if static.key != "" && static.secretKey != "" {
+			base = credentials.NewStaticCredentials(static.key, static.secretKey, "")
+		} else {
+			if len(providerSecret.Data[constant.AWSExternalID]) == 0 {
+				return nil, fmt.Errorf("%s is required when the role is assumed with the credentials of the controller", constant.AWSExternalID)
+			}
+			if err := h.ControllerIdentities.allow(providerSecret, roleARN); err != nil {
+				return nil, err
+			}
+		}
+		return &stsAWSCredentials{sourceType: sourceType, base: base, provider: provider}, nil
+
+	case AWSCredentialSourceWebIdentity:
+		if roleARN == "" {
+			return nil, fmt.Errorf("%s is required for the %s credential source", constant.AWSRoleARN, sourceType)
+		}
+		if h.AWSWebIdentityTokenFile == "" {
+			return nil, fmt.Errorf("the controller is not configured with an AWS web identity token file")
+		}
+		if err := h.ControllerIdentities.allow(providerSecret, roleARN); err != nil {
+			return nil, err
+		}
+		tokenFile := h.AWSWebIdentityTokenFile
+		provider := func(sess *session.Session) credentials.Provider {
+			return stscreds.NewWebIdentityRoleProviderWithToken(sts.New(sess), roleARN, credentialSessionName, stscreds.FetchTokenPath(tokenFile))
+		}
+		return &stsAWSCredentials{sourceType: sourceType, base: credentials.AnonymousCredentials, provider: provider}, nil
+	}
+
+	return nil, fmt.Errorf("unknown AWS %s: %s", constant.CredentialSource, providerSecret.Data[constant.CredentialSource])
+}
+
+// AzureCredentialSource reads the credential source from the cloud provider secret
+func (h *DefaultInfraHandler) AzureCredentialSource(providerSecret *corev1.Secret) (AzureCredentialSource, error) {
+	clientID := string(providerSecret.Data[constant.AzureClientID])
+	tenantID := string(providerSecret.Data[constant.AzureTenantID])
+	subscriptionID := string(providerSecret.Data[constant.AzureSubscriptionID])
+
+	switch sourceType := string(providerSecret.Data[constant.CredentialSource]); sourceType {
+	case "", AzureCredentialSourceServicePrincipal:
+		creds, err := getAzureCloudProviderCreds(providerSecret)
+		if err != nil {
+			return nil, fmt.Errorf("the cloud provider secret does not contain a valid %s value: %w", constant.AzureServicePrincipal, err)
+		}
+		return &servicePrincipalAzureCredentials{creds: creds}, nil
+
+	case AzureCredentialSourceWorkloadIdentity:
+		if clientID == "" || tenantID == "" || subscriptionID == "" {
+			return nil, fmt.Errorf("%s, %s and %s are required for the %s credential source",
+				constant.AzureClientID, constant.AzureTenantID, constant.AzureSubscriptionID, sourceType)
+		}
+		if h.AzureFederatedTokenFile == "" {
+			return nil, fmt.Errorf("the controller is not configured with an Azure federated token file")
+		}
+		if err := h.ControllerIdentities.allow(providerSecret, clientID); err != nil {
+			return nil, err
+		}
+		return &workloadIdentityAzureCredentials{
+			clientID:       clientID,
+			tenantID:       tenantID,
+			subscriptionID: subscriptionID,
+			tokenFile:      h.AzureFederatedTokenFile,
+		}, nil
+
+	case AzureCredentialSourceManagedIdentity:
+		// The system assigned identity of the controller node can not be allowed, a user assigned one is required
+		if clientID == "" || subscriptionID == "" {
+			return nil, fmt.Errorf("%s and %s are required for the %s credential source",
+				constant.AzureClientID, constant.AzureSubscriptionID, sourceType)
+		}
+		if err := h.ControllerIdentities.allow(providerSecret, clientID); err != nil {
+			return nil, err
+		}
+		return &managedIdentityAzureCredentials{clientID: clientID, subscriptionID: subscriptionID}, nil
+	}
+
+	return nil, fmt.Errorf("unknown Azure %s: %s", constant.CredentialSource, providerSecret.Data[constant.CredentialSource])
+}
+
+func getAzureCloudProviderCreds(providerSecret *corev1.Secret) (*fixtures.AzureCreds, error) {
+	credentials := &fixtures.AzureCreds{}
+	err := json.Unmarshal(providerSecret.Data[constant.AzureServicePrincipal], &credentials)
+	return credentials, err
+}
+
+type staticAWSCredentials struct {
+	key       string
+	secretKey string
+}
+
+func (s *staticAWSCredentials) Type() string {
+	return AWSCredentialSourceStatic
+}
+
+func (s *staticAWSCredentials) Retrieve(ctx context.Context, region string) (credentials.Value, error) {
+	return credentials.Value{AccessKeyID: s.key, SecretAccessKey: s.secretKey}, nil
+}
+
+// stsAWSCredentials are temporary credentials issued by STS, base signs the STS request, nil uses the default
+// credential chain of the controller
+type stsAWSCredentials struct {
+	sourceType string
+	base       *credentials.Credentials
+	provider   func(sess *session.Session) credentials.Provider
+}
+
+func (s *stsAWSCredentials) Type() string {
+	return s.sourceType
+}
+
+func (s *stsAWSCredentials) Retrieve(ctx context.Context, region string) (credentials.Value, error) {
+	sess, err := session.NewSession(&awssdk.Config{Credentials: s.base, Region: awssdk.String(region)})
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	return credentials.NewCredentials(s.provider(sess)).GetWithContext(ctx)
+}
+
+type servicePrincipalAzureCredentials struct {
+	creds *fixtures.AzureCreds
+}
+
+func (s *servicePrincipalAzureCredentials) Type() string {
+	return AzureCredentialSourceServicePrincipal
+}
+
+func (s *servicePrincipalAzureCredentials) SubscriptionID() string {
+	return s.creds.SubscriptionID
+}
+
+func (s *servicePrincipalAzureCredentials) Authorizer() (autorest.Authorizer, error) {
+	return auth.ClientCredentialsConfig{
+		TenantID:     s.creds.TenantID,
+		ClientID:     s.creds.ClientID,
+		ClientSecret: s.creds.ClientSecret,
+		AADEndpoint:  azure.PublicCloud.ActiveDirectoryEndpoint,
+		Resource:     azure.PublicCloud.ResourceManagerEndpoint,
+	}.Authorizer()
+}
+
+func (s *servicePrincipalAzureCredentials) ServicePrincipal() *fixtures.AzureCreds {
+	return s.creds
+}
+
+type workloadIdentityAzureCredentials struct {
+	clientID       string
+	tenantID       string
+	subscriptionID string
+	tokenFile      string
+}
+
+func (s *workloadIdentityAzureCredentials) Type() string {
+	return AzureCredentialSourceWorkloadIdentity
+}
+
+func (s *workloadIdentityAzureCredentials) SubscriptionID() string {
+	return s.subscriptionID
+}
+
+func (s *workloadIdentityAzureCredentials) Authorizer() (autorest.Authorizer, error) {
+	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, s.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	spt, err := adal.NewServicePrincipalTokenWithSecret(*oauthConfig, s.clientID, azure.PublicCloud.ResourceManagerEndpoint, &federatedTokenSecret{tokenFile: s.tokenFile})
+	if err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(spt), nil
+}
+
+func (s *workloadIdentityAzureCredentials) ServicePrincipal() *fixtures.AzureCreds {
+	return nil
+}
+
+// federatedTokenSecret exchanges the service account token of the controller for an Azure AD token
+type federatedTokenSecret struct {
+	tokenFile string
+}
+
+func (s *federatedTokenSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, values *url.Values) error {
+	// Read on every refresh, the projected token is rotated by the kubelet
+	token, err := os.ReadFile(s.tokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the federated token: %w", err)
+	}
+	values.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	values.Set("client_assertion", strings.TrimSpace(string(token)))
+	return nil
+}
+
+type managedIdentityAzureCredentials struct {
+	clientID       string
+	subscriptionID string
+}
+
+func (s *managedIdentityAzureCredentials) Type() string {
+	return AzureCredentialSourceManagedIdentity
+}
+
+func (s *managedIdentityAzureCredentials) SubscriptionID() string {
+	return s.subscriptionID
+}
+
+func (s *managedIdentityAzureCredentials) Authorizer() (autorest.Authorizer, error) {
+	spt, err := adal.NewServicePrincipalTokenFromManagedIdentity(azure.PublicCloud.ResourceManagerEndpoint, &adal.ManagedIdentityOptions{ClientID: s.clientID})
+	if err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(spt), nil
+}
+
+func (s *managedIdentityAzureCredentials) ServicePrincipal() *fixtures.AzureCreds {
+	return nil
+}
+
+// azureServicePrincipal returns the client secret the Hypershift Azure infrastructure library requires
+func azureServicePrincipal(creds AzureCredentialSource) (*fixtures.AzureCreds, error) {
+	if sp := creds.ServicePrincipal(); sp != nil {
+		return sp, nil
+	}
+	return nil, fmt.Errorf("%w: the Azure infrastructure can only be created and destroyed with the %s credential source, not %s",
+		ErrCredentialSourceUnsupported, AzureCredentialSourceServicePrincipal, creds.Type())
+}
+
+// awsCredentialOptions resolves the source into the key pair of the Hypershift AWS options, or into a temporary
+// credentials file when there is a session token, the options do not accept one. cleanup removes the file
+func awsCredentialOptions(ctx context.Context, creds AWSCredentialSource, region string) (key, secretKey, credentialsFile string, cleanup func(), err error) {
+	cleanup = func() {}
+
+	value, err := creds.Retrieve(ctx, region)
+	if err != nil {
+		return "", "", "", cleanup, fmt.Errorf("failed to retrieve the %s AWS credentials: %w", creds.Type(), err)
+	}
+	if value.SessionToken == "" {
+		return value.AccessKeyID, value.SecretAccessKey, "", cleanup, nil
+	}
+
+	f, err := os.CreateTemp("", "aws-credentials-")
+	if err != nil {
+		return "", "", "", cleanup, err
+	}
+	cleanup = func() { _ = os.Remove(f.Name()) }
+
+	_, err = fmt.Fprintf(f, "[default]\naws_access_key_id = %s\naws_secret_access_key = %s\naws_session_token = %s\n",
+		value.AccessKeyID, value.SecretAccessKey, value.SessionToken)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", "", "", func() {}, err
+	}
+	return "", "", f.Name(), cleanup, nil
+}
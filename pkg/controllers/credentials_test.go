@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+// sessionAWSCredentials returns temporary credentials, like the STS credential sources
+type sessionAWSCredentials struct{}
+
+func (s *sessionAWSCredentials) Type() string {
+	return AWSCredentialSourceAssumeRole
+}
+
+func (s *sessionAWSCredentials) Retrieve(ctx context.Context, region string) (credentials.Value, error) {
+	return credentials.Value{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}, nil
+}
+
+func getCredentialSecret(data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cloud", Namespace: "team-a"}, Data: map[string][]byte{}}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestParseControllerIdentities(t *testing.T) {
+	identities, err := ParseControllerIdentities(" team-a=arn:aws:iam::123456789012:role/hypershift, *=11111111-1111-1111-1111-111111111111,")
+	assert.Nil(t, err)
+	assert.Equal(t, ControllerIdentities{
+		"team-a": {"arn:aws:iam::123456789012:role/hypershift"},
+		"*":      {"11111111-1111-1111-1111-111111111111"},
+	}, identities)
+
+	identities, err = ParseControllerIdentities("")
+	assert.Nil(t, err)
+	assert.Empty(t, identities)
+
+	_, err = ParseControllerIdentities("arn:aws:iam::123456789012:role/hypershift")
+	assert.NotNil(t, err, "the namespace is required")
+}
+
+func TestAWSCredentialSource(t *testing.T) {
+	h := &DefaultInfraHandler{}
+
+	creds, err := h.AWSCredentialSource(getCredentialSecret(map[string]string{
+		constant.AWSAccessKeyID:     "AKIAEXAMPLE",
+		constant.AWSSecretAccessKey: "secret",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, AWSCredentialSourceStatic, creds.Type(), "static keys are the default")
+	value, err := creds.Retrieve(context.Background(), "us-east-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "AKIAEXAMPLE", value.AccessKeyID)
+
+	assumeRole := map[string]string{
+		constant.CredentialSource: AWSCredentialSourceAssumeRole,
+		constant.AWSRoleARN:       "arn:aws:iam::123456789012:role/hypershift",
+	}
+	_, err = h.AWSCredentialSource(getCredentialSecret(assumeRole))
+	assert.NotNil(t, err, "the external ID is required with the credentials of the controller")
+
+	assumeRole[constant.AWSExternalID] = "tenant-a"
+	_, err = h.AWSCredentialSource(getCredentialSecret(assumeRole))
+	assert.NotNil(t, err, "the role is not allowed for the namespace")
+
+	h.ControllerIdentities = ControllerIdentities{"team-a": {"arn:aws:iam::123456789012:role/hypershift"}}
+	creds, err = h.AWSCredentialSource(getCredentialSecret(assumeRole))
+	assert.Nil(t, err)
+	assert.Equal(t, AWSCredentialSourceAssumeRole, creds.Type())
+
+	h.ControllerIdentities = nil
+	creds, err = h.AWSCredentialSource(getCredentialSecret(map[string]string{
+		constant.CredentialSource:   AWSCredentialSourceAssumeRole,
+		constant.AWSRoleARN:         "arn:aws:iam::123456789012:role/hypershift",
+		constant.AWSAccessKeyID:     "AKIAEXAMPLE",
+		constant.AWSSecretAccessKey: "secret",
+	}))
+	assert.Nil(t, err, "the role is assumed with the keys of the secret, it does not need to be allowed")
+	assert.Equal(t, AWSCredentialSourceAssumeRole, creds.Type())
+
+	_, err = h.AWSCredentialSource(getCredentialSecret(map[string]string{constant.CredentialSource: AWSCredentialSourceAssumeRole}))
+	assert.NotNil(t, err, "a role is required")
+
+	_, err = h.AWSCredentialSource(getCredentialSecret(map[string]string{
+		constant.CredentialSource: AWSCredentialSourceWebIdentity,
+		constant.AWSRoleARN:       "arn:aws:iam::123456789012:role/hypershift",
+	}))
+	assert.NotNil(t, err, "the controller has no web identity token file")
+
+	h.AWSWebIdentityTokenFile = "/var/run/secrets/aws/token"
+	webIdentity := getCredentialSecret(map[string]string{
+		constant.CredentialSource: AWSCredentialSourceWebIdentity,
+		constant.AWSRoleARN:       "arn:aws:iam::123456789012:role/hypershift",
+	})
+	_, err = h.AWSCredentialSource(webIdentity)
+	assert.NotNil(t, err, "the role is not allowed for the namespace")
+
+	h.ControllerIdentities = ControllerIdentities{"*": {"arn:aws:iam::123456789012:role/hypershift"}}
+	creds, err = h.AWSCredentialSource(webIdentity)
+	assert.Nil(t, err)
+	assert.Equal(t, AWSCredentialSourceWebIdentity, creds.Type())
+
+	_, err = h.AWSCredentialSource(getCredentialSecret(map[string]string{constant.CredentialSource: "Unknown"}))
+	assert.NotNil(t, err)
+}
+
+func TestAzureCredentialSource(t *testing.T) {
+	h := &DefaultInfraHandler{}
+
+	creds, err := h.AzureCredentialSource(getProviderSecret())
+	assert.Nil(t, err)
+	assert.Equal(t, AzureCredentialSourceServicePrincipal, creds.Type(), "the service principal is the default")
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000", creds.SubscriptionID())
+	sp, err := azureServicePrincipal(creds)
+	assert.Nil(t, err)
+	assert.Equal(t, "abcdef123456", sp.ClientSecret)
+
+	identity := map[string]string{
+		constant.CredentialSource:    AzureCredentialSourceWorkloadIdentity,
+		constant.AzureClientID:       "11111111-1111-1111-1111-111111111111",
+		constant.AzureTenantID:       "22222222-2222-2222-2222-222222222222",
+		constant.AzureSubscriptionID: "33333333-3333-3333-3333-333333333333",
+	}
+	_, err = h.AzureCredentialSource(getCredentialSecret(identity))
+	assert.NotNil(t, err, "the controller has no federated token file")
+
+	h.AzureFederatedTokenFile = "/var/run/secrets/azure/tokens/azure-identity-token"
+	_, err = h.AzureCredentialSource(getCredentialSecret(identity))
+	assert.NotNil(t, err, "the client ID is not allowed for the namespace")
+
+	h.ControllerIdentities = ControllerIdentities{"team-a": {"11111111-1111-1111-1111-111111111111"}}
+	creds, err = h.AzureCredentialSource(getCredentialSecret(identity))
+	assert.Nil(t, err)
+	assert.Equal(t, "33333333-3333-3333-3333-333333333333", creds.SubscriptionID())
+
+	_, err = h.AzureCredentialSource(getCredentialSecret(map[string]string{constant.CredentialSource: AzureCredentialSourceWorkloadIdentity}))
+	assert.NotNil(t, err, "the identity is required")
+
+	_, err = h.AzureCredentialSource(getCredentialSecret(map[string]string{
+		constant.CredentialSource:    AzureCredentialSourceManagedIdentity,
+		constant.AzureSubscriptionID: "33333333-3333-3333-3333-333333333333",
+	}))
+	assert.NotNil(t, err, "the system assigned identity of the controller can not be used")
+
+	creds, err = h.AzureCredentialSource(getCredentialSecret(map[string]string{
+		constant.CredentialSource:    AzureCredentialSourceManagedIdentity,
+		constant.AzureClientID:       "11111111-1111-1111-1111-111111111111",
+		constant.AzureSubscriptionID: "33333333-3333-3333-3333-333333333333",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, AzureCredentialSourceManagedIdentity, creds.Type())
+
+	_, err = azureServicePrincipal(creds)
+	assert.True(t, errors.Is(err, ErrCredentialSourceUnsupported), "the infrastructure can not be created without a client secret")
+	assert.Equal(t, hypdeployment.InfraErrorAuthentication, classifyInfraError(err), "not retried")
+
+	_, err = h.AzureCredentialSource(getCredentialSecret(map[string]string{constant.CredentialSource: "Unknown"}))
+	assert.NotNil(t, err)
+}
+
+func TestFederatedTokenSecret(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.Nil(t, os.WriteFile(tokenFile, []byte("eyJhbGciOiJSUzI1NiJ9\n"), 0600))
+
+	values := url.Values{}
+	assert.Nil(t, (&federatedTokenSecret{tokenFile: tokenFile}).SetAuthenticationValues(nil, &values))
+	assert.Equal(t, "eyJhbGciOiJSUzI1NiJ9", values.Get("client_assertion"))
+	assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", values.Get("client_assertion_type"))
+
+	assert.NotNil(t, (&federatedTokenSecret{tokenFile: tokenFile + "-missing"}).SetAuthenticationValues(nil, &values))
+}
+
+func TestAWSCredentialOptions(t *testing.T) {
+	key, secretKey, file, cleanup, err := awsCredentialOptions(context.Background(), &staticAWSCredentials{key: "AKIAEXAMPLE", secretKey: "secret"}, "us-east-1")
+	assert.Nil(t, err)
+	cleanup()
+	assert.Equal(t, "AKIAEXAMPLE", key)
+	assert.Equal(t, "secret", secretKey)
+	assert.Empty(t, file, "static keys do not need a credentials file")
+
+	key, _, file, cleanup, err = awsCredentialOptions(context.Background(), &sessionAWSCredentials{}, "us-east-1")
+	assert.Nil(t, err)
+	assert.Empty(t, key)
+	content, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "aws_session_token = token")
+
+	cleanup()
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err), "the credentials file is removed")
+}
+
+func TestCreateAWSInfraInvalidCredentialSource(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	r.InfraHandler = &FakeInfraHandler{}
+	testHD := getRetryAWSHD(t, r)
+
+	providerSecret := getProviderSecret()
+	providerSecret.Data[constant.CredentialSource] = []byte(AWSCredentialSourceAssumeRole)
+
+	_, err := r.createAWSInfra(testHD, providerSecret)
+	assert.Nil(t, err)
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hypdeployment.ProviderSecretConfigured))
+	assert.NotNil(t, c)
+	assert.Equal(t, hypdeployment.MisConfiguredReason, c.Reason)
+	assert.Contains(t, c.Message, constant.AWSRoleARN)
+	assert.False(t, infraConfigured(testHD))
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -43,13 +44,10 @@ import (
 
 var resLog = ctrl.Log.WithName("resource-render")
 
+// getReleaseImagePullSpec is the fallback release image, the reconciler resolves the configured release
+// with ensureReleaseImage before scaffolding
 func getReleaseImagePullSpec() string {
-
-	//defaultVersion, err := version.LookupDefaultOCPVersion()
-	//if err != nil {
 	return constant.ReleaseImage
-	//}
-	//return defaultVersion.PullSpec
 }
 
 func (r *HypershiftDeploymentReconciler) scaffoldHostedCluster(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (*unstructured.Unstructured, error) {
@@ -183,12 +181,20 @@ func ScaffoldAWSHostedClusterSpec(hyd *hypdeployment.HypershiftDeployment, infra
 		aws.ResourceTags = []hyp.AWSResourceTag{}
 	}
 	//set the resource tags to prevent the work always updating the hostedcluster resource on the hosting cluster.
-	aws.ResourceTags = append(aws.ResourceTags,
-		hyp.AWSResourceTag{
-			Key:   "kubernetes.io/cluster/" + hyd.Spec.HostedClusterSpec.InfraID,
-			Value: "owned",
-		},
-	)
+	clusterTag := hyp.AWSResourceTag{
+		Key:   "kubernetes.io/cluster/" + hyd.Spec.HostedClusterSpec.InfraID,
+		Value: "owned",
+	}
+	// The spec is scaffolded again when drifted infrastructure is repaired
+	tagFound := false
+	for _, tag := range aws.ResourceTags {
+		if tag == clusterTag {
+			tagFound = true
+		}
+	}
+	if !tagFound {
+		aws.ResourceTags = append(aws.ResourceTags, clusterTag)
+	}
 	hyd.Spec.HostedClusterSpec.Platform.AWS = aws.DeepCopy()
 	hyd.Spec.HostedClusterSpec.Platform.AWS.CloudProviderConfig = scaffoldCloudProviderConfig(infraOut)
 	hyd.Spec.HostedClusterSpec.Platform.Type = hyp.AWSPlatform
@@ -201,6 +207,65 @@ func ScaffoldAWSHostedClusterSpec(hyd *hypdeployment.HypershiftDeployment, infra
 	}
 }
 
+func ScaffoldKubeVirtHostedClusterSpec(hyd *hypdeployment.HypershiftDeployment, baseDomain string) {
+	scaffoldHostedClusterSpec(hyd)
+	hyd.Spec.HostedClusterSpec.DNS = *scaffoldDnsSpec(baseDomain, "", "")
+	hyd.Spec.HostedClusterSpec.InfraID = hyd.Spec.InfraID
+	hyd.Spec.HostedClusterSpec.Platform.Type = hyp.KubevirtPlatform
+
+	// Without an address the services keep the LoadBalancer and Route defaults
+	address := hyd.Spec.Infrastructure.Platform.KubeVirt.APIServerAddress
+	if address != "" && reflect.DeepEqual(hyd.Spec.HostedClusterSpec.Services, defaultServices()) {
+		hyd.Spec.HostedClusterSpec.Services = scaffoldNodePortServices(address, hyd.Spec.HostedClusterSpec.Networking.NetworkType)
+	}
+}
+
+func ScaffoldAgentHostedClusterSpec(hyd *hypdeployment.HypershiftDeployment, baseDomain string) {
+	scaffoldHostedClusterSpec(hyd)
+	hyd.Spec.HostedClusterSpec.DNS = *scaffoldDnsSpec(baseDomain, "", "")
+	hyd.Spec.HostedClusterSpec.InfraID = hyd.Spec.InfraID
+	hyd.Spec.HostedClusterSpec.Platform.Type = hyp.AgentPlatform
+	hyd.Spec.HostedClusterSpec.Platform.Agent = &hyp.AgentPlatformSpec{
+		AgentNamespace: hyd.Spec.Infrastructure.Platform.Agent.AgentNamespace,
+	}
+
+	// Agents boot outside of the hosting cluster, so the services are always published on the node address
+	if reflect.DeepEqual(hyd.Spec.HostedClusterSpec.Services, defaultServices()) {
+		hyd.Spec.HostedClusterSpec.Services = scaffoldNodePortServices(
+			hyd.Spec.Infrastructure.Platform.Agent.APIServerAddress,
+			hyd.Spec.HostedClusterSpec.Networking.NetworkType)
+	}
+}
+
+func defaultServices() []hyp.ServicePublishingStrategyMapping {
+	return []hyp.ServicePublishingStrategyMapping{
+		spsMap(hyp.APIServer, hyp.LoadBalancer),
+		spsMap(hyp.OAuthServer, hyp.Route),
+		spsMap(hyp.Konnectivity, hyp.Route),
+		spsMap(hyp.Ignition, hyp.Route),
+	}
+}
+
+func scaffoldNodePortServices(address string, networkType hyp.NetworkType) []hyp.ServicePublishingStrategyMapping {
+	nodePort := func(service hyp.ServiceType, psType hyp.PublishingStrategyType) hyp.ServicePublishingStrategyMapping {
+		m := spsMap(service, psType)
+		m.NodePort = &hyp.NodePortPublishingStrategy{Address: address}
+		return m
+	}
+
+	services := []hyp.ServicePublishingStrategyMapping{
+		nodePort(hyp.APIServer, hyp.NodePort),
+		nodePort(hyp.OAuthServer, hyp.NodePort),
+		nodePort(hyp.OIDC, hyp.None),
+		nodePort(hyp.Konnectivity, hyp.NodePort),
+		nodePort(hyp.Ignition, hyp.NodePort),
+	}
+	if networkType == hyp.OVNKubernetes {
+		services = append(services, nodePort(hyp.OVNSbDb, hyp.NodePort))
+	}
+	return services
+}
+
 func replaceWhenNilOrEmpty(o interface{}, value interface{}) {
 	if o == nil || o == "" {
 		o = value
@@ -247,12 +312,7 @@ func scaffoldHostedClusterSpec(hyd *hypdeployment.HypershiftDeployment) {
 	}
 
 	if reflect.DeepEqual(hyd.Spec.HostedClusterSpec.Services, []hyp.ServicePublishingStrategyMapping{}) {
-		hyd.Spec.HostedClusterSpec.Services = []hyp.ServicePublishingStrategyMapping{
-			spsMap(hyp.APIServer, hyp.LoadBalancer),
-			spsMap(hyp.OAuthServer, hyp.Route),
-			spsMap(hyp.Konnectivity, hyp.Route),
-			spsMap(hyp.Ignition, hyp.Route),
-		}
+		hyd.Spec.HostedClusterSpec.Services = defaultServices()
 	}
 	if hyd.Spec.HostedClusterSpec.PullSecret.Name == "" {
 		hyd.Spec.HostedClusterSpec.PullSecret.Name = hyd.Name + "-pull-secret"
@@ -275,9 +335,14 @@ func scaffoldHostedClusterSpec(hyd *hypdeployment.HypershiftDeployment) {
 			},
 		}
 	}
-	if hyd.Spec.HostedClusterSpec.Networking.NetworkType == hyp.OVNKubernetes &&
-		strings.Contains(hyd.Spec.HostedClusterSpec.Release.Image, ":4.10.") {
-		hyd.Spec.HostedClusterSpec.Networking.NetworkType = hyp.OpenShiftSDN
+	setNetworkTypeForRelease(hyd.Spec.HostedClusterSpec)
+}
+
+// setNetworkTypeForRelease uses OpenShiftSDN for 4.10 releases, as OVNKubernetes is not supported
+func setNetworkTypeForRelease(spec *hyp.HostedClusterSpec) {
+	if spec.Networking.NetworkType == hyp.OVNKubernetes &&
+		strings.Contains(spec.Release.Image, ":4.10.") {
+		spec.Networking.NetworkType = hyp.OpenShiftSDN
 	}
 }
 
@@ -344,6 +409,68 @@ func ScaffoldAWSNodePoolSpec(hyd *hypdeployment.HypershiftDeployment, infraOut *
 	}
 }
 
+func ScaffoldKubeVirtNodePoolSpec(hyd *hypdeployment.HypershiftDeployment) {
+	ScaffoldNodePoolSpec(hyd, nil)
+	for _, np := range hyd.Spec.NodePools {
+		np.Spec.Platform.Type = hyp.KubevirtPlatform
+		if np.Spec.Platform.Kubevirt == nil {
+			np.Spec.Platform.Kubevirt = scaffoldKubeVirtNodePoolPlatform(hyd.Spec.Infrastructure.Platform.KubeVirt)
+		}
+	}
+}
+
+func ScaffoldAgentNodePoolSpec(hyd *hypdeployment.HypershiftDeployment) {
+	ScaffoldNodePoolSpec(hyd, nil)
+	for _, np := range hyd.Spec.NodePools {
+		np.Spec.Platform.Type = hyp.AgentPlatform
+		if np.Spec.Platform.Agent == nil {
+			np.Spec.Platform.Agent = &hyp.AgentNodePoolPlatform{
+				AgentLabelSelector: hyd.Spec.Infrastructure.Platform.Agent.AgentLabelSelector.DeepCopy(),
+			}
+		}
+	}
+}
+
+func scaffoldKubeVirtNodePoolPlatform(kv *hypdeployment.KubeVirtPlatform) *hyp.KubevirtNodePoolPlatform {
+	memory := resource.MustParse("4Gi")
+	cores := uint32(2)
+	volSize := resource.MustParse("16Gi")
+
+	if kv.Memory != nil {
+		memory = kv.Memory.DeepCopy()
+	}
+	if kv.Cores != nil {
+		cores = *kv.Cores
+	}
+	if kv.RootVolumeSize != nil {
+		volSize = kv.RootVolumeSize.DeepCopy()
+	}
+
+	platform := &hyp.KubevirtNodePoolPlatform{
+		RootVolume: &hyp.KubevirtRootVolume{
+			KubevirtVolume: hyp.KubevirtVolume{
+				Type: hyp.KubevirtVolumeTypePersistent,
+				Persistent: &hyp.KubevirtPersistentVolume{
+					Size: &volSize,
+				},
+			},
+		},
+		Compute: &hyp.KubevirtCompute{
+			Memory: &memory,
+			Cores:  &cores,
+		},
+	}
+	if kv.RootVolumeStorageClass != "" {
+		storageClass := kv.RootVolumeStorageClass
+		platform.RootVolume.Persistent.StorageClass = &storageClass
+	}
+	if kv.ContainerDiskImage != "" {
+		image := kv.ContainerDiskImage
+		platform.RootVolume.Image = &hyp.KubevirtDiskImage{ContainerDiskImage: &image}
+	}
+	return platform
+}
+
 func ScaffoldNodePoolSpec(hyd *hypdeployment.HypershiftDeployment, infraOut *aws.CreateInfraOutput) {
 	if len(hyd.Spec.NodePools) == 0 { // If no nodepool, then we handle zones here. What about nodepools are provided in HD?
 		hyd.Spec.NodePools = []*hypdeployment.HypershiftNodePools{}
@@ -498,6 +625,29 @@ func ScaffoldAzureCloudCredential(hyd *hypdeployment.HypershiftDeployment, creds
 	}
 }
 
+const agentCAPIProviderRoleName = "capi-provider-role"
+
+// ScaffoldAgentCAPIProviderRole lets the cluster-api agent provider of the hosted control plane claim the Agents
+func ScaffoldAgentCAPIProviderRole(agentNamespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agentCAPIProviderRoleName,
+			Namespace: agentNamespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"agent-install.openshift.io"},
+				Resources: []string{"agents"},
+				Verbs:     []string{"*"},
+			},
+		},
+	}
+}
+
 func scaffoldSSHCredential(hyd *hypdeployment.HypershiftDeployment, sshPublicKey, sshPrivateKey []byte) *corev1.Secret {
 	return &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -0,0 +1,222 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+// ExportManifestsKey is the key of the export ConfigMap with the manifests, as a multi-document YAML
+const ExportManifestsKey = "manifests.yaml"
+
+// ExportSecretKey is the key of the export ConfigMap with the name of the export Secret, the Secret manifests are
+// kept out of the ConfigMap. They are stored under ExportManifestsKey of the Secret
+const ExportSecretKey = "secret"
+
+func isDetach(hyd *hypdeployment.HypershiftDeployment) bool {
+	return hyd.Annotations[constant.DetachAnnotation] == "true"
+}
+
+// isDetached is true once the HostedCluster and NodePools were orphaned, the infrastructure is then kept when the
+// HypershiftDeployment is deleted
+func isDetached(hyd *hypdeployment.HypershiftDeployment) bool {
+	return meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.Detached))
+}
+
+func exportConfigMapName(hyd *hypdeployment.HypershiftDeployment) string {
+	return hyd.Name + constant.ExportConfigMapSuffix
+}
+
+func exportSecretName(hyd *hypdeployment.HypershiftDeployment) string {
+	return hyd.Name + constant.ExportSecretSuffix
+}
+
+// reconcileDetach exports the rendered manifests to a ConfigMap, then deletes the manifestwork with the orphan
+// delete option. It returns true while the DetachAnnotation is set, the caller returns without touching the
+// infrastructure or the manifestwork
+func (r *HypershiftDeploymentReconciler) reconcileDetach(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, bool, error) {
+	if !isDetach(hyd) {
+		return ctrl.Result{}, false, nil
+	}
+	if isDetached(hyd) {
+		return ctrl.Result{}, true, nil
+	}
+
+	// The export is taken once, the manifestwork may already be gone when the orphan delete option is waited for
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: hyd.Namespace, Name: exportConfigMapName(hyd)}, cm)
+	if apierrors.IsNotFound(err) {
+		err = r.exportManifests(ctx, hyd, providerSecret)
+	}
+	if err != nil {
+		r.Log.Error(err, "failed to export the manifests")
+		return ctrl.Result{}, true, r.updateStatusConditionsOnChange(hyd, hypdeployment.Detached, metav1.ConditionFalse,
+			"Failed to export the manifests: "+err.Error(), hypdeployment.MisConfiguredReason)
+	}
+
+	waiting, err := r.orphanManifestWork(ctx, hyd)
+	if err != nil {
+		return ctrl.Result{}, true, err
+	}
+	if len(waiting) != 0 {
+		if err := r.updateStatusConditionsOnChange(hyd, hypdeployment.Detached, metav1.ConditionFalse, waiting,
+			hypdeployment.RemovingReason); err != nil {
+			return ctrl.Result{}, true, err
+		}
+		return ctrl.Result{RequeueAfter: migrationRequeueInterval}, true, nil
+	}
+
+	r.recordEvent(hyd, corev1.EventTypeNormal, DetachedEvent, "Exported the manifests to configmap %s and secret %s, the HostedCluster is orphaned on %s",
+		exportConfigMapName(hyd), exportSecretName(hyd), helper.GetHostingClusterName(hyd))
+	return ctrl.Result{}, true, r.updateStatusConditionsOnChange(hyd, hypdeployment.Detached, metav1.ConditionTrue,
+		fmt.Sprintf("The manifests are in configmap %s and the Secret manifests in secret %s, the HostedCluster is no longer managed",
+			exportConfigMapName(hyd), exportSecretName(hyd)),
+		hypdeployment.DetachedReason)
+}
+
+// exportManifests renders the payload of the manifestwork on a copy of the HypershiftDeployment, like the dry run,
+// and stores it in a ConfigMap that is not owned by the HypershiftDeployment, so it is kept once it is deleted.
+// The Secret manifests are stored in a Secret referenced by the ConfigMap, which is written last: the export is
+// complete once the ConfigMap exists
+func (r *HypershiftDeploymentReconciler) exportManifests(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) error {
+	if hyd.Spec.IsInfrastructureOnly() {
+		return fmt.Errorf("there is no HostedCluster to export, the provisioning scope is InfrastructureOnly")
+	}
+	if hyd.Spec.HostedClusterSpec == nil && len(hyd.Spec.HostedClusterRef.Name) == 0 {
+		return fmt.Errorf("there is no HostedCluster to export, the HostedClusterSpec is not set yet")
+	}
+
+	exportHyd := hyd.DeepCopy()
+	if exportHyd.Spec.HostedClusterSpec != nil {
+		defaultHostedClusterSpec(exportHyd.Spec.HostedClusterSpec)
+		if len(exportHyd.Spec.HostedClusterSpec.Release.Image) == 0 && r.ReleaseImageResolver != nil {
+			if err := r.ensureReleaseImage(exportHyd); err != nil {
+				return err
+			}
+		}
+	}
+
+	m, err := scaffoldManifestwork(exportHyd)
+	if err != nil {
+		return err
+	}
+
+	payload, err := r.loadPayload(ctx, exportHyd, m, providerSecret)
+	if err != nil {
+		return err
+	}
+
+	payload, secrets := splitSecrets(payload)
+	manifests, err := manifestsYAML(payload)
+	if err != nil {
+		return err
+	}
+	secretManifests, err := manifestsYAML(secrets)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      exportSecretName(hyd),
+			Namespace: hyd.Namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Data = map[string][]byte{ExportManifestsKey: []byte(secretManifests)}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to store the exported Secret manifests in secret %s, err: %w", secret.Name, err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      exportConfigMapName(hyd),
+			Namespace: hyd.Namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{ExportManifestsKey: manifests, ExportSecretKey: secret.Name}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to store the exported manifests in configmap %s, err: %w", cm.Name, err)
+	}
+	return nil
+}
+
+// clearDetach removes the Detached condition once the annotation is removed, the next reconcile applies the
+// manifestwork again and takes the HostedCluster back. The export ConfigMap is kept
+func (r *HypershiftDeploymentReconciler) clearDetach(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) error {
+	if meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.Detached)) == nil {
+		return nil
+	}
+
+	inHyd := hyd.DeepCopy()
+	meta.RemoveStatusCondition(&hyd.Status.Conditions, string(hypdeployment.Detached))
+	setPhase(hyd)
+	return r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd))
+}
+
+// splitSecrets separates the Secrets from the other manifests of the payload
+func splitSecrets(payload []workv1.Manifest) ([]workv1.Manifest, []workv1.Manifest) {
+	manifests := []workv1.Manifest{}
+	secrets := []workv1.Manifest{}
+	for _, manifest := range payload {
+		if _, ok := manifest.Object.(*corev1.Secret); ok {
+			secrets = append(secrets, manifest)
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, secrets
+}
+
+// manifestsYAML joins the manifests in a multi-document YAML, in the order of the payload
+func manifestsYAML(payload []workv1.Manifest) (string, error) {
+	var out bytes.Buffer
+	for _, manifest := range payload {
+		obj, err := manifestObject(manifest)
+		if err != nil {
+			return "", err
+		}
+
+		b, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString("---\n")
+		out.Write(b)
+	}
+	return out.String(), nil
+}
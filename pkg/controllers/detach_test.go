@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+func TestDetach(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	reconcile := func() *hyd.HypershiftDeployment {
+		_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+		assert.Nil(t, err, "err nil when reconcile was successfull")
+
+		resultHD := &hyd.HypershiftDeployment{}
+		assert.Nil(t, client.Get(ctx, getNN, resultHD))
+		return resultHD
+	}
+
+	resultHD := reconcile()
+	mwKey := getManifestWorkKey(resultHD)
+	mw := &workv1.ManifestWork{}
+	assert.Nil(t, client.Get(ctx, mwKey, mw))
+
+	resultHD.Annotations[constant.DetachAnnotation] = "true"
+	assert.Nil(t, client.Update(ctx, resultHD))
+
+	resultHD = reconcile()
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.Detached))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, hyd.RemovingReason, c.Reason)
+	}
+
+	cmKey := types.NamespacedName{Namespace: testHD.Namespace, Name: testHD.Name + constant.ExportConfigMapSuffix}
+	cm := &corev1.ConfigMap{}
+	assert.Nil(t, client.Get(ctx, cmKey, cm))
+	assert.Empty(t, cm.OwnerReferences, "the configmap is kept once the HypershiftDeployment is deleted")
+	manifests := cm.Data[ExportManifestsKey]
+	assert.Contains(t, manifests, "kind: HostedCluster")
+	assert.Contains(t, manifests, "kind: NodePool")
+	assert.NotContains(t, manifests, "kind: Secret", "the secrets are not stored in a configmap")
+
+	secretKey := types.NamespacedName{Namespace: testHD.Namespace, Name: cm.Data[ExportSecretKey]}
+	assert.Equal(t, testHD.Name+constant.ExportSecretSuffix, secretKey.Name)
+	secret := &corev1.Secret{}
+	assert.Nil(t, client.Get(ctx, secretKey, secret))
+	assert.Empty(t, secret.OwnerReferences, "the secret is kept once the HypershiftDeployment is deleted")
+	secretManifests := string(secret.Data[ExportManifestsKey])
+	assert.Contains(t, secretManifests, "kind: Secret")
+	assert.Contains(t, secretManifests, "name: test1-pull-secret")
+	assert.NotContains(t, secretManifests, RedactedValue, "the secret data is exported")
+
+	assert.Nil(t, client.Get(ctx, mwKey, mw))
+	assert.Equal(t, workv1.DeletePropagationPolicyTypeOrphan, mw.Spec.DeleteOption.PropagationPolicy)
+
+	setWorkApplied(t, client, mwKey)
+	reconcile()
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, mwKey, mw)), "the manifestwork is deleted")
+
+	resultHD = reconcile()
+	assert.True(t, meta.IsStatusConditionTrue(resultHD.Status.Conditions, string(hyd.Detached)))
+	assert.Contains(t, meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.Detached)).Message, secretKey.Name)
+	assert.Equal(t, hyd.PhaseDetached, resultHD.Status.Phase)
+
+	resultHD = reconcile()
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, mwKey, mw)), "the manifestwork is not applied again")
+
+	// The HostedCluster is kept on the hosting cluster, the finalizer is removed right away
+	assert.Nil(t, client.Delete(ctx, resultHD))
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err)
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, getNN, resultHD)))
+	assert.Nil(t, client.Get(ctx, cmKey, cm), "the export is kept")
+	assert.Nil(t, client.Get(ctx, secretKey, secret), "the exported secrets are kept")
+}
+
+func TestClearDetach(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	setStatusCondition(testHD, hyd.Detached, metav1.ConditionTrue, "", hyd.DetachedReason)
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	// Removing the annotation applies the manifestwork, it takes the HostedCluster back
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err, "err nil when reconcile was successfull")
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.Nil(t, meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.Detached)))
+	assert.Nil(t, client.Get(ctx, getManifestWorkKey(resultHD), &workv1.ManifestWork{}))
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+// Keys of the dry run ConfigMap
+const (
+	DryRunPlanKey = "plan"
+	DryRunDiffKey = "diff"
+)
+
+// DryRunInfraID is rendered in the dry run of a HypershiftDeployment without Spec.InfraID, the infra-id is only
+// generated once the dry run annotation is removed
+const DryRunInfraID = "<generated>"
+
+func isDryRun(hyd *hypdeployment.HypershiftDeployment) bool {
+	return hyd.Annotations[constant.DryRunAnnotation] == "true"
+}
+
+func dryRunConfigMapName(hyd *hypdeployment.HypershiftDeployment) string {
+	return hyd.Name + constant.DryRunConfigMapSuffix
+}
+
+// dryRun computes the infrastructure plan and the ManifestWork payload without calling the InfraHandler or
+// applying the ManifestWork, the result is stored in a ConfigMap owned by the HypershiftDeployment
+func (r *HypershiftDeploymentReconciler) dryRun(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, error) {
+	planHyd := hyd
+	if len(hyd.Spec.InfraID) == 0 {
+		planHyd = hyd.DeepCopy()
+		planHyd.Spec.InfraID = DryRunInfraID
+	}
+
+	plan := infraPlan(planHyd)
+
+	diff, changes, err := r.dryRunManifestWorkDiff(ctx, planHyd, providerSecret)
+	if err != nil {
+		r.Log.Error(err, "failed to compute the manifestwork for the dry run")
+		return ctrl.Result{}, r.updateStatusConditionsOnChange(hyd, hypdeployment.DryRun, metav1.ConditionFalse,
+			"Failed to compute the manifestwork: "+err.Error(), hypdeployment.MisConfiguredReason)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dryRunConfigMapName(hyd),
+			Namespace: hyd.Namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{
+			DryRunPlanKey: strings.Join(plan, "\n"),
+			DryRunDiffKey: diff,
+		}
+		return controllerutil.SetControllerReference(hyd, cm, r.Client.Scheme())
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to store the dry run result in configmap %s, err: %w", cm.Name, err)
+	}
+
+	return ctrl.Result{}, r.updateStatusConditionsOnChange(hyd, hypdeployment.DryRun, metav1.ConditionTrue,
+		fmt.Sprintf("%d manifest(s) would change, the plan and the changes are in configmap %s", changes, cm.Name),
+		hypdeployment.DryRunCompletedReason)
+}
+
+// clearDryRun removes the DryRun condition and the ConfigMap once the annotation is removed
+func (r *HypershiftDeploymentReconciler) clearDryRun(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) error {
+	if meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.DryRun)) == nil {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dryRunConfigMapName(hyd),
+			Namespace: hyd.Namespace,
+		},
+	}
+	if err := r.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the dry run configmap %s, err: %w", cm.Name, err)
+	}
+
+	inHyd := hyd.DeepCopy()
+	meta.RemoveStatusCondition(&hyd.Status.Conditions, string(hypdeployment.DryRun))
+	return r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd))
+}
+
+// infraPlan describes the infrastructure and IAM operations the reconciler would run
+func infraPlan(hyd *hypdeployment.HypershiftDeployment) []string {
+	conds := hyd.Status.Conditions
+	infraDone := meta.IsStatusConditionTrue(conds, string(hypdeployment.PlatformConfigured))
+	iamDone := meta.IsStatusConditionTrue(conds, string(hypdeployment.PlatformIAMConfigured))
+
+	plan := []string{}
+	switch {
+	case !hyd.Spec.Infrastructure.Configure:
+		plan = append(plan, "Infrastructure is not configured, Spec.Infrastructure.Configure is false")
+	case hyd.Spec.Infrastructure.Platform == nil:
+		plan = append(plan, "Infrastructure can not be configured, Spec.Infrastructure.Platform is missing")
+	case hyd.Spec.Infrastructure.Platform.AWS != nil:
+		aws := hyd.Spec.Infrastructure.Platform.AWS
+		if !infraDone {
+			plan = append(plan, fmt.Sprintf("Create AWS infrastructure %s in region %s, zones %v", hyd.Spec.InfraID, aws.Region, aws.Zones))
+		}
+		if !iamDone {
+			plan = append(plan, fmt.Sprintf("Create AWS IAM %s", hyd.Spec.InfraID))
+		}
+	case hyd.Spec.Infrastructure.Platform.Azure != nil:
+		if !infraDone || !iamDone {
+			plan = append(plan, fmt.Sprintf("Create Azure infrastructure %s in location %s", hyd.Spec.InfraID,
+				hyd.Spec.Infrastructure.Platform.Azure.Location))
+		}
+	case hyd.Spec.Infrastructure.Platform.KubeVirt != nil:
+		if !infraDone {
+			plan = append(plan, "Scaffold the KubeVirt HostedCluster and NodePools, no cloud infrastructure is created")
+		}
+	case hyd.Spec.Infrastructure.Platform.Agent != nil:
+		if !infraDone {
+			plan = append(plan, "Scaffold the Agent HostedCluster and NodePools, no cloud infrastructure is created")
+		}
+	}
+
+	if hyd.Spec.Infrastructure.Configure && hyd.Spec.Infrastructure.Platform != nil && infraDone && iamDone {
+		plan = append(plan, "Infrastructure and IAM are configured")
+	}
+
+	if hyd.Spec.IsInfrastructureOnly() {
+		plan = append(plan, "Skip the HostedCluster and NodePools, the provisioning scope is InfrastructureOnly")
+	} else {
+		k := getManifestWorkKey(hyd)
+		plan = append(plan, fmt.Sprintf("Apply manifestwork %s to hosting cluster %s", k.Name, k.Namespace))
+	}
+
+	return plan
+}
+
+// dryRunManifestWorkDiff renders the payload on a copy of the HypershiftDeployment, so neither the spec defaults
+// nor Status.CopiedSources are written, and compares it with the payload of the current ManifestWork
+func (r *HypershiftDeploymentReconciler) dryRunManifestWorkDiff(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (string, int, error) {
+	if hyd.Spec.IsInfrastructureOnly() {
+		return "No manifestwork, the provisioning scope is InfrastructureOnly", 0, nil
+	}
+
+	if hyd.Spec.Infrastructure.Configure && hyd.Spec.HostedClusterSpec == nil {
+		return "The manifestwork is rendered once the infrastructure is configured", 0, nil
+	}
+
+	if hyd.Spec.Adopt != nil && hyd.Spec.HostedClusterSpec == nil {
+		return "The manifestwork is rendered once the HostedCluster is adopted", 0, nil
+	}
+
+	desiredHyd := hyd.DeepCopy()
+	if desiredHyd.Spec.HostedClusterSpec != nil {
+		defaultHostedClusterSpec(desiredHyd.Spec.HostedClusterSpec)
+		if len(desiredHyd.Spec.HostedClusterSpec.Release.Image) == 0 && r.ReleaseImageResolver != nil {
+			if err := r.ensureReleaseImage(desiredHyd); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+
+	m, err := scaffoldManifestwork(desiredHyd)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// A HypershiftDeployment without an infra-id has no manifestwork yet
+	current := []workv1.Manifest{}
+	if desiredHyd.Spec.InfraID != DryRunInfraID {
+		if err := r.Get(ctx, getManifestWorkKey(desiredHyd), m); err == nil {
+			current = m.Spec.Workload.Manifests
+		} else if !apierrors.IsNotFound(err) {
+			return "", 0, err
+		}
+	}
+
+	desired, err := r.loadPayload(ctx, desiredHyd, m, providerSecret)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return diffManifests(current, desired)
+}
+
+// diffManifests lists the added (+), removed (-) and changed (~) manifests, with the field changes of the
+// changed ones. The Secret data is replaced with a hash
+func diffManifests(current, desired []workv1.Manifest) (string, int, error) {
+	currentObjs, err := manifestsByID(current)
+	if err != nil {
+		return "", 0, err
+	}
+	desiredObjs, err := manifestsByID(desired)
+	if err != nil {
+		return "", 0, err
+	}
+
+	ids := []string{}
+	for id := range currentObjs {
+		ids = append(ids, id)
+	}
+	for id := range desiredObjs {
+		if _, found := currentObjs[id]; !found {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var sb strings.Builder
+	changes := 0
+	for _, id := range ids {
+		c, inCurrent := currentObjs[id]
+		d, inDesired := desiredObjs[id]
+		switch {
+		case !inCurrent:
+			fmt.Fprintf(&sb, "+ %s\n", id)
+		case !inDesired:
+			fmt.Fprintf(&sb, "- %s\n", id)
+		case !cmp.Equal(c, d):
+			fmt.Fprintf(&sb, "~ %s\n%s\n", id, cmp.Diff(c, d))
+		default:
+			continue
+		}
+		changes++
+	}
+
+	if changes == 0 {
+		return "No changes", 0, nil
+	}
+	return sb.String(), changes, nil
+}
+
+func manifestsByID(manifests []workv1.Manifest) (map[string]map[string]interface{}, error) {
+	out := map[string]map[string]interface{}{}
+	for _, manifest := range manifests {
+		raw := manifest.Raw
+		if len(raw) == 0 {
+			var err error
+			if raw, err = json.Marshal(manifest.Object); err != nil {
+				return nil, err
+			}
+		}
+
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		id := fmt.Sprintf("%v/%v %v/%v", obj["apiVersion"], obj["kind"], metadata["namespace"], metadata["name"])
+
+		if obj["kind"] == "Secret" {
+			for _, field := range []string{"data", "stringData"} {
+				data, _ := obj[field].(map[string]interface{})
+				for k, v := range data {
+					sum := sha256.Sum256([]byte(fmt.Sprint(v)))
+					data[k] = fmt.Sprintf("%s sha256:%x", RedactedValue, sum[:8])
+				}
+			}
+		}
+
+		out[id] = obj
+	}
+
+	return out, nil
+}
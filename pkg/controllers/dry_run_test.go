@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+func TestInfraPlan(t *testing.T) {
+	testHD := getHypershiftDeployment("default", "test1", true)
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.InfraID = "test1-abcde"
+	testHD.Spec.Infrastructure.Platform = &hyd.Platforms{AWS: &hyd.AWSPlatform{Region: "us-east-1"}}
+
+	plan := infraPlan(testHD)
+	assert.Contains(t, plan, "Create AWS infrastructure test1-abcde in region us-east-1, zones []")
+	assert.Contains(t, plan, "Create AWS IAM test1-abcde")
+	assert.Contains(t, plan, "Apply manifestwork test1-abcde to hosting cluster local-cluster")
+
+	setStatusCondition(testHD, hyd.PlatformConfigured, metav1.ConditionTrue, "", hyd.ConfiguredAsExpectedReason)
+	setStatusCondition(testHD, hyd.PlatformIAMConfigured, metav1.ConditionTrue, "", hyd.ConfiguredAsExpectedReason)
+	testHD.Spec.Override = hyd.InfraConfigureOnly
+
+	assert.Equal(t, []string{
+		"Infrastructure and IAM are configured",
+		"Skip the HostedCluster and NodePools, the provisioning scope is InfrastructureOnly",
+	}, infraPlan(testHD))
+}
+
+func TestDiffManifests(t *testing.T) {
+	ns := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: "ns1"},
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Data:       map[string][]byte{"key": []byte("v1")},
+	}
+	cm := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "cm1", Namespace: "ns1"},
+	}
+
+	current := []workv1.Manifest{
+		{RawExtension: runtime.RawExtension{Object: ns}},
+		{RawExtension: runtime.RawExtension{Object: secret}},
+	}
+
+	diff, changes, err := diffManifests(current, current)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+	assert.Equal(t, "No changes", diff)
+
+	newSecret := secret.DeepCopy()
+	newSecret.Data["key"] = []byte("v2")
+	desired := []workv1.Manifest{
+		{RawExtension: runtime.RawExtension{Object: newSecret}},
+		{RawExtension: runtime.RawExtension{Object: cm}},
+	}
+
+	diff, changes, err = diffManifests(current, desired)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, changes)
+	assert.Contains(t, diff, "- v1/Namespace <nil>/ns1")
+	assert.Contains(t, diff, "+ v1/ConfigMap ns1/cm1")
+	assert.Contains(t, diff, "~ v1/Secret ns1/s1")
+	assert.Contains(t, diff, RedactedValue+" sha256:")
+	assert.NotContains(t, diff, "djE=", "the secret data is not in the diff")
+	assert.NotContains(t, diff, "djI=", "the secret data is not in the diff")
+}
+
+func TestDryRunReconcile(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Annotations[constant.DryRunAnnotation] = "true"
+
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err, "err nil when reconcile was successfull")
+
+	mw := &workv1.ManifestWork{}
+	err = client.Get(ctx, getManifestWorkKey(testHD), mw)
+	assert.True(t, apierrors.IsNotFound(err), "the manifestwork is not applied during a dry run")
+
+	cmKey := types.NamespacedName{Namespace: testHD.Namespace, Name: testHD.Name + constant.DryRunConfigMapSuffix}
+	cm := &corev1.ConfigMap{}
+	assert.Nil(t, client.Get(ctx, cmKey, cm))
+	assert.Contains(t, cm.Data[DryRunPlanKey], "Apply manifestwork")
+	assert.Contains(t, cm.Data[DryRunDiffKey], "+ hypershift.openshift.io/v1alpha1/HostedCluster")
+	assert.Len(t, cm.OwnerReferences, 1, "the configmap is owned by the HypershiftDeployment")
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.DryRun))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, metav1.ConditionTrue, c.Status)
+		assert.Equal(t, hyd.DryRunCompletedReason, c.Reason)
+	}
+	assert.Empty(t, resultHD.Finalizers, "the HypershiftDeployment is not changed during a dry run")
+	assert.Equal(t, testHD.Spec.InfraID, resultHD.Spec.InfraID)
+	assert.Empty(t, resultHD.Labels[constant.InfraLabelName])
+
+	// Removing the annotation applies the manifestwork and clears the dry run
+	delete(resultHD.Annotations, constant.DryRunAnnotation)
+	assert.Nil(t, client.Update(ctx, resultHD))
+
+	_, err = hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err, "err nil when reconcile was successfull")
+
+	assert.Nil(t, client.Get(ctx, getManifestWorkKey(testHD), mw))
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, cmKey, cm)), "the dry run configmap is deleted")
+
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.Nil(t, meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.DryRun)))
+	assert.Contains(t, resultHD.Finalizers, constant.DestroyFinalizer)
+}
+
+func TestDryRunReconcileWithoutInfraID(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.InfraID = ""
+	testHD.Annotations[constant.DryRunAnnotation] = "true"
+
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	cmKey := types.NamespacedName{Namespace: testHD.Namespace, Name: testHD.Name + constant.DryRunConfigMapSuffix}
+	plans := []string{}
+	for i := 0; i < 2; i++ {
+		_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+		assert.Nil(t, err, "err nil when reconcile was successfull")
+
+		cm := &corev1.ConfigMap{}
+		assert.Nil(t, client.Get(ctx, cmKey, cm))
+		assert.Contains(t, cm.Data[DryRunPlanKey], "Apply manifestwork "+DryRunInfraID)
+		plans = append(plans, cm.Data[DryRunPlanKey])
+	}
+	assert.Equal(t, plans[0], plans[1], "the plan does not change between reconciles")
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.Empty(t, resultHD.Spec.InfraID, "the infra-id is not generated during a dry run")
+}
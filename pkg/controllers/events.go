@@ -0,0 +1,65 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+// Reasons of the events recorded on a HypershiftDeployment
+const (
+	InfraCreatedEvent            = "InfraCreated"
+	InfraCreateFailedEvent       = "InfraCreateFailed"
+	IAMCreatedEvent              = "IAMCreated"
+	IAMCreateFailedEvent         = "IAMCreateFailed"
+	InfraDestroyedEvent          = "InfraDestroyed"
+	InfraDestroyRetryEvent       = "InfraDestroyRetry"
+	IAMDestroyedEvent            = "IAMDestroyed"
+	IAMDestroyRetryEvent         = "IAMDestroyRetry"
+	InfraOrphanedEvent           = "InfraOrphaned"
+	IAMOrphanedEvent             = "IAMOrphaned"
+	ManifestWorkCreatedEvent     = "ManifestWorkCreated"
+	ManifestWorkUpdatedEvent     = "ManifestWorkUpdated"
+	ManifestWorkFailedEvent      = "ManifestWorkFailed"
+	ManifestWorkDeletedEvent     = "ManifestWorkDeleted"
+	ManifestWorkWaitingEvent     = "ManifestWorkWaiting"
+	InfraDriftDetectedEvent      = "InfraDriftDetected"
+	InfraDriftRepairEvent        = "InfraDriftRepair"
+	InfraRetryStoppedEvent       = "InfraRetryStopped"
+	ReconcilePausedEvent         = "ReconcilePaused"
+	ReconcileResumedEvent        = "ReconcileResumed"
+	HostingClusterSelectedEvent  = "HostingClusterSelected"
+	MigrationStartedEvent        = "MigrationStarted"
+	MigrationCompletedEvent      = "MigrationCompleted"
+	MigrationFailedEvent         = "MigrationFailed"
+	MigrationRolledBackEvent     = "MigrationRolledBack"
+	MigrationSourceOrphanedEvent = "MigrationSourceOrphaned"
+	HostingClusterFullEvent      = "HostingClusterFull"
+	HostedClusterAdoptedEvent    = "HostedClusterAdopted"
+	DetachedEvent                = "Detached"
+)
+
+func (r *HypershiftDeploymentReconciler) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	helper.RecordEvent(r.Recorder, obj, eventType, reason, messageFmt, args...)
+}
+
+func (r *HypershiftDeploymentReconciler) recordWarning(obj runtime.Object, reason string, err error) {
+	r.recordEvent(obj, corev1.EventTypeWarning, reason, "%s", err.Error())
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func assertEvent(t *testing.T, events []string, prefix string) {
+	for _, e := range events {
+		if strings.HasPrefix(e, prefix) {
+			return
+		}
+	}
+	t.Errorf("expected an event starting with %q in %v", prefix, events)
+}
+
+func TestAWSInfraEvents(t *testing.T) {
+	ctx := context.Background()
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Infrastructure.Platform.AWS.Region = "us-east-1"
+
+	r := GetHypershiftDeploymentReconciler()
+	recorder := record.NewFakeRecorder(20)
+	r.Recorder = recorder
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+	assert.Nil(t, r.Client.Create(ctx, getS3Secret("local-cluster")))
+
+	r.InfraHandler = &FakeInfraHandler{}
+	_, err := r.createAWSInfra(testHD, getProviderSecret())
+	assert.Nil(t, err)
+	events := drainEvents(recorder)
+	assertEvent(t, events, "Normal "+InfraCreatedEvent+" Created AWS infrastructure with infra-id: "+testHD.Spec.InfraID)
+	assertEvent(t, events, "Normal "+IAMCreatedEvent)
+
+	r.InfraHandler = &FakeInfraHandlerFailure{}
+	_, err = r.destroyAWSInfrastructure(testHD, getProviderSecret())
+	assert.Nil(t, err)
+	assertEvent(t, drainEvents(recorder), "Warning "+InfraDestroyRetryEvent+" Failed to destroy AWS infrastructure, retrying in 30s")
+
+	r.InfraHandler = &FakeInfraHandler{}
+	_, err = r.destroyAWSInfrastructure(testHD, getProviderSecret())
+	assert.Nil(t, err)
+	events = drainEvents(recorder)
+	assertEvent(t, events, "Normal "+InfraDestroyedEvent)
+	assertEvent(t, events, "Normal "+IAMDestroyedEvent)
+}
+
+func TestRecordEventWithoutRecorder(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	testHD := getHDforManifestWork()
+
+	assert.NotPanics(t, func() {
+		r.recordEvent(testHD, "Normal", InfraCreatedEvent, "no recorder")
+	}, "events are skipped when the reconciler has no Recorder")
+}
+
+func TestManifestWorkWaitingEventOnce(t *testing.T) {
+	ctx := context.Background()
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+
+	recorder := record.NewFakeRecorder(20)
+	r := &HypershiftDeploymentReconciler{
+		Client:   initClient(),
+		Log:      ctrl.Log.WithName("tester"),
+		Recorder: recorder,
+	}
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+	mw, _ := scaffoldManifestwork(testHD)
+	assert.Nil(t, r.Client.Create(ctx, mw))
+
+	for i := 0; i < 3; i++ {
+		_, err := r.deleteManifestworkWaitCleanUp(ctx, testHD)
+		assert.Nil(t, err)
+	}
+
+	waiting := 0
+	for _, e := range drainEvents(recorder) {
+		if strings.HasPrefix(e, "Normal "+ManifestWorkWaitingEvent) {
+			waiting++
+		}
+	}
+	assert.Equal(t, 1, waiting, "the event is recorded when the wait starts, not on every requeue")
+}
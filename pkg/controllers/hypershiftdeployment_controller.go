@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -42,6 +43,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	workv1 "open-cluster-management.io/api/work/v1"
 
 	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
@@ -56,17 +58,34 @@ type HypershiftDeploymentReconciler struct {
 	Scheme        *runtime.Scheme
 	ctx           context.Context
 	Log           logr.Logger
+	Recorder      record.EventRecorder
 
 	InfraHandler            InfraHandler
 	ValidateClusterSecurity bool
+	ReleaseImageResolver    *ReleaseImageResolver
+
+	// InfraVerifyInterval is how often the configured infrastructure is compared with the provider, 0 disables it
+	InfraVerifyInterval time.Duration
+
+	// InfraRetryLimit is the number of failed attempts of an infrastructure operation before it is no longer
+	// retried, 0 retries forever
+	InfraRetryLimit int32
+
+	// DNSSwitcher moves the DNS records of a migrated HostedCluster, the step is skipped when it is nil
+	DNSSwitcher DNSSwitcher
+
+	// CapacityScheduler admits new HostedClusters to a hosting cluster with capacity, nothing is checked when it is nil
+	CapacityScheduler *CapacityScheduler
 }
 
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=hypershiftdeployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=hypershiftdeployments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=hypershiftdeployments/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=create;get;list;patch;update;watch;deletecollection
-//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=create;delete;get;list;update;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=hypershift.openshift.io,resources=hostedclusters;nodepools,verbs=create;delete;get;list;patch;update;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=placementdecisions,verbs=get;list;watch
 //+kubebuilder:rbac:groups=work.open-cluster-management.io,resources=manifestworks,verbs=create;delete;get;list;patch;update;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -92,10 +111,13 @@ func (r *HypershiftDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{}, nil
 	}
 
-	if r.migrateAWSRoles(&hyd) {
-		if err := r.patchHypershiftDeploymentResource(&hyd); err != nil {
-			return ctrl.Result{}, fmt.Errorf("failed to migrate AWS roles to rolesRef error: %w", err)
-		}
+	// Leave the infrastructure and the manifestwork untouched during a maintenance, including a deletion
+	if hyd.Spec.Paused {
+		log.Info("Reconciliation is paused")
+		return ctrl.Result{}, r.pauseReconcile(&hyd)
+	}
+	if err := r.resumeReconcile(&hyd); err != nil {
+		return ctrl.Result{}, err
 	}
 
 	var providerSecret corev1.Secret
@@ -135,8 +157,14 @@ func (r *HypershiftDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 		}
 	}
 
+	// Preview the infrastructure and the manifestwork without applying them. The HypershiftDeployment is not
+	// changed, the finalizer, infra-id and label are only written once the dry run annotation is removed
+	if hyd.DeletionTimestamp == nil && isDryRun(&hyd) {
+		return r.dryRun(ctx, &hyd, &providerSecret)
+	}
+
 	if hyd.Spec.InfraID == "" {
-		hyd.Spec.InfraID = fmt.Sprintf("%s-%s", hyd.GetName(), utilrand.String(5))
+		hyd.Spec.InfraID = generateInfraID(hyd.GetName())
 		log.Info("Using INFRA-ID: " + hyd.Spec.InfraID)
 	}
 
@@ -157,14 +185,61 @@ func (r *HypershiftDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 
 	// Destroying Platform infrastructure used by the HypershiftDeployment scheduled for deletion
 	if hyd.DeletionTimestamp != nil {
+		// A migration is rolled back before the HostedCluster is removed from the source hosting cluster
+		if res, migrating, err := r.reconcileMigration(ctx, &hyd); err != nil || migrating {
+			return res, err
+		}
 		return r.destroyHypershift(&hyd, &providerSecret)
 	}
 
+	if err := r.clearDryRun(ctx, &hyd); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// A detached HostedCluster is orphaned on the hosting cluster and left to be managed from the exported manifests
+	if res, detached, err := r.reconcileDetach(ctx, &hyd, &providerSecret); err != nil || detached {
+		return res, err
+	}
+	if err := r.clearDetach(ctx, &hyd); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The PlacementDecisions are watched, wait for one when no hosting cluster is selected
+	if selected, err := r.selectHostingCluster(ctx, &hyd); err != nil || !selected {
+		return ctrl.Result{}, err
+	}
+
+	// A change of Spec.HostingCluster moves the HostedCluster, the manifestwork is left untouched until it completes
+	if res, migrating, err := r.reconcileMigration(ctx, &hyd); err != nil || migrating {
+		return res, err
+	}
+
+	// An existing HostedCluster is read back from the hosting cluster before the manifestwork takes it over
+	if res, adopting, err := r.reconcileAdoption(ctx, &hyd); err != nil || adopting {
+		return res, err
+	}
+
+	// The capacity of the hosting cluster is checked before the infrastructure is created for a HostedCluster that
+	// may have to wait for it
+	if !hyd.Spec.IsInfrastructureOnly() && len(helper.GetHostingClusterName(&hyd)) != 0 {
+		if res, admitted, err := r.admitHostedCluster(ctx, &hyd); err != nil || !admitted {
+			return res, err
+		}
+	}
+
 	if configureInfra {
 		if hyd.Spec.Infrastructure.Platform == nil {
 			return ctrl.Result{}, r.updateMissingInfrastructureParameterCondition(&hyd, "Missing value HypershiftDeployment.Spec.Infrastructure.Platform")
 		}
 
+		if err := r.verifyInfrastructure(&hyd, &providerSecret); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if res, wait, err := r.waitForInfraRetry(&hyd, &providerSecret); err != nil || wait {
+			return res, err
+		}
+
 		if hyd.Spec.Infrastructure.Platform.AWS != nil {
 			if requeue, err := r.createAWSInfra(&hyd, &providerSecret); err != nil || requeue.Requeue {
 				return requeue, err
@@ -175,6 +250,16 @@ func (r *HypershiftDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 				return requeue, err
 			}
 		}
+		if hyd.Spec.Infrastructure.Platform.KubeVirt != nil {
+			if requeue, err := r.createKubeVirtInfra(&hyd, &providerSecret); err != nil || requeue.Requeue {
+				return requeue, err
+			}
+		}
+		if hyd.Spec.Infrastructure.Platform.Agent != nil {
+			if requeue, err := r.createAgentInfra(&hyd, &providerSecret); err != nil || requeue.Requeue {
+				return requeue, err
+			}
+		}
 	} else {
 		_ = r.updateStatusConditionsOnChange(&hyd, hypdeployment.PlatformIAMConfigured, metav1.ConditionFalse, "Platform IAM configuration is not applicable for Spec.Infrastructure.Configure=False", hypdeployment.NotApplicableReason)
 
@@ -182,9 +267,9 @@ func (r *HypershiftDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 	}
 
 	// Just build the infrastruction platform, do not deploy HostedCluster and NodePool(s)
-	if hyd.Spec.Override == hypdeployment.InfraConfigureOnly {
+	if hyd.Spec.IsInfrastructureOnly() {
 		log.Info("Completed Infrastructure confiugration, skipping HostedCluster and NodePool(s)")
-		return ctrl.Result{}, nil
+		return r.requeueForInfraVerification(&hyd, ctrl.Result{}), nil
 	}
 
 	// Apply the HostedCluster if Infrastructure is AsExpected or configureInfra: false (user brings their own)
@@ -202,42 +287,13 @@ func (r *HypershiftDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 
 		// In Azure, the providerSecret is needed for Configure true or false
 		log.Info("Wrap hostedCluster, nodepool and secrets to manifestwork")
-		return r.createOrUpdateMainfestwork(ctx, req, hyd.DeepCopy(), &providerSecret)
-	}
-	return ctrl.Result{}, nil
-}
-
-func (r *HypershiftDeploymentReconciler) migrateAWSRoles(hyd *hypdeployment.HypershiftDeployment) bool {
-	log := r.Log
-
-	migrated := false
-
-	if hyd.Spec.HostedClusterSpec != nil && hyd.Spec.HostedClusterSpec.Platform.AWS != nil && hyd.Spec.HostedClusterSpec.Platform.AWS.Roles != nil {
-		for _, role := range hyd.Spec.HostedClusterSpec.Platform.AWS.Roles {
-			switch role.Namespace {
-			case "openshift-image-registry":
-				log.Info("migrating openshift-image-registry ARN")
-				hyd.Spec.HostedClusterSpec.Platform.AWS.RolesRef.ImageRegistryARN = role.ARN
-			case "openshift-ingress-operator":
-				log.Info("migrating openshift-ingress-operator ARN")
-				hyd.Spec.HostedClusterSpec.Platform.AWS.RolesRef.IngressARN = role.ARN
-			case "openshift-cloud-network-config-controller":
-				log.Info("migrating openshift-cloud-network-config-controller ARN")
-				hyd.Spec.HostedClusterSpec.Platform.AWS.RolesRef.NetworkARN = role.ARN
-			case "openshift-cluster-csi-drivers":
-				log.Info("migrating openshift-cluster-csi-drivers ARN")
-				hyd.Spec.HostedClusterSpec.Platform.AWS.RolesRef.StorageARN = role.ARN
-			default:
-				log.Info(fmt.Sprintf("Invalid namespace for deprecated role: %q", role.Namespace))
-			}
+		res, err := r.createOrUpdateMainfestwork(ctx, req, hyd.DeepCopy(), &providerSecret)
+		if err != nil {
+			return res, err
 		}
-
-		hyd.Spec.HostedClusterSpec.Platform.AWS.Roles = nil
-
-		migrated = true
+		return r.requeueForInfraVerification(&hyd, res), nil
 	}
-
-	return migrated
+	return ctrl.Result{}, nil
 }
 
 func (r *HypershiftDeploymentReconciler) setDefaultValueForHostedCluster(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) error {
@@ -245,22 +301,30 @@ func (r *HypershiftDeploymentReconciler) setDefaultValueForHostedCluster(ctx con
 		return nil
 	}
 
-	log := r.Log
+	changed := defaultHostedClusterSpec(hyd.Spec.HostedClusterSpec)
 
-	spec := hyd.Spec.HostedClusterSpec
-	needsUpdate := false
-	if spec.ClusterID == "" {
-		hyd.Spec.HostedClusterSpec.ClusterID = uuid.NewString()
-		needsUpdate = true
-		log.Info("Setting clusterID", "clusterID", hyd.Spec.HostedClusterSpec.ClusterID)
+	// The rolesRef of an existing HostedCluster is immutable, only a new HostedCluster is created with it
+	if hyd.Spec.Adopt == nil && meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.HostedClusterAvailable)) == nil {
+		err := r.Get(ctx, getManifestWorkKey(hyd), &workv1.ManifestWork{})
+		switch {
+		case apierrors.IsNotFound(err):
+			changed = r.migrateAWSRoles(hyd) || changed
+		case err != nil:
+			return err
+		}
 	}
 
-	if spec.OLMCatalogPlacement == "" {
-		hyd.Spec.HostedClusterSpec.OLMCatalogPlacement = hyp.ManagementOLMCatalogPlacement
-		needsUpdate = true
-		log.Info("Setting OLMCatalogPlacement", "OLMCatalogPlacement", hyd.Spec.HostedClusterSpec.OLMCatalogPlacement)
+	if len(hyd.Spec.HostedClusterSpec.Release.Image) == 0 {
+		if err := r.ensureReleaseImage(hyd); err != nil {
+			return fmt.Errorf("failed to resolve the release image, error: %w", err)
+		}
+		changed = true
 	}
-	if needsUpdate {
+
+	if changed {
+		r.Log.Info("Setting HostedClusterSpec defaults", "clusterID", hyd.Spec.HostedClusterSpec.ClusterID,
+			"OLMCatalogPlacement", hyd.Spec.HostedClusterSpec.OLMCatalogPlacement,
+			"releaseImage", hyd.Spec.HostedClusterSpec.Release.Image)
 		if err := r.patchHypershiftDeploymentResource(hyd); err != nil {
 			return fmt.Errorf("failed to update infra-id: \"%s\" and  olm-catalog-placement: \"%s\",error: %w",
 				hyd.Spec.HostedClusterSpec.ClusterID, hyd.Spec.HostedClusterSpec.OLMCatalogPlacement, err)
@@ -269,6 +333,73 @@ func (r *HypershiftDeploymentReconciler) setDefaultValueForHostedCluster(ctx con
 	return nil
 }
 
+// migrateAWSRoles moves the deprecated AWS roles, by the namespace of the role, and the deprecated credentials to
+// the rolesRef, a role already set in the rolesRef is kept. Returns true if the spec was changed
+func (r *HypershiftDeploymentReconciler) migrateAWSRoles(hyd *hypdeployment.HypershiftDeployment) bool {
+	if hyd.Spec.HostedClusterSpec.Platform.AWS == nil {
+		return false
+	}
+
+	aws := hyd.Spec.HostedClusterSpec.Platform.AWS
+	rolesRef := aws.RolesRef
+	for _, role := range aws.Roles {
+		switch role.Namespace {
+		case "openshift-image-registry":
+			rolesRef.ImageRegistryARN = role.ARN
+		case "openshift-ingress-operator":
+			rolesRef.IngressARN = role.ARN
+		case "openshift-cloud-network-config-controller":
+			rolesRef.NetworkARN = role.ARN
+		case "openshift-cluster-csi-drivers":
+			rolesRef.StorageARN = role.ARN
+		default:
+			r.Log.Info(fmt.Sprintf("Invalid namespace for deprecated role: %q", role.Namespace))
+		}
+	}
+
+	if hyd.Spec.Credentials != nil && hyd.Spec.Credentials.AWS != nil {
+		creds := hyd.Spec.Credentials.AWS
+		if len(rolesRef.ControlPlaneOperatorARN) == 0 {
+			rolesRef.ControlPlaneOperatorARN = creds.ControlPlaneOperatorARN
+		}
+		if len(rolesRef.KubeCloudControllerARN) == 0 {
+			rolesRef.KubeCloudControllerARN = creds.KubeCloudControllerARN
+		}
+		if len(rolesRef.NodePoolManagementARN) == 0 {
+			rolesRef.NodePoolManagementARN = creds.NodePoolManagementARN
+		}
+	}
+
+	if aws.Roles == nil && rolesRef == aws.RolesRef {
+		return false
+	}
+	r.Log.Info("Migrating the deprecated AWS roles and credentials to the rolesRef")
+	aws.RolesRef = rolesRef
+	aws.Roles = nil
+	return true
+}
+
+func generateInfraID(name string) string {
+	return fmt.Sprintf("%s-%s", name, utilrand.String(5))
+}
+
+// defaultHostedClusterSpec sets the values the hypershift operator would otherwise default on the hosting
+// cluster, returns true if the spec was changed
+func defaultHostedClusterSpec(spec *hyp.HostedClusterSpec) bool {
+	changed := false
+	if spec.ClusterID == "" {
+		spec.ClusterID = uuid.NewString()
+		changed = true
+	}
+
+	if spec.OLMCatalogPlacement == "" {
+		spec.OLMCatalogPlacement = hyp.ManagementOLMCatalogPlacement
+		changed = true
+	}
+
+	return changed
+}
+
 func (r *HypershiftDeploymentReconciler) scaffoldPullSecret(hyd *hypdeployment.HypershiftDeployment, providerSecret corev1.Secret) *corev1.Secret {
 	return &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -300,6 +431,7 @@ func setStatusCondition(hyd *hypdeployment.HypershiftDeployment, conditionType h
 		Reason:             reason,
 	}
 	meta.SetStatusCondition(&hyd.Status.Conditions, condition)
+	setPhase(hyd)
 	return condition
 }
 
@@ -383,7 +515,17 @@ func (r *HypershiftDeploymentReconciler) destroyHypershift(hyd *hypdeployment.Hy
 		return ctrl.Result{}, nil
 	}
 
-	if hyd.Spec.Override != hypdeployment.InfraConfigureOnly {
+	// A HostedCluster that is not adopted yet is left on the hosting cluster
+	if hyd.Spec.Adopt != nil {
+		if err := r.deleteAdoptManifestWork(ctx, hyd); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The manifestwork of a detached HostedCluster is already removed, the HostedCluster and its infrastructure are kept
+	detached := isDetached(hyd)
+
+	if !hyd.Spec.IsInfrastructureOnly() && !detached {
 		log.Info("Removing Manifestwork and wait for hostedcluster and nodepool to be cleaned up.")
 		res, err := r.deleteManifestworkWaitCleanUp(ctx, hyd)
 
@@ -401,16 +543,22 @@ func (r *HypershiftDeploymentReconciler) destroyHypershift(hyd *hypdeployment.Hy
 		}
 	}
 
-	if hyd.Spec.Override != hypdeployment.InfraOverrideDestroy &&
-		hyd.Spec.Infrastructure.Configure {
+	policy := hyd.Spec.GetDeletionPolicy()
+	if hyd.Spec.Infrastructure.Configure && !detached &&
+		(policy.Infrastructure == hypdeployment.DeletionPolicyDelete || policy.IAM == hypdeployment.DeletionPolicyDelete) {
 		// Infrastructure is the last step
+		if res, wait, err := r.waitForInfraRetry(hyd, providerSecret); err != nil || wait {
+			return res, err
+		}
+		// The finalizer is kept when the destroy is no longer retried, the resources would be leaked
 		if hyd.Spec.Infrastructure.Platform.AWS != nil {
-			if result, err := r.destroyAWSInfrastructure(hyd, providerSecret); err != nil || result.Requeue {
+			if result, err := r.destroyAWSInfrastructure(hyd, providerSecret); err != nil || result.Requeue || infraFailed(hyd) {
 				return result, nil // destroyAWSInfrastructure uses requeue times, switch to nil
 			}
 		}
-		if hyd.Spec.Infrastructure.Platform.Azure != nil {
-			if result, err := r.destroyAzureInfrastructure(hyd, providerSecret); err != nil || result.Requeue {
+		// Azure has no IAM to destroy
+		if hyd.Spec.Infrastructure.Platform.Azure != nil && policy.Infrastructure == hypdeployment.DeletionPolicyDelete {
+			if result, err := r.destroyAzureInfrastructure(hyd, providerSecret); err != nil || result.Requeue || infraFailed(hyd) {
 				return result, nil // destroyAzureInfrastructure uses requeue times, switch to nil
 			}
 		}
@@ -430,6 +578,10 @@ func (r *HypershiftDeploymentReconciler) destroyHypershift(hyd *hypdeployment.Hy
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *HypershiftDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &hypdeployment.HypershiftDeployment{}, sourceRefIndex, indexReferencedSources); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&hypdeployment.HypershiftDeployment{}).
 		Watches(&source.Kind{Type: &workv1.ManifestWork{}},
@@ -453,6 +605,9 @@ func (r *HypershiftDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) erro
 
 				return []reconcile.Request{req}
 			})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.enqueueForSource(sourceKindSecret))).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.enqueueForSource(sourceKindConfigMap))).
+		Watches(&source.Kind{Type: &clusterv1beta1.PlacementDecision{}}, handler.EnqueueRequestsFromMapFunc(r.enqueueForPlacementDecision)).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
 		Complete(r)
 }
@@ -412,43 +412,55 @@ func TestHypershiftdeployment_controller(t *testing.T) {
 func TestHypershiftdeployment_roles_migration(t *testing.T) {
 
 	client := initClient()
-
-	namespacedName := types.NamespacedName{
-		Namespace: "default",
-		Name:      "test-migrate",
-	}
-
 	ctx := context.Background()
-	infraOut := getAWSInfrastructureOut()
-	testHD := getHypershiftDeployment("default", "test-migrate", false)
-	testHD.Spec.Infrastructure.Platform = &hyd.Platforms{AWS: &hyd.AWSPlatform{}}
-	testHD.Spec.Credentials = &hyd.CredentialARNs{AWS: &hyd.AWSCredentials{}}
-	ScaffoldAWSHostedClusterSpec(testHD, infraOut)
-	ScaffoldAWSNodePoolSpec(testHD, infraOut)
-
-	testHD.Spec.HostedClusterSpec.Platform.AWS.Roles = []hyp.AWSRoleCredentials{}
-	testHD.Spec.HostedClusterSpec.Platform.AWS.Roles = append(testHD.Spec.HostedClusterSpec.Platform.AWS.Roles, hyp.AWSRoleCredentials{ARN: "arn:aws:iam::123456789123:role/test-openshift-ingress", Namespace: "openshift-ingress-operator", Name: "cloud-credentials"})
-	testHD.Spec.HostedClusterSpec.Platform.AWS.Roles = append(testHD.Spec.HostedClusterSpec.Platform.AWS.Roles, hyp.AWSRoleCredentials{ARN: "arn:aws:iam::123456789123:role/test-openshift-image-registry", Namespace: "openshift-image-registry", Name: "installer-cloud-credentials"})
-	testHD.Spec.HostedClusterSpec.Platform.AWS.Roles = append(testHD.Spec.HostedClusterSpec.Platform.AWS.Roles, hyp.AWSRoleCredentials{ARN: "arn:aws:iam::123456789123:role/test-aws-ebs-csi-driver-controller", Namespace: "openshift-cluster-csi-drivers", Name: "ebs-cloud-credentials"})
-	testHD.Spec.HostedClusterSpec.Platform.AWS.Roles = append(testHD.Spec.HostedClusterSpec.Platform.AWS.Roles, hyp.AWSRoleCredentials{ARN: "arn:aws:iam::123456789123:role/test-cloud-network-config-controller", Namespace: "openshift-cloud-network-config-controller", Name: "cloud-credentials"})
 
-	client.Create(ctx, testHD)
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Credentials.AWS = &hyd.AWSCredentials{
+		ControlPlaneOperatorARN: "arn:aws:iam::123456789123:role/cpo",
+		KubeCloudControllerARN:  "arn:aws:iam::123456789123:role/kcc",
+		NodePoolManagementARN:   "arn:aws:iam::123456789123:role/npm",
+	}
+	roles := []hyp.AWSRoleCredentials{
+		{ARN: "arn:aws:iam::123456789123:role/test-openshift-ingress", Namespace: "openshift-ingress-operator", Name: "cloud-credentials"},
+		{ARN: "arn:aws:iam::123456789123:role/test-openshift-image-registry", Namespace: "openshift-image-registry", Name: "installer-cloud-credentials"},
+		{ARN: "arn:aws:iam::123456789123:role/test-aws-ebs-csi-driver-controller", Namespace: "openshift-cluster-csi-drivers", Name: "ebs-cloud-credentials"},
+		{ARN: "arn:aws:iam::123456789123:role/test-cloud-network-config-controller", Namespace: "openshift-cloud-network-config-controller", Name: "cloud-credentials"},
+	}
+	testHD.Spec.HostedClusterSpec.Platform.AWS.Roles = roles
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
 
 	hdr := &HypershiftDeploymentReconciler{
 		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
 	}
-	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
 	assert.Nil(t, err, "err nil when reconcile was successfull")
 
 	var updated hyd.HypershiftDeployment
-	err = client.Get(ctx, namespacedName, &updated)
-	assert.Nil(t, err, "is nil when HypershiftDeployment resource is found")
+	assert.Nil(t, client.Get(ctx, getNN, &updated))
+	aws := updated.Spec.HostedClusterSpec.Platform.AWS
+	assert.Nil(t, aws.Roles, "roles should be nil")
+	assert.Equal(t, "arn:aws:iam::123456789123:role/test-openshift-image-registry", aws.RolesRef.ImageRegistryARN)
+	assert.Equal(t, "arn:aws:iam::123456789123:role/test-openshift-ingress", aws.RolesRef.IngressARN)
+	assert.Equal(t, "arn:aws:iam::123456789123:role/test-aws-ebs-csi-driver-controller", aws.RolesRef.StorageARN)
+	assert.Equal(t, "arn:aws:iam::123456789123:role/test-cloud-network-config-controller", aws.RolesRef.NetworkARN)
+	assert.Equal(t, "arn:aws:iam::123456789123:role/cpo", aws.RolesRef.ControlPlaneOperatorARN)
+	assert.Equal(t, "arn:aws:iam::123456789123:role/kcc", aws.RolesRef.KubeCloudControllerARN)
+	assert.Equal(t, "arn:aws:iam::123456789123:role/npm", aws.RolesRef.NodePoolManagementARN)
+	assert.NotNil(t, updated.Spec.Credentials, "the credentials still render the creds secrets")
+
+	// The rolesRef of an existing HostedCluster is not changed
+	updated.Spec.HostedClusterSpec.Platform.AWS.Roles = roles
+	updated.Spec.HostedClusterSpec.Platform.AWS.RolesRef = hyp.AWSRolesRef{}
+	assert.Nil(t, client.Update(ctx, &updated))
+	_, err = hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err, "err nil when reconcile was successfull")
 
-	assert.Nil(t, updated.Spec.HostedClusterSpec.Platform.AWS.Roles, "roles should be nil")
-	assert.Equal(t, updated.Spec.HostedClusterSpec.Platform.AWS.RolesRef.ImageRegistryARN, "arn:aws:iam::123456789123:role/test-openshift-image-registry")
-	assert.Equal(t, updated.Spec.HostedClusterSpec.Platform.AWS.RolesRef.IngressARN, "arn:aws:iam::123456789123:role/test-openshift-ingress")
-	assert.Equal(t, updated.Spec.HostedClusterSpec.Platform.AWS.RolesRef.StorageARN, "arn:aws:iam::123456789123:role/test-aws-ebs-csi-driver-controller")
-	assert.Equal(t, updated.Spec.HostedClusterSpec.Platform.AWS.RolesRef.NetworkARN, "arn:aws:iam::123456789123:role/test-cloud-network-config-controller")
+	assert.Nil(t, client.Get(ctx, getNN, &updated))
+	assert.Equal(t, roles, updated.Spec.HostedClusterSpec.Platform.AWS.Roles)
+	assert.Equal(t, hyp.AWSRolesRef{}, updated.Spec.HostedClusterSpec.Platform.AWS.RolesRef)
 }
 
 func TestHypershiftdeployment_controllerWithObjectRef(t *testing.T) {
@@ -0,0 +1,373 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+//+kubebuilder:webhook:path=/mutate-cluster-open-cluster-management-io-v1alpha1-hypershiftdeployment,mutating=true,failurePolicy=fail,sideEffects=None,groups=cluster.open-cluster-management.io,resources=hypershiftdeployments,verbs=create;update,versions=v1alpha1,name=mhypershiftdeployment.open-cluster-management.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-cluster-open-cluster-management-io-v1alpha1-hypershiftdeployment,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.open-cluster-management.io,resources=hypershiftdeployments,verbs=create;update,versions=v1alpha1,name=vhypershiftdeployment.open-cluster-management.io,admissionReviewVersions=v1
+
+// HypershiftDeploymentWebhook defaults and validates HypershiftDeployments at admission time, so spec
+// problems are rejected instead of being reported later as a MisConfigured condition
+type HypershiftDeploymentWebhook struct {
+	Log logr.Logger
+
+	// Client reads the cloud provider secret, its credential source is not validated when it is nil
+	Client client.Reader
+}
+
+var _ admission.CustomDefaulter = &HypershiftDeploymentWebhook{}
+var _ admission.CustomValidator = &HypershiftDeploymentWebhook{}
+
+// SetupWebhookWithManager registers the defaulting, validating and conversion webhooks with the manager's webhook
+// server. The v1alpha1 webhooks also receive the v1beta1 requests, converted to v1alpha1
+func (w *HypershiftDeploymentWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&hypdeployment.HypershiftDeployment{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default performs the defaulting otherwise done by the reconciler before the manifestwork is rendered
+func (w *HypershiftDeploymentWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	hyd, ok := obj.(*hypdeployment.HypershiftDeployment)
+	if !ok {
+		return fmt.Errorf("expected a HypershiftDeployment but got a %T", obj)
+	}
+
+	if hyd.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if hyd.Spec.InfraID == "" && hyd.Name != "" {
+		hyd.Spec.InfraID = generateInfraID(hyd.Name)
+		w.Log.V(1).Info("Defaulting INFRA-ID", "infraID", hyd.Spec.InfraID)
+	}
+
+	if len(hyd.Spec.InfraID) != 0 {
+		if hyd.Labels == nil {
+			hyd.Labels = map[string]string{}
+		}
+		if _, found := hyd.Labels[constant.InfraLabelName]; !found {
+			hyd.Labels[constant.InfraLabelName] = hyd.Spec.InfraID
+		}
+	}
+
+	// With configure=true and no HostedClusterSpec, the spec is scaffolded from the infrastructure output
+	if hyd.Spec.HostedClusterSpec == nil {
+		return nil
+	}
+
+	scaffoldHostedClusterSpec(hyd)
+	defaultHostedClusterSpec(hyd.Spec.HostedClusterSpec)
+
+	for _, np := range hyd.Spec.NodePools {
+		if np == nil {
+			continue
+		}
+		if np.Spec.ClusterName == "" {
+			np.Spec.ClusterName = hyd.Name
+		}
+		if np.Spec.Platform.Type == "" {
+			np.Spec.Platform.Type = hyd.Spec.HostedClusterSpec.Platform.Type
+		}
+		if np.Spec.Release.Image == "" {
+			np.Spec.Release.Image = hyd.Spec.HostedClusterSpec.Release.Image
+		}
+	}
+
+	return nil
+}
+
+// ValidateCreate rejects HypershiftDeployments the reconciler would flag as MisConfigured
+func (w *HypershiftDeploymentWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	hyd, ok := obj.(*hypdeployment.HypershiftDeployment)
+	if !ok {
+		return fmt.Errorf("expected a HypershiftDeployment but got a %T", obj)
+	}
+
+	allErrs := validateHypershiftDeployment(hyd)
+	allErrs = append(allErrs, validateHypershiftDeploymentCreate(hyd)...)
+	allErrs = append(allErrs, w.validateCredentialSource(ctx, hyd)...)
+
+	return toInvalidError(hyd, allErrs)
+}
+
+// ValidateUpdate also enforces the immutable fields of the spec
+func (w *HypershiftDeploymentWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldHyd, ok := oldObj.(*hypdeployment.HypershiftDeployment)
+	if !ok {
+		return fmt.Errorf("expected a HypershiftDeployment but got a %T", oldObj)
+	}
+	hyd, ok := newObj.(*hypdeployment.HypershiftDeployment)
+	if !ok {
+		return fmt.Errorf("expected a HypershiftDeployment but got a %T", newObj)
+	}
+
+	// Never block the finalizers from being removed
+	if hyd.DeletionTimestamp != nil {
+		return nil
+	}
+
+	allErrs := validateHypershiftDeployment(hyd)
+	allErrs = append(allErrs, validateHypershiftDeploymentUpdate(hyd, oldHyd)...)
+
+	return toInvalidError(hyd, allErrs)
+}
+
+// ValidateDelete is a no-op, deletion is handled by the finalizers
+func (w *HypershiftDeploymentWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func toInvalidError(hyd *hypdeployment.HypershiftDeployment, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(hypdeployment.GroupVersion.WithKind("HypershiftDeployment").GroupKind(), hyd.Name, allErrs)
+}
+
+func validateHypershiftDeployment(hyd *hypdeployment.HypershiftDeployment) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+	infraPath := specPath.Child("infrastructure")
+
+	infraOnly := hyd.Spec.IsInfrastructureOnly()
+
+	placement := hyd.Spec.HostingClusterPlacement
+	switch {
+	case len(hyd.Spec.HostingCluster) != 0 && placement != nil:
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("hostingClusterPlacement"),
+			"hostingClusterPlacement can not be used with hostingCluster"))
+	case placement != nil && len(placement.Name) == 0:
+		allErrs = append(allErrs, field.Required(specPath.Child("hostingClusterPlacement", "name"), ""))
+	case len(hyd.Spec.HostingCluster) == 0 && placement == nil && !infraOnly:
+		allErrs = append(allErrs, field.Required(specPath.Child("hostingCluster"), constant.HostingClusterMissing))
+	}
+
+	configureInfra := hyd.Spec.Infrastructure.Configure
+	if configureInfra ||
+		(hyd.Spec.HostedClusterSpec != nil && hyd.Spec.HostedClusterSpec.Platform.Azure != nil) {
+		if len(hyd.Spec.Infrastructure.CloudProvider.Name) == 0 {
+			allErrs = append(allErrs, field.Required(infraPath.Child("cloudProvider", "name"),
+				"spec.infrastructure.cloudProvider is required for configuring infrastructure"))
+		}
+	}
+
+	if configureInfra {
+		platform := hyd.Spec.Infrastructure.Platform
+		platformPath := infraPath.Child("platform")
+		platforms := configuredPlatforms(platform)
+		switch {
+		case len(platforms) == 0:
+			allErrs = append(allErrs, field.Required(platformPath, "a platform is required when configure is true"))
+		case len(platforms) > 1:
+			allErrs = append(allErrs, field.Invalid(platformPath, strings.Join(platforms, ", "), "only one platform can be configured"))
+		case platform.AWS != nil && len(platform.AWS.Region) == 0:
+			allErrs = append(allErrs, field.Required(platformPath.Child("aws", "region"), ""))
+		case platform.Azure != nil && len(platform.Azure.Location) == 0:
+			allErrs = append(allErrs, field.Required(platformPath.Child("azure", "location"), ""))
+		case platform.Agent != nil:
+			if len(platform.Agent.AgentNamespace) == 0 {
+				allErrs = append(allErrs, field.Required(platformPath.Child("agent", "agentNamespace"), ""))
+			}
+			if len(platform.Agent.APIServerAddress) == 0 {
+				allErrs = append(allErrs, field.Required(platformPath.Child("agent", "apiServerAddress"), ""))
+			}
+		}
+	} else if !infraOnly && hyd.Spec.HostedClusterSpec == nil && len(hyd.Spec.HostedClusterRef.Name) == 0 && hyd.Spec.Adopt == nil {
+		allErrs = append(allErrs, field.Required(specPath.Child("hostedClusterSpec"),
+			"HostedClusterSpec or HostedClusterRef is required"))
+	}
+
+	if hyd.Spec.HostedClusterSpec != nil {
+		for i, np := range hyd.Spec.NodePools {
+			npPath := specPath.Child("nodePools").Index(i)
+			if np == nil {
+				allErrs = append(allErrs, field.Required(npPath, ""))
+				continue
+			}
+
+			if err := checkHostedClusterAndNodePool(hyd.Name, *hyd.Spec.HostedClusterSpec, np.Spec); err != nil {
+				allErrs = append(allErrs, field.Invalid(npPath.Child("spec"), np.Name, err.Error()))
+			}
+		}
+	}
+
+	for i, np := range hyd.Spec.NodePools {
+		if np == nil || np.Policy == nil {
+			continue
+		}
+		if msg := validateNodePoolPolicy(np.Policy); len(msg) != 0 {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("nodePools").Index(i).Child("policy"), np.Name, msg))
+		}
+	}
+
+	if hyd.Spec.Adopt != nil {
+		adoptPath := specPath.Child("adopt")
+		switch {
+		case configureInfra:
+			allErrs = append(allErrs, field.Forbidden(adoptPath,
+				"a HostedCluster can only be adopted with spec.infrastructure.configure false"))
+		case len(hyd.Spec.HostedClusterRef.Name) != 0 || len(hyd.Spec.NodePoolsRef) != 0:
+			allErrs = append(allErrs, field.Forbidden(adoptPath,
+				"a HostedCluster can not be adopted with hostedClusterReference or nodePoolReferences"))
+		}
+		if len(hyd.Spec.Adopt.Image) == 0 {
+			allErrs = append(allErrs, field.Required(adoptPath.Child("image"), ""))
+		}
+	}
+
+	// Deleting the hosting namespace would also delete the orphaned HostedCluster
+	policy := hyd.Spec.GetDeletionPolicy()
+	if policy.HostedCluster == hypdeployment.DeletionPolicyOrphan && policy.HostingNamespace == hypdeployment.DeletionPolicyDelete {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("deletionPolicy", "hostingNamespace"), policy.HostingNamespace,
+			"the hosting namespace can not be deleted when the hostedCluster is orphaned"))
+	}
+
+	// The orphaned HostedCluster keeps running on the infrastructure and IAM the HypershiftDeployment configured
+	if configureInfra && !infraOnly && policy.HostedCluster == hypdeployment.DeletionPolicyOrphan &&
+		(policy.Infrastructure == hypdeployment.DeletionPolicyDelete || policy.IAM == hypdeployment.DeletionPolicyDelete) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("deletionPolicy", "hostedCluster"), policy.HostedCluster,
+			"the hostedCluster can only be orphaned with the infrastructure and iam when spec.infrastructure.configure is true"))
+	}
+
+	if hyd.Spec.ProvisioningScope == hypdeployment.ProvisioningScopeInfrastructureOnly && !configureInfra {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("provisioningScope"),
+			"InfrastructureOnly requires spec.infrastructure.configure to be true"))
+	}
+
+	return allErrs
+}
+
+// validateCredentialSource rejects the Azure credential sources the infrastructure can not be configured with. A
+// missing cloud provider secret is reported by the reconciler. It only runs on create, an update must not be blocked
+// because the secret of configured infrastructure was switched afterwards
+func (w *HypershiftDeploymentWebhook) validateCredentialSource(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if w.Client == nil || !hyd.Spec.Infrastructure.Configure || hyd.Spec.Infrastructure.Platform == nil ||
+		hyd.Spec.Infrastructure.Platform.Azure == nil || len(hyd.Spec.Infrastructure.CloudProvider.Name) == 0 {
+		return allErrs
+	}
+
+	providerSecret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: hyd.Namespace, Name: hyd.Spec.Infrastructure.CloudProvider.Name}
+	if err := w.Client.Get(ctx, key, providerSecret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, field.InternalError(field.NewPath("spec", "infrastructure", "cloudProvider"), err))
+		}
+		return allErrs
+	}
+
+	switch source := string(providerSecret.Data[constant.CredentialSource]); source {
+	case AzureCredentialSourceWorkloadIdentity, AzureCredentialSourceManagedIdentity:
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "infrastructure", "cloudProvider", "name"), key.Name,
+			fmt.Sprintf("the Azure infrastructure can only be configured with the %s credential source, not %s",
+				AzureCredentialSourceServicePrincipal, source)))
+	}
+	return allErrs
+}
+
+// configuredPlatforms lists the json names of the platforms set in spec.infrastructure.platform
+func configuredPlatforms(platform *hypdeployment.Platforms) []string {
+	platforms := []string{}
+	if platform == nil {
+		return platforms
+	}
+	if platform.AWS != nil {
+		platforms = append(platforms, "aws")
+	}
+	if platform.Azure != nil {
+		platforms = append(platforms, "azure")
+	}
+	if platform.KubeVirt != nil {
+		platforms = append(platforms, "kubevirt")
+	}
+	if platform.Agent != nil {
+		platforms = append(platforms, "agent")
+	}
+	return platforms
+}
+
+// validateHypershiftDeploymentCreate covers the fields that are only set by the controller once it is created
+func validateHypershiftDeploymentCreate(hyd *hypdeployment.HypershiftDeployment) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	// The spec is copied from the adopted HostedCluster and NodePools
+	if hyd.Spec.Adopt != nil {
+		if hyd.Spec.HostedClusterSpec != nil {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("hostedClusterSpec"),
+				"hostedClusterSpec is read from the adopted HostedCluster"))
+		}
+		if len(hyd.Spec.NodePools) != 0 {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("nodePools"),
+				"nodePools are read from the adopted NodePools"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateHypershiftDeploymentUpdate(hyd, oldHyd *hypdeployment.HypershiftDeployment) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+	infraPath := specPath.Child("infrastructure")
+
+	// An empty InfraID is generated by the controller, so it can only be set once. The infra-id of an adopted
+	// HostedCluster replaces the generated one when its spec is copied
+	adopted := oldHyd.Spec.Adopt != nil && oldHyd.Spec.HostedClusterSpec == nil && hyd.Spec.HostedClusterSpec != nil &&
+		hyd.Spec.InfraID == hyd.Spec.HostedClusterSpec.InfraID
+	if len(oldHyd.Spec.InfraID) != 0 && !adopted {
+		allErrs = append(allErrs, apivalidation.ValidateImmutableField(hyd.Spec.InfraID, oldHyd.Spec.InfraID, specPath.Child("infra-id"))...)
+	}
+
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(hyd.Spec.Infrastructure.Configure,
+		oldHyd.Spec.Infrastructure.Configure, infraPath.Child("configure"))...)
+
+	// Covers the AWS region and Azure location, the cloudProvider secret can be changed to rotate credentials
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(hyd.Spec.Infrastructure.Platform,
+		oldHyd.Spec.Infrastructure.Platform, infraPath.Child("platform"))...)
+
+	// The target of a migration is fixed until it completes or is rolled back
+	if migrationInProgress(oldHyd) && hyd.Spec.HostingCluster != oldHyd.Spec.HostingCluster {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("hostingCluster"),
+			"hostingCluster can not be changed while the HostedCluster is migrated to "+oldHyd.Status.Migration.Target))
+	}
+
+	return allErrs
+}
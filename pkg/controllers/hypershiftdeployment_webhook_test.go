@@ -0,0 +1,412 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+func getValidAWSHypershiftDeployment() *hyd.HypershiftDeployment {
+	testHD := getHypershiftDeployment("default", "test1", true)
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Infrastructure.CloudProvider = corev1.LocalObjectReference{Name: "aws-creds"}
+	testHD.Spec.Infrastructure.Platform = &hyd.Platforms{AWS: &hyd.AWSPlatform{Region: "us-east-1"}}
+	return testHD
+}
+
+func TestWebhookDefault(t *testing.T) {
+	w := &HypershiftDeploymentWebhook{Log: ctrl.Log.WithName("webhook")}
+
+	testHD := getHypershiftDeployment("default", "test1", false)
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.HostedClusterSpec = &hyp.HostedClusterSpec{
+		Platform: hyp.PlatformSpec{Type: hyp.AWSPlatform},
+		Release:  hyp.Release{Image: "quay.io/openshift-release-dev/ocp-release:4.11.2-x86_64"},
+		Services: []hyp.ServicePublishingStrategyMapping{},
+	}
+	testHD.Spec.NodePools = []*hyd.HypershiftNodePools{{Name: "np1"}}
+
+	assert.Nil(t, w.Default(context.Background(), testHD), "err is nil when defaulting")
+
+	assert.Contains(t, testHD.Spec.InfraID, "test1-", "the infra-id is generated from the name")
+	assert.Equal(t, testHD.Spec.InfraID, testHD.Labels[constant.InfraLabelName], "the infra-id label is set")
+	assert.NotEmpty(t, testHD.Spec.HostedClusterSpec.ClusterID, "the clusterID is generated")
+	assert.Equal(t, hyp.ManagementOLMCatalogPlacement, testHD.Spec.HostedClusterSpec.OLMCatalogPlacement)
+	assert.Equal(t, "test1-pull-secret", testHD.Spec.HostedClusterSpec.PullSecret.Name)
+	assert.NotEmpty(t, testHD.Spec.HostedClusterSpec.Services, "services are scaffolded")
+	assert.Equal(t, "test1", testHD.Spec.NodePools[0].Spec.ClusterName)
+	assert.Equal(t, hyp.AWSPlatform, testHD.Spec.NodePools[0].Spec.Platform.Type)
+	assert.Equal(t, testHD.Spec.HostedClusterSpec.Release.Image, testHD.Spec.NodePools[0].Spec.Release.Image)
+
+	assert.Nil(t, w.ValidateCreate(context.Background(), testHD), "a defaulted HypershiftDeployment is valid")
+}
+
+func TestWebhookDefaultConfigureWithoutSpec(t *testing.T) {
+	w := &HypershiftDeploymentWebhook{Log: ctrl.Log.WithName("webhook")}
+
+	testHD := getValidAWSHypershiftDeployment()
+	testHD.Spec.InfraID = "test1-abcde"
+
+	assert.Nil(t, w.Default(context.Background(), testHD), "err is nil when defaulting")
+	assert.Equal(t, "test1-abcde", testHD.Spec.InfraID, "a provided infra-id is kept")
+	assert.Nil(t, testHD.Spec.HostedClusterSpec, "the HostedClusterSpec is scaffolded from the infrastructure output")
+}
+
+func TestWebhookValidateCreate(t *testing.T) {
+	w := &HypershiftDeploymentWebhook{Log: ctrl.Log.WithName("webhook")}
+
+	cases := []struct {
+		name        string
+		mutate      func(*hyd.HypershiftDeployment)
+		expectedErr string
+	}{
+		{
+			name:   "valid",
+			mutate: func(*hyd.HypershiftDeployment) {},
+		},
+		{
+			name:        "missing hosting cluster",
+			mutate:      func(h *hyd.HypershiftDeployment) { h.Spec.HostingCluster = "" },
+			expectedErr: "spec.hostingCluster",
+		},
+		{
+			name: "missing hosting cluster with infra-only",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.HostingCluster = ""
+				h.Spec.Override = hyd.InfraConfigureOnly
+			},
+		},
+		{
+			name: "hosting cluster placement",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.HostingCluster = ""
+				h.Spec.HostingClusterPlacement = &corev1.LocalObjectReference{Name: "hosting"}
+			},
+		},
+		{
+			name: "hosting cluster and placement",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.HostingClusterPlacement = &corev1.LocalObjectReference{Name: "hosting"}
+			},
+			expectedErr: "spec.hostingClusterPlacement",
+		},
+		{
+			name:        "missing platform",
+			mutate:      func(h *hyd.HypershiftDeployment) { h.Spec.Infrastructure.Platform = nil },
+			expectedErr: "spec.infrastructure.platform",
+		},
+		{
+			name:        "missing region",
+			mutate:      func(h *hyd.HypershiftDeployment) { h.Spec.Infrastructure.Platform.AWS.Region = "" },
+			expectedErr: "spec.infrastructure.platform.aws.region",
+		},
+		{
+			name: "more than one platform",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Platform.KubeVirt = &hyd.KubeVirtPlatform{}
+			},
+			expectedErr: "only one platform can be configured",
+		},
+		{
+			name: "kubevirt",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Platform = &hyd.Platforms{KubeVirt: &hyd.KubeVirtPlatform{}}
+			},
+		},
+		{
+			name: "agent without an apiServerAddress",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Platform = &hyd.Platforms{Agent: &hyd.AgentPlatform{AgentNamespace: "agents"}}
+			},
+			expectedErr: "spec.infrastructure.platform.agent.apiServerAddress",
+		},
+		{
+			name:        "missing cloud provider",
+			mutate:      func(h *hyd.HypershiftDeployment) { h.Spec.Infrastructure.CloudProvider.Name = "" },
+			expectedErr: "spec.infrastructure.cloudProvider.name",
+		},
+		{
+			name: "missing hostedClusterSpec and hostedClusterRef",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Configure = false
+			},
+			expectedErr: "spec.hostedClusterSpec",
+		},
+		{
+			name: "nodepool platform mismatch",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.HostedClusterSpec = &hyp.HostedClusterSpec{Platform: hyp.PlatformSpec{Type: hyp.AWSPlatform}}
+				h.Spec.NodePools = []*hyd.HypershiftNodePools{
+					{Name: "np1", Spec: hyp.NodePoolSpec{ClusterName: h.Name, Platform: hyp.NodePoolPlatform{Type: hyp.AzurePlatform}}},
+				}
+			},
+			expectedErr: errPlatformTypeMismatch.Error(),
+		},
+		{
+			name: "nodepool cluster name mismatch",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.HostedClusterSpec = &hyp.HostedClusterSpec{Platform: hyp.PlatformSpec{Type: hyp.AWSPlatform}}
+				h.Spec.NodePools = []*hyd.HypershiftNodePools{
+					{Name: "np1", Spec: hyp.NodePoolSpec{ClusterName: "other", Platform: hyp.NodePoolPlatform{Type: hyp.AWSPlatform}}},
+				}
+			},
+			expectedErr: errNodePoolClusterName.Error(),
+		},
+		{
+			name: "nodepool policy without replicas",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.NodePools = []*hyd.HypershiftNodePools{{Name: "np1", Policy: &hyd.NodePoolPolicy{}}}
+			},
+			expectedErr: "spec.nodePools[0].policy",
+		},
+		{
+			name: "missing hosting cluster with the infrastructure only scope",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.HostingCluster = ""
+				h.Spec.ProvisioningScope = hyd.ProvisioningScopeInfrastructureOnly
+			},
+		},
+		{
+			name: "infrastructure only scope without configure",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Configure = false
+				h.Spec.HostedClusterRef.Name = "hc"
+				h.Spec.ProvisioningScope = hyd.ProvisioningScopeInfrastructureOnly
+			},
+			expectedErr: "spec.provisioningScope",
+		},
+		{
+			name: "infrastructure only scope and delete the hosting namespace",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.ProvisioningScope = hyd.ProvisioningScopeInfrastructureOnly
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{HostingNamespace: hyd.DeletionPolicyDelete}
+			},
+		},
+		{
+			name: "orphan the infrastructure and delete the IAM",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{Infrastructure: hyd.DeletionPolicyOrphan}
+			},
+		},
+		{
+			name: "orphan the hosted cluster and delete the hosting namespace",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{
+					HostedCluster:    hyd.DeletionPolicyOrphan,
+					Infrastructure:   hyd.DeletionPolicyOrphan,
+					IAM:              hyd.DeletionPolicyOrphan,
+					HostingNamespace: hyd.DeletionPolicyDelete,
+				}
+			},
+			expectedErr: "spec.deletionPolicy.hostingNamespace",
+		},
+		{
+			name: "orphan the hosted cluster, the infrastructure and the IAM",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{
+					HostedCluster:  hyd.DeletionPolicyOrphan,
+					Infrastructure: hyd.DeletionPolicyOrphan,
+					IAM:            hyd.DeletionPolicyOrphan,
+				}
+			},
+		},
+		{
+			name: "orphan the hosted cluster and delete the infrastructure",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{HostedCluster: hyd.DeletionPolicyOrphan, IAM: hyd.DeletionPolicyOrphan}
+			},
+			expectedErr: "spec.deletionPolicy.hostedCluster",
+		},
+		{
+			name: "orphan the hosted cluster and delete the IAM",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{HostedCluster: hyd.DeletionPolicyOrphan, Infrastructure: hyd.DeletionPolicyOrphan}
+			},
+			expectedErr: "spec.deletionPolicy.hostedCluster",
+		},
+		{
+			name: "orphan the hosted cluster without configuring the infrastructure",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Configure = false
+				h.Spec.HostedClusterRef.Name = "hc"
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{HostedCluster: hyd.DeletionPolicyOrphan}
+			},
+		},
+		{
+			name: "orphan override",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Override = hyd.InfraOverrideDestroy
+			},
+		},
+		{
+			name: "orphan override and delete the hosting namespace",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Override = hyd.InfraOverrideDestroy
+				h.Spec.DeletionPolicy = &hyd.DeletionPolicy{HostingNamespace: hyd.DeletionPolicyDelete}
+			},
+			expectedErr: "spec.deletionPolicy.hostingNamespace",
+		},
+		{
+			name: "adopt",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Configure = false
+				h.Spec.HostedClusterSpec = nil
+				h.Spec.NodePools = nil
+				h.Spec.Adopt = &hyd.AdoptSpec{Image: "quay.io/openshift/origin-cli:4.11"}
+			},
+		},
+		{
+			name: "adopt with configure",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Adopt = &hyd.AdoptSpec{Image: "quay.io/openshift/origin-cli:4.11"}
+			},
+			expectedErr: "spec.adopt",
+		},
+		{
+			name: "adopt with a hosted cluster spec",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Configure = false
+				h.Spec.HostedClusterSpec = &hyp.HostedClusterSpec{Platform: hyp.PlatformSpec{Type: hyp.AWSPlatform}}
+				h.Spec.Adopt = &hyd.AdoptSpec{Image: "quay.io/openshift/origin-cli:4.11"}
+			},
+			expectedErr: "spec.hostedClusterSpec",
+		},
+		{
+			name: "adopt with nodepools",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Configure = false
+				h.Spec.NodePools = []*hyd.HypershiftNodePools{{Name: "np1"}}
+				h.Spec.Adopt = &hyd.AdoptSpec{Image: "quay.io/openshift/origin-cli:4.11"}
+			},
+			expectedErr: "spec.nodePools",
+		},
+		{
+			name: "adopt without image",
+			mutate: func(h *hyd.HypershiftDeployment) {
+				h.Spec.Infrastructure.Configure = false
+				h.Spec.Adopt = &hyd.AdoptSpec{}
+			},
+			expectedErr: "spec.adopt.image",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testHD := getValidAWSHypershiftDeployment()
+			c.mutate(testHD)
+
+			err := w.ValidateCreate(context.Background(), testHD)
+			if len(c.expectedErr) == 0 {
+				assert.Nil(t, err, "err is nil when the HypershiftDeployment is valid")
+				return
+			}
+
+			assert.True(t, apierrors.IsInvalid(err), "err is an invalid error")
+			assert.Contains(t, err.Error(), c.expectedErr)
+		})
+	}
+}
+
+func TestWebhookValidateCredentialSource(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+	w := &HypershiftDeploymentWebhook{Log: ctrl.Log.WithName("webhook"), Client: client}
+
+	testHD := getHypershiftDeployment("default", "test1", true)
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Infrastructure.CloudProvider = corev1.LocalObjectReference{Name: "azure-creds"}
+	testHD.Spec.Infrastructure.Platform = &hyd.Platforms{Azure: &hyd.AzurePlatform{Location: "centralus"}}
+	assert.Nil(t, w.ValidateCreate(ctx, testHD), "the cloud provider secret is reported by the reconciler when it is missing")
+
+	providerSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "azure-creds", Namespace: "default"},
+		Data: map[string][]byte{
+			constant.CredentialSource:    []byte(AzureCredentialSourceWorkloadIdentity),
+			constant.AzureClientID:       []byte("11111111-1111-1111-1111-111111111111"),
+			constant.AzureTenantID:       []byte("22222222-2222-2222-2222-222222222222"),
+			constant.AzureSubscriptionID: []byte("33333333-3333-3333-3333-333333333333"),
+		},
+	}
+	assert.Nil(t, client.Create(ctx, providerSecret))
+	err := w.ValidateCreate(ctx, testHD)
+	assert.True(t, apierrors.IsInvalid(err), "the Azure infrastructure can not be configured with the identity of the controller")
+	assert.Contains(t, err.Error(), "spec.infrastructure.cloudProvider.name")
+
+	updatedHD := testHD.DeepCopy()
+	updatedHD.Labels = map[string]string{"team": "a"}
+	assert.Nil(t, w.ValidateUpdate(ctx, testHD, updatedHD), "the credential source is only validated on create")
+
+	testHD.Spec.Infrastructure.Configure = false
+	testHD.Spec.HostedClusterSpec = &hyp.HostedClusterSpec{Platform: hyp.PlatformSpec{Type: hyp.AzurePlatform}}
+	assert.Nil(t, w.ValidateCreate(ctx, testHD), "the identity can be used when the infrastructure is not configured")
+}
+
+func TestWebhookValidateUpdate(t *testing.T) {
+	w := &HypershiftDeploymentWebhook{Log: ctrl.Log.WithName("webhook")}
+
+	oldHD := getValidAWSHypershiftDeployment()
+	oldHD.Spec.InfraID = "test1-abcde"
+
+	newHD := oldHD.DeepCopy()
+	newHD.Spec.Infrastructure.CloudProvider.Name = "rotated-aws-creds"
+	assert.Nil(t, w.ValidateUpdate(context.Background(), oldHD, newHD), "the cloud provider secret can be changed")
+
+	newHD = oldHD.DeepCopy()
+	newHD.Spec.Infrastructure.Platform.AWS.Region = "us-west-1"
+	err := w.ValidateUpdate(context.Background(), oldHD, newHD)
+	assert.NotNil(t, err, "the region is immutable")
+	assert.Contains(t, err.Error(), "spec.infrastructure.platform")
+
+	newHD = oldHD.DeepCopy()
+	newHD.Spec.InfraID = "test1-fghij"
+	err = w.ValidateUpdate(context.Background(), oldHD, newHD)
+	assert.NotNil(t, err, "the infra-id is immutable")
+	assert.Contains(t, err.Error(), "spec.infra-id")
+
+	newHD = oldHD.DeepCopy()
+	newHD.Spec.Infrastructure.Configure = false
+	err = w.ValidateUpdate(context.Background(), oldHD, newHD)
+	assert.NotNil(t, err, "configure is immutable")
+	assert.Contains(t, err.Error(), "spec.infrastructure.configure")
+
+	newHD = oldHD.DeepCopy()
+	newHD.Spec.HostingCluster = "cluster2"
+	assert.Nil(t, w.ValidateUpdate(context.Background(), oldHD, newHD), "the hosting cluster can be changed to migrate")
+
+	migratingHD := oldHD.DeepCopy()
+	migratingHD.Status.Migration = &hyd.MigrationStatus{Target: "cluster2", Step: hyd.MigrationStepApplying}
+	err = w.ValidateUpdate(context.Background(), migratingHD, newHD)
+	assert.NotNil(t, err, "the hosting cluster is fixed during a migration")
+	assert.Contains(t, err.Error(), "spec.hostingCluster")
+
+	emptyInfraIDHD := oldHD.DeepCopy()
+	emptyInfraIDHD.Spec.InfraID = ""
+	assert.Nil(t, w.ValidateUpdate(context.Background(), emptyInfraIDHD, oldHD), "the infra-id can be set once")
+
+	adoptHD := oldHD.DeepCopy()
+	adoptHD.Spec.Infrastructure.Configure = false
+	adoptHD.Spec.Adopt = &hyd.AdoptSpec{Image: "quay.io/openshift/origin-cli:4.11"}
+	newHD = adoptHD.DeepCopy()
+	newHD.Spec.InfraID = "test1-xyz"
+	newHD.Spec.HostedClusterSpec = &hyp.HostedClusterSpec{InfraID: "test1-xyz", Platform: hyp.PlatformSpec{Type: hyp.AWSPlatform}}
+	assert.Nil(t, w.ValidateUpdate(context.Background(), adoptHD, newHD), "the infra-id of the adopted HostedCluster is kept")
+
+	newHD.Spec.InfraID = "test1-fghij"
+	err = w.ValidateUpdate(context.Background(), adoptHD, newHD)
+	assert.NotNil(t, err, "only the infra-id of the adopted HostedCluster can be set")
+	assert.Contains(t, err.Error(), "spec.infra-id")
+
+	newHD = oldHD.DeepCopy()
+	newHD.Spec.HostingCluster = ""
+	newHD.DeletionTimestamp = &metav1.Time{}
+	assert.Nil(t, w.ValidateUpdate(context.Background(), oldHD, newHD), "deleting HypershiftDeployments are not validated")
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openshift/hypershift/cmd/infra/aws"
+	"github.com/openshift/hypershift/cmd/infra/azure"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/metrics"
+)
+
+// infraConfigured is true once both the infrastructure and IAM are configured, there is nothing to verify before
+func infraConfigured(hyd *hypdeployment.HypershiftDeployment) bool {
+	return meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured)) &&
+		meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.PlatformIAMConfigured))
+}
+
+// nextInfraVerification is the time until the recorded infrastructure should be verified, 0 when it is not verified
+func (r *HypershiftDeploymentReconciler) nextInfraVerification(hyd *hypdeployment.HypershiftDeployment) time.Duration {
+	if r.InfraVerifyInterval <= 0 || !hyd.Spec.Infrastructure.Configure || hyd.Status.Infrastructure == nil ||
+		(hyd.Status.Infrastructure.AWS == nil && hyd.Status.Infrastructure.Azure == nil) {
+		return 0
+	}
+
+	last := hyd.Status.Infrastructure.LastVerifiedTime
+	if last == nil {
+		return time.Nanosecond
+	}
+	if next := r.InfraVerifyInterval - time.Since(last.Time); next > 0 {
+		return next
+	}
+	return time.Nanosecond
+}
+
+// requeueForInfraVerification makes sure the HypershiftDeployment is reconciled again when the next verification is due
+func (r *HypershiftDeploymentReconciler) requeueForInfraVerification(hyd *hypdeployment.HypershiftDeployment, res ctrl.Result) ctrl.Result {
+	next := r.nextInfraVerification(hyd)
+	if next == 0 {
+		return res
+	}
+	if next < time.Second {
+		next = time.Second
+	}
+	if res.RequeueAfter == 0 || next < res.RequeueAfter {
+		res.RequeueAfter = next
+	}
+	return res
+}
+
+// verifyInfrastructure compares the resources recorded in Status.Infrastructure with the provider, once every
+// InfraVerifyInterval. With Spec.Infrastructure.RepairDrift, the platform conditions are reset so the infrastructure
+// and IAM are created again by createAWSInfra or createAzureInfra
+func (r *HypershiftDeploymentReconciler) verifyInfrastructure(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) error {
+	log := r.Log
+
+	if !infraConfigured(hyd) || r.nextInfraVerification(hyd) != time.Nanosecond {
+		return nil
+	}
+
+	var missing []string
+	var err error
+	var platform string
+	infra := hyd.Status.Infrastructure
+	start := time.Now()
+	switch {
+	case infra.AWS != nil && hyd.Spec.Infrastructure.Platform.AWS != nil:
+		platform = metrics.PlatformAWS
+		creds, credErr := r.InfraHandler.AWSCredentialSource(providerSecret)
+		if credErr != nil {
+			// Reported by createAWSInfra through the ProviderSecretConfigured condition
+			return nil
+		}
+		missing, err = r.InfraHandler.AwsInfraVerifier(
+			creds,
+			hyd.Spec.Infrastructure.Platform.AWS.Region,
+			infra.AWS,
+		)(r.ctx)
+	case infra.Azure != nil && hyd.Spec.Infrastructure.Platform.Azure != nil:
+		platform = metrics.PlatformAzure
+		creds, credErr := r.InfraHandler.AzureCredentialSource(providerSecret)
+		if credErr != nil {
+			// Reported by createAzureInfra through the ProviderSecretConfigured condition
+			return nil
+		}
+		missing, err = r.InfraHandler.AzureInfraVerifier(creds, infra.Azure)(r.ctx)
+	default:
+		return nil
+	}
+	metrics.ObserveInfraOperation(platform, metrics.OperationVerifyInfra, start, err)
+
+	// Recorded even when the verification fails, so an unavailable provider is not called on every reconcile
+	if err := r.updateInfrastructureStatus(hyd, func(s *hypdeployment.InfrastructureStatus) {
+		now := metav1.Now()
+		s.LastVerifiedTime = &now
+	}); err != nil {
+		return err
+	}
+
+	if err != nil {
+		log.Error(err, "Could not verify the infrastructure")
+		return r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformInfrastructureDrifted, metav1.ConditionUnknown, err.Error(), hypdeployment.VerificationFailedReason)
+	}
+
+	if len(missing) == 0 {
+		return r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformInfrastructureDrifted, metav1.ConditionFalse, "", hypdeployment.AsExpectedReason)
+	}
+
+	message := "Missing on the provider: " + strings.Join(missing, ", ")
+	log.Info("Infrastructure drift detected", "missing", missing)
+	r.recordEvent(hyd, corev1.EventTypeWarning, InfraDriftDetectedEvent, "%s", message)
+	if err := r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformInfrastructureDrifted, metav1.ConditionTrue, message, hypdeployment.ResourcesMissingReason); err != nil {
+		return err
+	}
+
+	if !hyd.Spec.Infrastructure.RepairDrift {
+		return nil
+	}
+
+	log.Info("Repairing the infrastructure drift")
+	r.recordEvent(hyd, corev1.EventTypeNormal, InfraDriftRepairEvent, "Configuring the infrastructure and IAM again with infra-id: %s", hyd.Spec.InfraID)
+
+	// Verified again as soon as the repair completes, which clears the drift condition
+	if err := r.updateInfrastructureStatus(hyd, func(s *hypdeployment.InfrastructureStatus) {
+		s.LastVerifiedTime = nil
+	}); err != nil {
+		return err
+	}
+	setStatusCondition(hyd, hypdeployment.PlatformIAMConfigured, metav1.ConditionFalse, "Repairing drifted infrastructure with infra-id: "+hyd.Spec.InfraID, hypdeployment.RepairingReason)
+	return r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, "Repairing drifted infrastructure with infra-id: "+hyd.Spec.InfraID, hypdeployment.RepairingReason)
+}
+
+// updateRepairedAWSNodePools points the NodePools at the subnets and security group recreated by a repair,
+// ScaffoldAWSNodePoolSpec only fills in the references that are missing
+func updateRepairedAWSNodePools(hyd *hypdeployment.HypershiftDeployment, previous *hypdeployment.AWSInfrastructureStatus, infraOut *aws.CreateInfraOutput) {
+	if previous == nil {
+		return
+	}
+
+	subnets := map[string]string{}
+	for _, old := range previous.Zones {
+		for _, zone := range infraOut.Zones {
+			if zone.Name == old.Name && zone.SubnetID != old.SubnetID {
+				subnets[old.SubnetID] = zone.SubnetID
+			}
+		}
+	}
+
+	for _, np := range hyd.Spec.NodePools {
+		npAWS := np.Spec.Platform.AWS
+		if npAWS == nil {
+			continue
+		}
+		if npAWS.Subnet != nil && npAWS.Subnet.ID != nil {
+			if id, found := subnets[*npAWS.Subnet.ID]; found {
+				npAWS.Subnet.ID = &id
+			}
+		}
+		if previous.SecurityGroupID == "" || previous.SecurityGroupID == infraOut.SecurityGroupID {
+			continue
+		}
+		for i, sg := range npAWS.SecurityGroups {
+			if sg.ID != nil && *sg.ID == previous.SecurityGroupID {
+				id := infraOut.SecurityGroupID
+				npAWS.SecurityGroups[i].ID = &id
+			}
+		}
+	}
+}
+
+// updateRepairedAzureNodePools points the NodePools at the boot image recreated by a repair
+func updateRepairedAzureNodePools(hyd *hypdeployment.HypershiftDeployment, previous *hypdeployment.AzureInfrastructureStatus, infraOut *azure.CreateInfraOutput) {
+	if previous == nil || previous.BootImageID == "" || previous.BootImageID == infraOut.BootImageID {
+		return
+	}
+
+	for _, np := range hyd.Spec.NodePools {
+		if np.Spec.Platform.Azure != nil && np.Spec.Platform.Azure.ImageID == previous.BootImageID {
+			np.Spec.Platform.Azure.ImageID = infraOut.BootImageID
+		}
+	}
+}
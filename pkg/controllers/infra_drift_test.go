@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/openshift/hypershift/cmd/infra/aws"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// driftInfraHandler reports the missing resources for the recorded AWS infrastructure
+type driftInfraHandler struct {
+	FakeInfraHandler
+	missing []string
+	calls   int
+}
+
+func (h *driftInfraHandler) AwsInfraVerifier(creds AWSCredentialSource, region string, infra *hypdeployment.AWSInfrastructureStatus) AwsVerifyInfra {
+	return func(ctx context.Context) ([]string, error) {
+		h.calls++
+		return h.missing, nil
+	}
+}
+
+func getConfiguredAWSHD(t *testing.T, r *HypershiftDeploymentReconciler, repairDrift bool) *hypdeployment.HypershiftDeployment {
+	ctx := context.Background()
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Infrastructure.Platform.AWS.Region = "us-east-1"
+	testHD.Spec.Infrastructure.Configure = true
+	testHD.Spec.Infrastructure.RepairDrift = repairDrift
+
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+	assert.Nil(t, r.Client.Create(ctx, getS3Secret("local-cluster")))
+
+	r.InfraHandler = &FakeInfraHandler{}
+	_, err := r.createAWSInfra(testHD, getProviderSecret())
+	assert.Nil(t, err)
+	assert.True(t, infraConfigured(testHD), "the infrastructure and IAM are configured")
+	return testHD
+}
+
+func TestVerifyInfrastructureDrift(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	testHD := getConfiguredAWSHD(t, r, false)
+
+	handler := &driftInfraHandler{missing: []string{"subnet subnet-0123456789abcdefg"}}
+	r.InfraHandler = handler
+
+	t.Log("Verification is disabled without an interval")
+	assert.Nil(t, r.verifyInfrastructure(testHD, getProviderSecret()))
+	assert.Equal(t, 0, handler.calls)
+
+	r.InfraVerifyInterval = time.Hour
+	assert.Nil(t, r.verifyInfrastructure(testHD, getProviderSecret()))
+	assert.Equal(t, 1, handler.calls)
+
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hypdeployment.PlatformInfrastructureDrifted))
+	assert.NotNil(t, c)
+	assert.Equal(t, hypdeployment.ResourcesMissingReason, c.Reason)
+	assert.Contains(t, c.Message, "subnet-0123456789abcdefg")
+	assert.NotNil(t, testHD.Status.Infrastructure.LastVerifiedTime)
+	assert.True(t, infraConfigured(testHD), "the platform is not reconfigured without repairDrift")
+
+	t.Log("Verification is not repeated until the interval passes")
+	assert.Nil(t, r.verifyInfrastructure(testHD, getProviderSecret()))
+	assert.Equal(t, 1, handler.calls)
+
+	res := r.requeueForInfraVerification(testHD, ctrl.Result{})
+	assert.True(t, res.RequeueAfter > 59*time.Minute && res.RequeueAfter <= time.Hour, "requeued for the next verification")
+	res = r.requeueForInfraVerification(testHD, ctrl.Result{RequeueAfter: time.Minute})
+	assert.Equal(t, time.Minute, res.RequeueAfter, "an earlier requeue is kept")
+}
+
+func TestVerifyInfrastructureNoDrift(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	testHD := getConfiguredAWSHD(t, r, false)
+
+	r.InfraVerifyInterval = time.Hour
+	assert.Nil(t, r.verifyInfrastructure(testHD, getProviderSecret()))
+	assert.True(t, meta.IsStatusConditionFalse(testHD.Status.Conditions, string(hypdeployment.PlatformInfrastructureDrifted)))
+	assert.True(t, infraConfigured(testHD))
+}
+
+func TestVerifyInfrastructureFailure(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	testHD := getConfiguredAWSHD(t, r, false)
+
+	r.InfraVerifyInterval = time.Hour
+	r.InfraHandler = &FakeInfraHandlerFailure{}
+	assert.Nil(t, r.verifyInfrastructure(testHD, getProviderSecret()), "a provider error is reported in the condition")
+
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hypdeployment.PlatformInfrastructureDrifted))
+	assert.NotNil(t, c)
+	assert.Equal(t, hypdeployment.VerificationFailedReason, c.Reason)
+	assert.True(t, infraConfigured(testHD), "a failed verification does not repair the infrastructure")
+}
+
+func TestVerifyInfrastructureRepair(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	testHD := getConfiguredAWSHD(t, r, true)
+
+	r.InfraVerifyInterval = time.Hour
+	r.InfraHandler = &driftInfraHandler{missing: []string{"security group sg-a1b2c3d4e5f6g7hig"}}
+	assert.Nil(t, r.verifyInfrastructure(testHD, getProviderSecret()))
+
+	assert.True(t, meta.IsStatusConditionTrue(testHD.Status.Conditions, string(hypdeployment.PlatformInfrastructureDrifted)))
+	for _, ct := range []hypdeployment.ConditionType{hypdeployment.PlatformConfigured, hypdeployment.PlatformIAMConfigured} {
+		c := meta.FindStatusCondition(testHD.Status.Conditions, string(ct))
+		assert.Equal(t, hypdeployment.RepairingReason, c.Reason, "%s is reset to repair the drift", ct)
+	}
+	assert.Nil(t, testHD.Status.Infrastructure.LastVerifiedTime, "verified again once the repair completes")
+}
+
+func TestUpdateRepairedAWSNodePools(t *testing.T) {
+	testHD := getHDforManifestWork()
+	oldSubnet, oldSG, otherSG := "subnet-old", "sg-old", "sg-user"
+	testHD.Spec.NodePools = []*hypdeployment.HypershiftNodePools{{
+		Name: "np1",
+		Spec: hyp.NodePoolSpec{Platform: hyp.NodePoolPlatform{AWS: &hyp.AWSNodePoolPlatform{
+			Subnet:         &hyp.AWSResourceReference{ID: &oldSubnet},
+			SecurityGroups: []hyp.AWSResourceReference{{ID: &oldSG}, {ID: &otherSG}},
+		}}},
+	}}
+	previous := &hypdeployment.AWSInfrastructureStatus{
+		SecurityGroupID: oldSG,
+		Zones:           []hypdeployment.AWSZoneStatus{{Name: "us-east-1a", SubnetID: oldSubnet}},
+	}
+	infraOut := &aws.CreateInfraOutput{
+		SecurityGroupID: "sg-new",
+		Zones:           []*aws.CreateInfraOutputZone{{Name: "us-east-1a", SubnetID: "subnet-new"}},
+	}
+
+	updateRepairedAWSNodePools(testHD, previous, infraOut)
+
+	npAWS := testHD.Spec.NodePools[0].Spec.Platform.AWS
+	assert.Equal(t, "subnet-new", *npAWS.Subnet.ID)
+	assert.Equal(t, "sg-new", *npAWS.SecurityGroups[0].ID)
+	assert.Equal(t, "sg-user", *npAWS.SecurityGroups[1].ID, "other security groups are kept")
+}
@@ -20,24 +20,34 @@ import (
 	"context"
 	"errors"
 
+	corev1 "k8s.io/api/core/v1"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/go-logr/logr"
-	"github.com/openshift/hypershift/api/fixtures"
 	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
 	"github.com/openshift/hypershift/cmd/infra/aws"
 	"github.com/openshift/hypershift/cmd/infra/azure"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
 )
 
 type InfraHandler interface {
-	AwsInfraCreator(awsKey, awsSecretKey, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra
-	AwsInfraDestroyer(awsKey, awsSecretKey, region, infraID, name, baseDomain string) AwsDestroyInfra
-	AwsIAMCreator(awsKey, awsSecretKey, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM
-	AwsIAMDestroyer(awsKey, awsSecretKey, region, infraID string) AwsDestroyIAM
+	AwsInfraCreator(creds AWSCredentialSource, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra
+	AwsInfraDestroyer(creds AWSCredentialSource, region, infraID, name, baseDomain string) AwsDestroyInfra
+	AwsIAMCreator(creds AWSCredentialSource, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM
+	AwsIAMDestroyer(creds AWSCredentialSource, region, infraID string) AwsDestroyIAM
+
+	AzureInfraDestroyer(name, location, infraID string, creds AzureCredentialSource) AzureDestroyInfra
+	AzureInfraCreator(name, baseDomain, location, infraID string, creds AzureCredentialSource) AzureCreateInfra
+
+	AwsInfraVerifier(creds AWSCredentialSource, region string, infra *hypdeployment.AWSInfrastructureStatus) AwsVerifyInfra
+	AzureInfraVerifier(creds AzureCredentialSource, infra *hypdeployment.AzureInfrastructureStatus) AzureVerifyInfra
 
-	AzureInfraDestroyer(name, location, infraID string, credentials *fixtures.AzureCreds) AzureDestroyInfra
-	AzureInfraCreator(name, baseDomain, location, infraID string, credentials *fixtures.AzureCreds) AzureCreateInfra
+	// AWSCredentialSource and AzureCredentialSource select how the credentials are obtained from the cloud provider
+	// secret, see credentials.go
+	AWSCredentialSource(providerSecret *corev1.Secret) (AWSCredentialSource, error)
+	AzureCredentialSource(providerSecret *corev1.Secret) (AzureCredentialSource, error)
 }
 
 type AwsCreateInfra func(ctx context.Context, l logr.Logger) (*aws.CreateInfraOutput, error)
@@ -47,86 +57,132 @@ type AwsDestroyIAM func(ctx context.Context) error
 type AzureDestroyInfra func(ctx context.Context) error
 type AzureCreateInfra func(ctx context.Context, l logr.Logger) (*azure.CreateInfraOutput, error)
 
+// AwsVerifyInfra and AzureVerifyInfra return the recorded resources that no longer exist on the provider
+type AwsVerifyInfra func(ctx context.Context) ([]string, error)
+type AzureVerifyInfra func(ctx context.Context) ([]string, error)
+
 var _ InfraHandler = &DefaultInfraHandler{}
 
-type DefaultInfraHandler struct{}
+type DefaultInfraHandler struct {
+	// AWSWebIdentityTokenFile and AzureFederatedTokenFile are the service account tokens of the controller, used by
+	// the WebIdentity and WorkloadIdentity credential sources
+	AWSWebIdentityTokenFile string
+	AzureFederatedTokenFile string
 
-func (h *DefaultInfraHandler) AwsInfraCreator(awsKey, awsSecretKey, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra {
-	o := &aws.CreateInfraOptions{
-		AWSKey:       awsKey,
-		AWSSecretKey: awsSecretKey,
-		Region:       region,
-		Zones:        zones,
-		InfraID:      infraID,
-		Name:         name,
-		BaseDomain:   baseDomain,
-	}
-	return o.CreateInfra
+	// ControllerIdentities allows the credential sources that act with the identity of the controller: AssumeRole
+	// without keys, WebIdentity, WorkloadIdentity and ManagedIdentity
+	ControllerIdentities ControllerIdentities
 }
 
-func (h *DefaultInfraHandler) AwsInfraDestroyer(awsKey, awsSecretKey, region, infraID, name, baseDomain string) AwsDestroyInfra {
-	o := &aws.DestroyInfraOptions{
-		AWSKey:       awsKey,
-		AWSSecretKey: awsSecretKey,
-		Region:       region,
-		InfraID:      infraID,
-		Name:         name,
-		BaseDomain:   baseDomain,
-		Log:          log.FromContext(context.Background()),
+func (h *DefaultInfraHandler) AwsInfraCreator(creds AWSCredentialSource, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra {
+	return func(ctx context.Context, l logr.Logger) (*aws.CreateInfraOutput, error) {
+		o := &aws.CreateInfraOptions{
+			Region:     region,
+			Zones:      zones,
+			InfraID:    infraID,
+			Name:       name,
+			BaseDomain: baseDomain,
+		}
+		var cleanup func()
+		var err error
+		if o.AWSKey, o.AWSSecretKey, o.AWSCredentialsFile, cleanup, err = awsCredentialOptions(ctx, creds, region); err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		return o.CreateInfra(ctx, l)
 	}
-	return o.DestroyInfra
 }
 
-func (h *DefaultInfraHandler) AwsIAMCreator(awsKey, awsSecretKey, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM {
-	iamOpt := aws.CreateIAMOptions{
-		Region:       region,
-		AWSKey:       awsKey,
-		AWSSecretKey: awsSecretKey,
-		InfraID:      infraID,
-		// IssuerURL:                       "", //This is generated on the fly by CreateIAMOutput
-		// AdditionalTags:                  []string{},
-		OIDCStorageProviderS3BucketName: s3BucketName,
-		OIDCStorageProviderS3Region:     s3Region,
-		PrivateZoneID:                   privateZoneID,
-		PublicZoneID:                    publicZoneID,
-		LocalZoneID:                     localZoneID,
+func (h *DefaultInfraHandler) AwsInfraDestroyer(creds AWSCredentialSource, region, infraID, name, baseDomain string) AwsDestroyInfra {
+	return func(ctx context.Context) error {
+		o := &aws.DestroyInfraOptions{
+			Region:     region,
+			InfraID:    infraID,
+			Name:       name,
+			BaseDomain: baseDomain,
+			Log:        log.FromContext(context.Background()),
+		}
+		var cleanup func()
+		var err error
+		if o.AWSKey, o.AWSSecretKey, o.AWSCredentialsFile, cleanup, err = awsCredentialOptions(ctx, creds, region); err != nil {
+			return err
+		}
+		defer cleanup()
+		return o.DestroyInfra(ctx)
 	}
-
-	return iamOpt.CreateIAM
 }
 
-func (h *DefaultInfraHandler) AwsIAMDestroyer(awsKey, awsSecretKey, region, infraID string) AwsDestroyIAM {
-	iamOpt := aws.DestroyIAMOptions{
-		Region:       region,
-		AWSKey:       awsKey,
-		AWSSecretKey: awsSecretKey,
-		InfraID:      infraID,
-		Log:          log.FromContext(context.Background()),
+func (h *DefaultInfraHandler) AwsIAMCreator(creds AWSCredentialSource, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM {
+	return func(ctx context.Context, client crclient.Client) (*aws.CreateIAMOutput, error) {
+		iamOpt := aws.CreateIAMOptions{
+			Region:  region,
+			InfraID: infraID,
+			// IssuerURL:                       "", //This is generated on the fly by CreateIAMOutput
+			// AdditionalTags:                  []string{},
+			OIDCStorageProviderS3BucketName: s3BucketName,
+			OIDCStorageProviderS3Region:     s3Region,
+			PrivateZoneID:                   privateZoneID,
+			PublicZoneID:                    publicZoneID,
+			LocalZoneID:                     localZoneID,
+		}
+		var cleanup func()
+		var err error
+		if iamOpt.AWSKey, iamOpt.AWSSecretKey, iamOpt.AWSCredentialsFile, cleanup, err = awsCredentialOptions(ctx, creds, region); err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		return iamOpt.CreateIAM(ctx, client)
 	}
-
-	return iamOpt.DestroyIAM
 }
 
-func (h *DefaultInfraHandler) AzureInfraDestroyer(name, location, infraID string, credentials *fixtures.AzureCreds) AzureDestroyInfra {
-	dOpts := azure.DestroyInfraOptions{
-		Location:    location,
-		Credentials: credentials,
-		Name:        name,
-		InfraID:     infraID,
+func (h *DefaultInfraHandler) AwsIAMDestroyer(creds AWSCredentialSource, region, infraID string) AwsDestroyIAM {
+	return func(ctx context.Context) error {
+		iamOpt := aws.DestroyIAMOptions{
+			Region:  region,
+			InfraID: infraID,
+			Log:     log.FromContext(context.Background()),
+		}
+		var cleanup func()
+		var err error
+		if iamOpt.AWSKey, iamOpt.AWSSecretKey, iamOpt.AWSCredentialsFile, cleanup, err = awsCredentialOptions(ctx, creds, region); err != nil {
+			return err
+		}
+		defer cleanup()
+		return iamOpt.DestroyIAM(ctx)
 	}
+}
 
-	return dOpts.Run
+func (h *DefaultInfraHandler) AzureInfraDestroyer(name, location, infraID string, creds AzureCredentialSource) AzureDestroyInfra {
+	return func(ctx context.Context) error {
+		credentials, err := azureServicePrincipal(creds)
+		if err != nil {
+			return err
+		}
+		dOpts := azure.DestroyInfraOptions{
+			Location:    location,
+			Credentials: credentials,
+			Name:        name,
+			InfraID:     infraID,
+		}
+		return dOpts.Run(ctx)
+	}
 }
 
-func (h *DefaultInfraHandler) AzureInfraCreator(name, baseDomain, location, infraID string, credentials *fixtures.AzureCreds) AzureCreateInfra {
-	o := azure.CreateInfraOptions{
-		Location:    location,
-		InfraID:     infraID,
-		Name:        name,
-		BaseDomain:  baseDomain,
-		Credentials: credentials,
+func (h *DefaultInfraHandler) AzureInfraCreator(name, baseDomain, location, infraID string, creds AzureCredentialSource) AzureCreateInfra {
+	return func(ctx context.Context, l logr.Logger) (*azure.CreateInfraOutput, error) {
+		credentials, err := azureServicePrincipal(creds)
+		if err != nil {
+			return nil, err
+		}
+		o := azure.CreateInfraOptions{
+			Location:    location,
+			InfraID:     infraID,
+			Name:        name,
+			BaseDomain:  baseDomain,
+			Credentials: credentials,
+		}
+		return o.Run(ctx, l)
 	}
-	return o.Run
 }
 
 var _ InfraHandler = &FakeInfraHandler{}
@@ -135,7 +191,23 @@ type FakeInfraHandler struct{}
 
 type FakeInfraHandlerFailure struct{}
 
-func (h *FakeInfraHandler) AwsInfraCreator(awsKey, awsSecretKey, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra {
+func (h *FakeInfraHandler) AWSCredentialSource(providerSecret *corev1.Secret) (AWSCredentialSource, error) {
+	return (&DefaultInfraHandler{}).AWSCredentialSource(providerSecret)
+}
+
+func (h *FakeInfraHandlerFailure) AWSCredentialSource(providerSecret *corev1.Secret) (AWSCredentialSource, error) {
+	return (&DefaultInfraHandler{}).AWSCredentialSource(providerSecret)
+}
+
+func (h *FakeInfraHandler) AzureCredentialSource(providerSecret *corev1.Secret) (AzureCredentialSource, error) {
+	return (&DefaultInfraHandler{}).AzureCredentialSource(providerSecret)
+}
+
+func (h *FakeInfraHandlerFailure) AzureCredentialSource(providerSecret *corev1.Secret) (AzureCredentialSource, error) {
+	return (&DefaultInfraHandler{}).AzureCredentialSource(providerSecret)
+}
+
+func (h *FakeInfraHandler) AwsInfraCreator(creds AWSCredentialSource, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra {
 	return func(ctx context.Context, l logr.Logger) (*aws.CreateInfraOutput, error) {
 		return &aws.CreateInfraOutput{
 			Zones: []*aws.CreateInfraOutputZone{
@@ -159,25 +231,25 @@ func (h *FakeInfraHandler) AwsInfraCreator(awsKey, awsSecretKey, region, infraID
 	}
 }
 
-func (h *FakeInfraHandlerFailure) AwsInfraCreator(awsKey, awsSecretKey, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra {
+func (h *FakeInfraHandlerFailure) AwsInfraCreator(creds AWSCredentialSource, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra {
 	return func(ctx context.Context, l logr.Logger) (*aws.CreateInfraOutput, error) {
 		return nil, errors.New("failed to create aws infrastructure")
 	}
 }
 
-func (h *FakeInfraHandler) AwsInfraDestroyer(awsKey, awsSecretKey, region, infraID, name, baseDomain string) AwsDestroyInfra {
+func (h *FakeInfraHandler) AwsInfraDestroyer(creds AWSCredentialSource, region, infraID, name, baseDomain string) AwsDestroyInfra {
 	return func(ctx context.Context) error {
 		return nil
 	}
 }
 
-func (h *FakeInfraHandlerFailure) AwsInfraDestroyer(awsKey, awsSecretKey, region, infraID, name, baseDomain string) AwsDestroyInfra {
+func (h *FakeInfraHandlerFailure) AwsInfraDestroyer(creds AWSCredentialSource, region, infraID, name, baseDomain string) AwsDestroyInfra {
 	return func(ctx context.Context) error {
 		return errors.New("failed to destroy aws infrastructure")
 	}
 }
 
-func (h *FakeInfraHandler) AwsIAMCreator(awsKey, awsSecretKey, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM {
+func (h *FakeInfraHandler) AwsIAMCreator(creds AWSCredentialSource, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM {
 	return func(ctx context.Context, client crclient.Client) (*aws.CreateIAMOutput, error) {
 		return &aws.CreateIAMOutput{
 			IssuerURL: "https://bucket-hypershift.s3.us-east-1.amazonaws.com/hypershift-test-abcde",
@@ -194,37 +266,37 @@ func (h *FakeInfraHandler) AwsIAMCreator(awsKey, awsSecretKey, region, infraID,
 	}
 }
 
-func (h *FakeInfraHandlerFailure) AwsIAMCreator(awsKey, awsSecretKey, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM {
+func (h *FakeInfraHandlerFailure) AwsIAMCreator(creds AWSCredentialSource, region, infraID, s3BucketName, s3Region, privateZoneID, publicZoneID, localZoneID string) AwsCreateIAM {
 	return func(ctx context.Context, client crclient.Client) (*aws.CreateIAMOutput, error) {
 		return nil, errors.New("failed to create aws iam infrastructure")
 	}
 }
 
-func (h *FakeInfraHandler) AwsIAMDestroyer(awsKey, awsSecretKey, region, infraID string) AwsDestroyIAM {
+func (h *FakeInfraHandler) AwsIAMDestroyer(creds AWSCredentialSource, region, infraID string) AwsDestroyIAM {
 	return func(ctx context.Context) error {
 		return nil
 	}
 }
 
-func (h *FakeInfraHandlerFailure) AwsIAMDestroyer(awsKey, awsSecretKey, region, infraID string) AwsDestroyIAM {
+func (h *FakeInfraHandlerFailure) AwsIAMDestroyer(creds AWSCredentialSource, region, infraID string) AwsDestroyIAM {
 	return func(ctx context.Context) error {
 		return errors.New("failed to destroy aws iam infrastructure")
 	}
 }
 
-func (h *FakeInfraHandler) AzureInfraDestroyer(name, location, infraID string, credentials *fixtures.AzureCreds) AzureDestroyInfra {
+func (h *FakeInfraHandler) AzureInfraDestroyer(name, location, infraID string, creds AzureCredentialSource) AzureDestroyInfra {
 	return func(ctx context.Context) error {
 		return nil
 	}
 }
 
-func (h *FakeInfraHandlerFailure) AzureInfraDestroyer(name, location, infraID string, credentials *fixtures.AzureCreds) AzureDestroyInfra {
+func (h *FakeInfraHandlerFailure) AzureInfraDestroyer(name, location, infraID string, creds AzureCredentialSource) AzureDestroyInfra {
 	return func(ctx context.Context) error {
 		return errors.New("failed to destroy azure infrastructure")
 	}
 }
 
-func (h *FakeInfraHandler) AzureInfraCreator(name, baseDomain, location, infraID string, credentials *fixtures.AzureCreds) AzureCreateInfra {
+func (h *FakeInfraHandler) AzureInfraCreator(name, baseDomain, location, infraID string, creds AzureCredentialSource) AzureCreateInfra {
 	return func(ctx context.Context, l logr.Logger) (*azure.CreateInfraOutput, error) {
 		return &azure.CreateInfraOutput{
 			Location:          "centralus",
@@ -242,8 +314,32 @@ func (h *FakeInfraHandler) AzureInfraCreator(name, baseDomain, location, infraID
 	}
 }
 
-func (h *FakeInfraHandlerFailure) AzureInfraCreator(name, baseDomain, location, infraID string, credentials *fixtures.AzureCreds) AzureCreateInfra {
+func (h *FakeInfraHandlerFailure) AzureInfraCreator(name, baseDomain, location, infraID string, creds AzureCredentialSource) AzureCreateInfra {
 	return func(ctx context.Context, l logr.Logger) (*azure.CreateInfraOutput, error) {
 		return nil, errors.New("failed to create azure infrastructure")
 	}
 }
+
+func (h *FakeInfraHandler) AwsInfraVerifier(creds AWSCredentialSource, region string, infra *hypdeployment.AWSInfrastructureStatus) AwsVerifyInfra {
+	return func(ctx context.Context) ([]string, error) {
+		return []string{}, nil
+	}
+}
+
+func (h *FakeInfraHandlerFailure) AwsInfraVerifier(creds AWSCredentialSource, region string, infra *hypdeployment.AWSInfrastructureStatus) AwsVerifyInfra {
+	return func(ctx context.Context) ([]string, error) {
+		return nil, errors.New("failed to verify aws infrastructure")
+	}
+}
+
+func (h *FakeInfraHandler) AzureInfraVerifier(creds AzureCredentialSource, infra *hypdeployment.AzureInfrastructureStatus) AzureVerifyInfra {
+	return func(ctx context.Context) ([]string, error) {
+		return []string{}, nil
+	}
+}
+
+func (h *FakeInfraHandlerFailure) AzureInfraVerifier(creds AzureCredentialSource, infra *hypdeployment.AzureInfrastructureStatus) AzureVerifyInfra {
+	return func(ctx context.Context) ([]string, error) {
+		return nil, errors.New("failed to verify azure infrastructure")
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+const (
+	infraRetryBaseDelay = 30 * time.Second
+	infraRetryMaxDelay  = 30 * time.Minute
+)
+
+var (
+	awsAuthErrorCodes = []string{
+		"AuthFailure",
+		"UnauthorizedOperation",
+		"InvalidClientTokenId",
+		"SignatureDoesNotMatch",
+		"AccessDenied",
+		"AccessDeniedException",
+		"ExpiredToken",
+		"InvalidAccessKeyId",
+		"OptInRequired",
+	}
+
+	// Throttling shares the LimitExceeded suffix with the quota errors
+	awsThrottlingErrorCodes = []string{
+		"RequestLimitExceeded",
+		"Throttling",
+		"ThrottlingException",
+	}
+)
+
+// classifyInfraError separates the errors that need a change from the user, from the ones worth retrying
+func classifyInfraError(err error) hypdeployment.InfraErrorClass {
+	if errors.Is(err, ErrCredentialSourceUnsupported) {
+		return hypdeployment.InfraErrorAuthentication
+	}
+
+	var awsReqErr awserr.RequestFailure
+	if errors.As(err, &awsReqErr) &&
+		(awsReqErr.StatusCode() == http.StatusUnauthorized || awsReqErr.StatusCode() == http.StatusForbidden) {
+		return hypdeployment.InfraErrorAuthentication
+	}
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		code := awsErr.Code()
+		for _, c := range awsThrottlingErrorCodes {
+			if code == c {
+				return hypdeployment.InfraErrorTransient
+			}
+		}
+		for _, c := range awsAuthErrorCodes {
+			if code == c {
+				return hypdeployment.InfraErrorAuthentication
+			}
+		}
+		if strings.HasSuffix(code, "LimitExceeded") || code == "TooManyHostedZones" {
+			return hypdeployment.InfraErrorQuota
+		}
+	}
+
+	var tokenErr adal.TokenRefreshError
+	if errors.As(err, &tokenErr) {
+		return hypdeployment.InfraErrorAuthentication
+	}
+
+	var reqErr *azure.RequestError
+	if errors.As(err, &reqErr) && reqErr.ServiceError != nil && strings.Contains(reqErr.ServiceError.Code, "QuotaExceeded") {
+		return hypdeployment.InfraErrorQuota
+	}
+
+	var detailedErr autorest.DetailedError
+	if errors.As(err, &detailedErr) {
+		if code, ok := detailedErr.StatusCode.(int); ok && (code == http.StatusUnauthorized || code == http.StatusForbidden) {
+			return hypdeployment.InfraErrorAuthentication
+		}
+	}
+
+	return hypdeployment.InfraErrorTransient
+}
+
+// infraRetryDelay doubles from infraRetryBaseDelay with each attempt, up to infraRetryMaxDelay
+func infraRetryDelay(attempts int32) time.Duration {
+	delay := infraRetryBaseDelay
+	for i := int32(1); i < attempts && delay < infraRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > infraRetryMaxDelay {
+		return infraRetryMaxDelay
+	}
+	return delay
+}
+
+// infraFailed is true when the infrastructure operations are no longer retried
+func infraFailed(hyd *hypdeployment.HypershiftDeployment) bool {
+	return meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.InfrastructureFailed))
+}
+
+// waitForInfraRetry returns true while the last failed infrastructure operation must not be tried again, either
+// because its backoff has not expired or because it is no longer retried. The attempts start over when the spec or
+// the cloud provider secret changed since the last failure
+func (r *HypershiftDeploymentReconciler) waitForInfraRetry(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, bool, error) {
+	retry := hyd.Status.InfraRetry
+	if retry == nil {
+		return ctrl.Result{}, false, nil
+	}
+
+	if retry.ObservedGeneration != hyd.Generation || retry.ProviderSecretResourceVersion != providerSecret.ResourceVersion {
+		r.Log.Info("The spec or the cloud provider secret changed, retrying the infrastructure operation", "operation", retry.Operation)
+		return ctrl.Result{}, false, r.clearInfraRetry(hyd)
+	}
+
+	if infraFailed(hyd) {
+		return ctrl.Result{}, true, nil
+	}
+
+	if retry.NextRetryTime != nil {
+		if wait := time.Until(retry.NextRetryTime.Time); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait, Requeue: true}, true, nil
+		}
+	}
+	return ctrl.Result{}, false, nil
+}
+
+// retryInfraOperation records a failed infrastructure operation on the status together with the condition of the
+// operation. Transient errors are requeued with an exponential backoff, permanent errors and failures beyond
+// InfraRetryLimit set the InfrastructureFailed condition and are not requeued
+func (r *HypershiftDeploymentReconciler) retryInfraOperation(
+	hyd *hypdeployment.HypershiftDeployment,
+	providerSecret *corev1.Secret,
+	operation string,
+	conditionType hypdeployment.ConditionType,
+	reason string,
+	opErr error) (ctrl.Result, error) {
+
+	inHyd := hyd.DeepCopy()
+
+	retry := hyd.Status.InfraRetry
+	if retry == nil || retry.Operation != operation {
+		retry = &hypdeployment.InfraRetryStatus{Operation: operation}
+	}
+	retry.Attempts++
+	retry.LastErrorClass = classifyInfraError(opErr)
+	retry.LastError = opErr.Error()
+	retry.ObservedGeneration = hyd.Generation
+	retry.ProviderSecretResourceVersion = providerSecret.ResourceVersion
+	retry.NextRetryTime = nil
+	hyd.Status.InfraRetry = retry
+
+	setStatusCondition(hyd, conditionType, metav1.ConditionFalse, opErr.Error(), reason)
+
+	res := ctrl.Result{}
+	switch {
+	case retry.LastErrorClass != hypdeployment.InfraErrorTransient:
+		message := fmt.Sprintf("%s failed with a %s error, update the spec or the cloud provider secret to retry: %v", operation, retry.LastErrorClass, opErr)
+		setStatusCondition(hyd, hypdeployment.InfrastructureFailed, metav1.ConditionTrue, message, hypdeployment.PermanentErrorReason)
+		r.recordEvent(hyd, corev1.EventTypeWarning, InfraRetryStoppedEvent, "%s", message)
+	case r.InfraRetryLimit > 0 && retry.Attempts >= r.InfraRetryLimit:
+		message := fmt.Sprintf("%s failed %d times, update the spec or the cloud provider secret to retry: %v", operation, retry.Attempts, opErr)
+		setStatusCondition(hyd, hypdeployment.InfrastructureFailed, metav1.ConditionTrue, message, hypdeployment.RetryLimitReachedReason)
+		r.recordEvent(hyd, corev1.EventTypeWarning, InfraRetryStoppedEvent, "%s", message)
+	default:
+		delay := infraRetryDelay(retry.Attempts)
+		next := metav1.NewTime(time.Now().Add(delay))
+		retry.NextRetryTime = &next
+		res = ctrl.Result{RequeueAfter: delay, Requeue: true}
+	}
+
+	if err := r.Client.Status().Patch(r.ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		r.Log.Error(err, "Failed to record the infrastructure retry in HypershiftDeployment.Status")
+		return res, err
+	}
+	return res, nil
+}
+
+// clearInfraRetry forgets the failed attempts once an operation succeeds, or the user changed something
+func (r *HypershiftDeploymentReconciler) clearInfraRetry(hyd *hypdeployment.HypershiftDeployment) error {
+	if hyd.Status.InfraRetry == nil && meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.InfrastructureFailed)) == nil {
+		return nil
+	}
+
+	inHyd := hyd.DeepCopy()
+	hyd.Status.InfraRetry = nil
+	meta.RemoveStatusCondition(&hyd.Status.Conditions, string(hypdeployment.InfrastructureFailed))
+	setPhase(hyd)
+
+	if err := r.Client.Status().Patch(r.ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		r.Log.Error(err, "Failed to clear the infrastructure retry in HypershiftDeployment.Status")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/go-logr/logr"
+	"github.com/openshift/hypershift/cmd/infra/aws"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// authFailureInfraHandler fails to create the AWS infrastructure with invalid credentials
+type authFailureInfraHandler struct {
+	FakeInfraHandler
+}
+
+func (h *authFailureInfraHandler) AwsInfraCreator(creds AWSCredentialSource, region, infraID, name, baseDomain string, zones []string) AwsCreateInfra {
+	return func(ctx context.Context, l logr.Logger) (*aws.CreateInfraOutput, error) {
+		return nil, fmt.Errorf("failed to create vpc: %w", awserr.New("AuthFailure", "AWS was not able to validate the provided access credentials", nil))
+	}
+}
+
+func getRetryAWSHD(t *testing.T, r *HypershiftDeploymentReconciler) *hypdeployment.HypershiftDeployment {
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Infrastructure.Configure = true
+	testHD.Spec.Infrastructure.Platform.AWS.Region = "us-east-1"
+	assert.Nil(t, r.Client.Create(context.Background(), testHD))
+	return testHD
+}
+
+func TestClassifyInfraError(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected hypdeployment.InfraErrorClass
+	}{
+		{errors.New("connection reset by peer"), hypdeployment.InfraErrorTransient},
+		{awserr.New("AuthFailure", "", nil), hypdeployment.InfraErrorAuthentication},
+		{fmt.Errorf("failed to create role: %w", awserr.New("AccessDenied", "", nil)), hypdeployment.InfraErrorAuthentication},
+		{awserr.New("VpcLimitExceeded", "", nil), hypdeployment.InfraErrorQuota},
+		{awserr.New("RequestLimitExceeded", "", nil), hypdeployment.InfraErrorTransient},
+		{awserr.NewRequestFailure(awserr.New("Unknown", "", nil), http.StatusForbidden, ""), hypdeployment.InfraErrorAuthentication},
+		{autorest.DetailedError{StatusCode: http.StatusUnauthorized}, hypdeployment.InfraErrorAuthentication},
+		{autorest.DetailedError{StatusCode: http.StatusInternalServerError}, hypdeployment.InfraErrorTransient},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, classifyInfraError(c.err), "class of %v", c.err)
+	}
+}
+
+func TestInfraRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, infraRetryDelay(1))
+	assert.Equal(t, time.Minute, infraRetryDelay(2))
+	assert.Equal(t, 8*time.Minute, infraRetryDelay(5))
+	assert.Equal(t, 30*time.Minute, infraRetryDelay(20), "the delay is capped")
+}
+
+func TestInfraRetryLimit(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	r.InfraHandler = &FakeInfraHandlerFailure{}
+	r.InfraRetryLimit = 2
+	testHD := getRetryAWSHD(t, r)
+	providerSecret := getProviderSecret()
+
+	res, err := r.createAWSInfra(testHD, providerSecret)
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, res.RequeueAfter, "the first retry uses the base delay")
+
+	retry := testHD.Status.InfraRetry
+	assert.NotNil(t, retry)
+	assert.Equal(t, int32(1), retry.Attempts)
+	assert.Equal(t, "create-infra", retry.Operation)
+	assert.Equal(t, hypdeployment.InfraErrorTransient, retry.LastErrorClass)
+	assert.NotNil(t, retry.NextRetryTime)
+
+	res, wait, err := r.waitForInfraRetry(testHD, providerSecret)
+	assert.Nil(t, err)
+	assert.True(t, wait, "the operation waits for the backoff")
+	assert.True(t, res.RequeueAfter > 0)
+
+	res, err = r.createAWSInfra(testHD, providerSecret)
+	assert.Nil(t, err)
+	assert.True(t, res.IsZero(), "not requeued once the limit is reached")
+	assert.Equal(t, int32(2), testHD.Status.InfraRetry.Attempts)
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hypdeployment.InfrastructureFailed))
+	assert.NotNil(t, c)
+	assert.Equal(t, hypdeployment.RetryLimitReachedReason, c.Reason)
+	assert.Equal(t, hypdeployment.PhaseFailed, testHD.Status.Phase)
+
+	_, wait, err = r.waitForInfraRetry(testHD, providerSecret)
+	assert.Nil(t, err)
+	assert.True(t, wait, "the operation is not retried")
+
+	t.Log("The attempts start over when the cloud provider secret changes")
+	providerSecret.ResourceVersion = "2"
+	_, wait, err = r.waitForInfraRetry(testHD, providerSecret)
+	assert.Nil(t, err)
+	assert.False(t, wait)
+	assert.Nil(t, testHD.Status.InfraRetry)
+	assert.False(t, infraFailed(testHD))
+
+	r.InfraHandler = &FakeInfraHandler{}
+	assert.Nil(t, r.Client.Create(context.Background(), getS3Secret("local-cluster")))
+	_, err = r.createAWSInfra(testHD, providerSecret)
+	assert.Nil(t, err)
+	assert.True(t, infraConfigured(testHD))
+	assert.Nil(t, testHD.Status.InfraRetry)
+}
+
+func TestInfraRetryPermanentError(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	r.InfraHandler = &authFailureInfraHandler{}
+	testHD := getRetryAWSHD(t, r)
+
+	res, err := r.createAWSInfra(testHD, getProviderSecret())
+	assert.Nil(t, err)
+	assert.True(t, res.IsZero(), "authentication errors are not retried")
+	assert.Equal(t, hypdeployment.InfraErrorAuthentication, testHD.Status.InfraRetry.LastErrorClass)
+
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hypdeployment.InfrastructureFailed))
+	assert.NotNil(t, c)
+	assert.Equal(t, hypdeployment.PermanentErrorReason, c.Reason)
+}
+
+func TestDestroyAWSInfraRetryLimit(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	r.InfraHandler = &FakeInfraHandlerFailure{}
+	r.InfraRetryLimit = 1
+	testHD := getRetryAWSHD(t, r)
+
+	res, err := r.destroyAWSInfrastructure(testHD, getProviderSecret())
+	assert.Nil(t, err)
+	assert.False(t, res.Requeue)
+	assert.True(t, infraFailed(testHD), "the destroy is no longer retried")
+	assert.Equal(t, "destroy-infra", testHD.Status.InfraRetry.Operation)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/openshift/hypershift/cmd/infra/aws"
+	"github.com/openshift/hypershift/cmd/infra/azure"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// updateInfrastructureStatus records the cloud resource IDs on the status subresource, before they are used to
+// scaffold the spec, so they survive a failed spec patch and are available to destroy the infrastructure
+func (r *HypershiftDeploymentReconciler) updateInfrastructureStatus(hyd *hypdeployment.HypershiftDeployment, mutate func(*hypdeployment.InfrastructureStatus)) error {
+	inHyd := hyd.DeepCopy()
+
+	if hyd.Status.Infrastructure == nil {
+		hyd.Status.Infrastructure = &hypdeployment.InfrastructureStatus{}
+	}
+	mutate(hyd.Status.Infrastructure)
+
+	if err := r.Client.Status().Patch(r.ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		r.Log.Error(err, "Failed to record the infrastructure in HypershiftDeployment.Status")
+		return err
+	}
+	return nil
+}
+
+func awsInfrastructureStatus(infraOut *aws.CreateInfraOutput) *hypdeployment.AWSInfrastructureStatus {
+	status := &hypdeployment.AWSInfrastructureStatus{
+		Region:          infraOut.Region,
+		VPCID:           infraOut.VPCID,
+		MachineCIDR:     infraOut.MachineCIDR,
+		SecurityGroupID: infraOut.SecurityGroupID,
+		BaseDomain:      infraOut.BaseDomain,
+		PublicZoneID:    infraOut.PublicZoneID,
+		PrivateZoneID:   infraOut.PrivateZoneID,
+		LocalZoneID:     infraOut.LocalZoneID,
+		ProxyAddr:       infraOut.ProxyAddr,
+	}
+	for _, zone := range infraOut.Zones {
+		status.Zones = append(status.Zones, hypdeployment.AWSZoneStatus{Name: zone.Name, SubnetID: zone.SubnetID})
+	}
+	return status
+}
+
+func awsIAMStatus(iamOut *aws.CreateIAMOutput) *hypdeployment.AWSIAMStatus {
+	return &hypdeployment.AWSIAMStatus{
+		IssuerURL:          iamOut.IssuerURL,
+		ProfileName:        iamOut.ProfileName,
+		Roles:              iamOut.Roles,
+		KMSKeyARN:          iamOut.KMSKeyARN,
+		KMSProviderRoleARN: iamOut.KMSProviderRoleARN,
+	}
+}
+
+func azureInfrastructureStatus(infraOut *azure.CreateInfraOutput) *hypdeployment.AzureInfrastructureStatus {
+	return &hypdeployment.AzureInfrastructureStatus{
+		Location:          infraOut.Location,
+		ResourceGroupName: infraOut.ResourceGroupName,
+		VNetID:            infraOut.VNetID,
+		VnetName:          infraOut.VnetName,
+		SubnetName:        infraOut.SubnetName,
+		SecurityGroupName: infraOut.SecurityGroupName,
+		MachineIdentityID: infraOut.MachineIdentityID,
+		BootImageID:       infraOut.BootImageID,
+		BaseDomain:        infraOut.BaseDomain,
+		PublicZoneID:      infraOut.PublicZoneID,
+		PrivateZoneID:     infraOut.PrivateZoneID,
+	}
+}
+
+// awsDestroyBaseDomain prefers the base domain the infrastructure was created with, the cloud provider
+// secret may have been edited since
+func awsDestroyBaseDomain(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) string {
+	if infra := hyd.Status.Infrastructure; infra != nil && infra.AWS != nil && infra.AWS.BaseDomain != "" {
+		return infra.AWS.BaseDomain
+	}
+	return string(providerSecret.Data["baseDomain"])
+}
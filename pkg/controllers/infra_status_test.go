@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+func TestAWSInfrastructureStatus(t *testing.T) {
+	ctx := context.Background()
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Infrastructure.Platform.AWS.Region = "us-east-1"
+
+	r := GetHypershiftDeploymentReconciler()
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+	assert.Nil(t, r.Client.Create(ctx, getS3Secret("local-cluster")))
+
+	r.InfraHandler = &FakeInfraHandler{}
+	_, err := r.createAWSInfra(testHD, getProviderSecret())
+	assert.Nil(t, err)
+
+	resultHD := &hypdeployment.HypershiftDeployment{}
+	assert.Nil(t, r.Client.Get(ctx, types.NamespacedName{Namespace: testHD.Namespace, Name: testHD.Name}, resultHD))
+
+	infra := resultHD.Status.Infrastructure
+	assert.NotNil(t, infra, "the infrastructure is recorded in the status")
+	assert.NotNil(t, infra.AWS)
+	assert.Equal(t, "vpc-abcdefg0123456789", infra.AWS.VPCID)
+	assert.Equal(t, "a.b.c", infra.AWS.BaseDomain)
+	assert.Equal(t, []hypdeployment.AWSZoneStatus{
+		{Name: "us-east-1a", SubnetID: "subnet-0123456789abcdefg"},
+		{Name: "us-east-1b", SubnetID: "subnet-00000011111222233"},
+	}, infra.AWS.Zones)
+	assert.NotNil(t, infra.AWS.IAM, "the IAM outputs are recorded in the status")
+	assert.Equal(t, "https://bucket-hypershift.s3.us-east-1.amazonaws.com/hypershift-test-abcde", infra.AWS.IAM.IssuerURL)
+	assert.Equal(t, resultHD.Spec.HostedClusterSpec.Platform.AWS.RolesRef, infra.AWS.IAM.Roles,
+		"the HostedCluster roles match the recorded roles")
+
+	assert.Equal(t, "a.b.c", awsDestroyBaseDomain(resultHD, getProviderSecret()),
+		"the recorded base domain is used to destroy the infrastructure")
+}
+
+func TestAzureInfrastructureStatus(t *testing.T) {
+	ctx := context.Background()
+	testHD := getFakeAzureHD()
+	testHD.Spec.Infrastructure.Platform.Azure.Location = "centralus"
+
+	r := GetHypershiftDeploymentReconciler()
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+
+	r.InfraHandler = &FakeInfraHandler{}
+	_, err := r.createAzureInfra(testHD, getProviderSecret())
+	assert.Nil(t, err)
+
+	infra := testHD.Status.Infrastructure
+	assert.NotNil(t, infra, "the infrastructure is recorded in the status")
+	assert.NotNil(t, infra.Azure)
+	assert.Equal(t, "centralus", infra.Azure.Location)
+	assert.Equal(t, "hypershift-test-hypershift-test-abcde", infra.Azure.ResourceGroupName)
+	assert.Equal(t, infra.Azure.SubnetName, testHD.Spec.HostedClusterSpec.Platform.Azure.SubnetName)
+}
+
+func TestAWSDestroyBaseDomainWithoutStatus(t *testing.T) {
+	testHD := getHDforManifestWork()
+	secret := getProviderSecret()
+	secret.Data["baseDomain"] = []byte("my-domain.com")
+
+	assert.Equal(t, "my-domain.com", awsDestroyBaseDomain(testHD, secret),
+		"the provider secret is used when the infrastructure was not recorded")
+}
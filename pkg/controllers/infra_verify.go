@@ -0,0 +1,224 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/msi/mgmt/2018-11-30/msi"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-05-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/privatedns/mgmt/2018-09-01/privatedns"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2020-10-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/route53"
+	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// AwsInfraVerifier looks up every resource recorded in the AWSInfrastructureStatus, it returns the missing ones.
+// Any other error from the provider fails the verification, so a throttled call is never reported as drift
+func (h *DefaultInfraHandler) AwsInfraVerifier(creds AWSCredentialSource, region string, infra *hypdeployment.AWSInfrastructureStatus) AwsVerifyInfra {
+	return func(ctx context.Context) ([]string, error) {
+		key, secretKey, credentialsFile, cleanup, err := awsCredentialOptions(ctx, creds, region)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+
+		awsSession := awsutil.NewSession("hypershift-deployment-verify", credentialsFile, key, secretKey, region)
+		ec2Client := ec2.New(awsSession, awsutil.NewConfig())
+		route53Client := route53.New(awsSession, awsutil.NewAWSRoute53Config())
+		iamClient := iam.New(awsSession, awsutil.NewConfig())
+
+		missing := []string{}
+		check := func(resource string, err error, notFoundCodes ...string) error {
+			if err == nil {
+				return nil
+			}
+			var awsErr awserr.Error
+			if errors.As(err, &awsErr) {
+				for _, code := range notFoundCodes {
+					if awsErr.Code() == code {
+						missing = append(missing, resource)
+						return nil
+					}
+				}
+			}
+			return fmt.Errorf("failed to verify %s: %w", resource, err)
+		}
+
+		if infra.VPCID != "" {
+			_, err := ec2Client.DescribeVpcsWithContext(ctx, &ec2.DescribeVpcsInput{VpcIds: []*string{awssdk.String(infra.VPCID)}})
+			if err := check("vpc "+infra.VPCID, err, "InvalidVpcID.NotFound"); err != nil {
+				return nil, err
+			}
+		}
+		for _, zone := range infra.Zones {
+			_, err := ec2Client.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{SubnetIds: []*string{awssdk.String(zone.SubnetID)}})
+			if err := check("subnet "+zone.SubnetID, err, "InvalidSubnetID.NotFound"); err != nil {
+				return nil, err
+			}
+		}
+		if infra.SecurityGroupID != "" {
+			_, err := ec2Client.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: []*string{awssdk.String(infra.SecurityGroupID)}})
+			if err := check("security group "+infra.SecurityGroupID, err, "InvalidGroup.NotFound", "InvalidGroupId.NotFound"); err != nil {
+				return nil, err
+			}
+		}
+		for _, zoneID := range []string{infra.PrivateZoneID, infra.LocalZoneID} {
+			if zoneID == "" {
+				continue
+			}
+			_, err := route53Client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: awssdk.String(zoneID)})
+			if err := check("hosted zone "+zoneID, err, route53.ErrCodeNoSuchHostedZone); err != nil {
+				return nil, err
+			}
+		}
+
+		if infra.IAM == nil {
+			return missing, nil
+		}
+		if infra.IAM.ProfileName != "" {
+			_, err := iamClient.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: awssdk.String(infra.IAM.ProfileName)})
+			if err := check("instance profile "+infra.IAM.ProfileName, err, iam.ErrCodeNoSuchEntityException); err != nil {
+				return nil, err
+			}
+		}
+		for _, arn := range awsRoleARNs(infra.IAM.Roles) {
+			_, err := iamClient.GetRoleWithContext(ctx, &iam.GetRoleInput{RoleName: awssdk.String(lastSegment(arn))})
+			if err := check("role "+arn, err, iam.ErrCodeNoSuchEntityException); err != nil {
+				return nil, err
+			}
+		}
+
+		return missing, nil
+	}
+}
+
+// AzureInfraVerifier looks up every resource recorded in the AzureInfrastructureStatus, it returns the missing ones
+func (h *DefaultInfraHandler) AzureInfraVerifier(creds AzureCredentialSource, infra *hypdeployment.AzureInfrastructureStatus) AzureVerifyInfra {
+	return func(ctx context.Context) ([]string, error) {
+		authorizer, err := creds.Authorizer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get azure authorizer: %w", err)
+		}
+
+		subscriptionID := creds.SubscriptionID()
+
+		missing := []string{}
+		check := func(resource string, err error) error {
+			if err == nil {
+				return nil
+			}
+			var detailedErr autorest.DetailedError
+			if errors.As(err, &detailedErr) && detailedErr.StatusCode == http.StatusNotFound {
+				missing = append(missing, resource)
+				return nil
+			}
+			return fmt.Errorf("failed to verify %s: %w", resource, err)
+		}
+
+		rg := infra.ResourceGroupName
+		groupsClient := resources.NewGroupsClient(subscriptionID)
+		groupsClient.Authorizer = authorizer
+		_, err = groupsClient.Get(ctx, rg)
+		if err := check("resource group "+rg, err); err != nil {
+			return nil, err
+		}
+		// Everything else lives in the resource group
+		if len(missing) != 0 {
+			return missing, nil
+		}
+
+		if infra.VnetName != "" {
+			vnetClient := network.NewVirtualNetworksClient(subscriptionID)
+			vnetClient.Authorizer = authorizer
+			_, err = vnetClient.Get(ctx, rg, infra.VnetName, "")
+			if err := check("vnet "+infra.VnetName, err); err != nil {
+				return nil, err
+			}
+
+			if infra.SubnetName != "" {
+				subnetsClient := network.NewSubnetsClient(subscriptionID)
+				subnetsClient.Authorizer = authorizer
+				_, err = subnetsClient.Get(ctx, rg, infra.VnetName, infra.SubnetName, "")
+				if err := check("subnet "+infra.SubnetName, err); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if infra.SecurityGroupName != "" {
+			securityGroupClient := network.NewSecurityGroupsClient(subscriptionID)
+			securityGroupClient.Authorizer = authorizer
+			_, err = securityGroupClient.Get(ctx, rg, infra.SecurityGroupName, "")
+			if err := check("network security group "+infra.SecurityGroupName, err); err != nil {
+				return nil, err
+			}
+		}
+		if infra.MachineIdentityID != "" {
+			identityClient := msi.NewUserAssignedIdentitiesClient(subscriptionID)
+			identityClient.Authorizer = authorizer
+			_, err = identityClient.Get(ctx, rg, lastSegment(infra.MachineIdentityID))
+			if err := check("managed identity "+lastSegment(infra.MachineIdentityID), err); err != nil {
+				return nil, err
+			}
+		}
+		if infra.PrivateZoneID != "" {
+			privateZoneClient := privatedns.NewPrivateZonesClient(subscriptionID)
+			privateZoneClient.Authorizer = authorizer
+			_, err = privateZoneClient.Get(ctx, rg, lastSegment(infra.PrivateZoneID))
+			if err := check("private dns zone "+lastSegment(infra.PrivateZoneID), err); err != nil {
+				return nil, err
+			}
+		}
+
+		return missing, nil
+	}
+}
+
+func awsRoleARNs(roles hyperv1.AWSRolesRef) []string {
+	arns := []string{}
+	for _, arn := range []string{
+		roles.IngressARN,
+		roles.ImageRegistryARN,
+		roles.StorageARN,
+		roles.NetworkARN,
+		roles.KubeCloudControllerARN,
+		roles.NodePoolManagementARN,
+		roles.ControlPlaneOperatorARN,
+	} {
+		if arn != "" {
+			arns = append(arns, arn)
+		}
+	}
+	return arns
+}
+
+// lastSegment returns the name at the end of an AWS ARN or an Azure resource ID
+func lastSegment(id string) string {
+	return id[strings.LastIndex(id, "/")+1:]
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// KubeVirt and Agent clusters run on capacity that already exists on (or next to) the hosting cluster,
+// there is no cloud infrastructure or IAM to create, only the HostedCluster and NodePool specs to scaffold.
+
+func (r *HypershiftDeploymentReconciler) createKubeVirtInfra(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, error) {
+	baseDomain := hyd.Spec.Infrastructure.Platform.KubeVirt.BaseDomain
+	if baseDomain == "" {
+		baseDomain = string(providerSecret.Data["baseDomain"])
+	}
+
+	return r.scaffoldPlatform(hyd, "KubeVirt", func() {
+		ScaffoldKubeVirtHostedClusterSpec(hyd, baseDomain)
+		ScaffoldKubeVirtNodePoolSpec(hyd)
+	})
+}
+
+func (r *HypershiftDeploymentReconciler) createAgentInfra(hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, error) {
+	if hyd.Spec.Infrastructure.Platform.Agent.AgentNamespace == "" {
+		return ctrl.Result{}, r.updateMissingInfrastructureParameterCondition(hyd, "Missing value HypershiftDeployment.Spec.Infrastructure.Platform.Agent.AgentNamespace")
+	}
+	if hyd.Spec.Infrastructure.Platform.Agent.APIServerAddress == "" {
+		return ctrl.Result{}, r.updateMissingInfrastructureParameterCondition(hyd, "Missing value HypershiftDeployment.Spec.Infrastructure.Platform.Agent.APIServerAddress")
+	}
+
+	baseDomain := hyd.Spec.Infrastructure.Platform.Agent.BaseDomain
+	if baseDomain == "" {
+		baseDomain = string(providerSecret.Data["baseDomain"])
+	}
+
+	return r.scaffoldPlatform(hyd, "Agent", func() {
+		ScaffoldAgentHostedClusterSpec(hyd, baseDomain)
+		ScaffoldAgentNodePoolSpec(hyd)
+	})
+}
+
+// scaffoldPlatform fills in the HostedCluster and NodePool specs once, for platforms without infrastructure
+func (r *HypershiftDeploymentReconciler) scaffoldPlatform(hyd *hypdeployment.HypershiftDeployment, platform string, scaffold func()) (ctrl.Result, error) {
+	log := r.Log
+
+	if meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.PlatformConfigured)) {
+		return ctrl.Result{}, nil
+	}
+
+	log.Info("Configuring the " + platform + " platform for the HostedCluster & NodePools")
+	setStatusCondition(hyd, hypdeployment.PlatformIAMConfigured, metav1.ConditionTrue, "Platform IAM with infra-id: "+hyd.Spec.InfraID, hypdeployment.NotApplicableReason)
+	_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, "Configuring platform with infra-id: "+hyd.Spec.InfraID, hypdeployment.BeingConfiguredReason)
+
+	if err := r.ensureReleaseImage(hyd); err != nil {
+		log.Error(err, "Could not resolve the release image")
+
+		return ctrl.Result{RequeueAfter: 1 * time.Minute, Requeue: true},
+			r.updateStatusConditionsOnChange(
+				hyd, hypdeployment.PlatformConfigured,
+				metav1.ConditionFalse,
+				err.Error(),
+				hypdeployment.MisConfiguredReason)
+	}
+
+	scaffold()
+
+	if err := r.patchHypershiftDeploymentResource(hyd); err != nil {
+		_ = r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionFalse, err.Error(), hypdeployment.MisConfiguredReason)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.updateStatusConditionsOnChange(hyd, hypdeployment.PlatformConfigured, metav1.ConditionTrue, "", hypdeployment.ConfiguredAsExpectedReason); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info("Platform configured")
+	r.recordEvent(hyd, corev1.EventTypeNormal, InfraCreatedEvent, "Configured %s platform with infra-id: %s", platform, hyd.Spec.InfraID)
+
+	return ctrl.Result{}, nil
+}
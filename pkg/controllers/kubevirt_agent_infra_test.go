@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+func getKubeVirtHD() *hypdeployment.HypershiftDeployment {
+	testHD := getHypershiftDeployment("default", "test1", true)
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.InfraID = "test1-abcde"
+	testHD.Spec.Infrastructure.Platform = &hypdeployment.Platforms{KubeVirt: &hypdeployment.KubeVirtPlatform{BaseDomain: "my-domain.com"}}
+	return testHD
+}
+
+func getAgentHD() *hypdeployment.HypershiftDeployment {
+	testHD := getHypershiftDeployment("default", "test1", true)
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.InfraID = "test1-abcde"
+	testHD.Spec.Infrastructure.Platform = &hypdeployment.Platforms{Agent: &hypdeployment.AgentPlatform{
+		AgentNamespace:   "agents",
+		APIServerAddress: "10.0.0.10",
+		AgentLabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"cluster": "test1"},
+		},
+	}}
+	return testHD
+}
+
+func TestCreateKubeVirtInfra(t *testing.T) {
+	ctx := context.Background()
+	testHD := getKubeVirtHD()
+	r := GetHypershiftDeploymentReconciler()
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+
+	_, err := r.createKubeVirtInfra(testHD, getProviderSecret())
+	assert.Nil(t, err, "nil, when the platform is configured")
+
+	assert.True(t, meta.IsStatusConditionTrue(testHD.Status.Conditions, string(hypdeployment.PlatformConfigured)), "true, when the platform is configured")
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hypdeployment.PlatformIAMConfigured))
+	assert.Equal(t, hypdeployment.NotApplicableReason, c.Reason, "there is no IAM to configure")
+
+	hcSpec := testHD.Spec.HostedClusterSpec
+	assert.Equal(t, hyp.KubevirtPlatform, hcSpec.Platform.Type)
+	assert.Equal(t, "my-domain.com", hcSpec.DNS.BaseDomain)
+	assert.Equal(t, defaultServices(), hcSpec.Services, "services use the defaults without an apiServerAddress")
+
+	assert.Len(t, testHD.Spec.NodePools, 1)
+	np := testHD.Spec.NodePools[0]
+	assert.Equal(t, hyp.KubevirtPlatform, np.Spec.Platform.Type)
+	assert.Equal(t, resource.MustParse("4Gi"), *np.Spec.Platform.Kubevirt.Compute.Memory)
+	assert.Equal(t, uint32(2), *np.Spec.Platform.Kubevirt.Compute.Cores)
+	assert.Equal(t, resource.MustParse("16Gi"), *np.Spec.Platform.Kubevirt.RootVolume.Persistent.Size)
+	assert.Nil(t, np.Spec.Platform.Kubevirt.RootVolume.Image, "the release image is used without a containerDiskImage")
+}
+
+func TestScaffoldKubeVirtNodePort(t *testing.T) {
+	testHD := getKubeVirtHD()
+	cores := uint32(4)
+	testHD.Spec.Infrastructure.Platform.KubeVirt.APIServerAddress = "10.0.0.10"
+	testHD.Spec.Infrastructure.Platform.KubeVirt.Cores = &cores
+	testHD.Spec.Infrastructure.Platform.KubeVirt.RootVolumeStorageClass = "fast"
+	testHD.Spec.Infrastructure.Platform.KubeVirt.ContainerDiskImage = "quay.io/containerdisks/rhcos:4.11"
+
+	ScaffoldKubeVirtHostedClusterSpec(testHD, "my-domain.com")
+	ScaffoldKubeVirtNodePoolSpec(testHD)
+
+	for _, s := range testHD.Spec.HostedClusterSpec.Services {
+		assert.Equal(t, "10.0.0.10", s.NodePort.Address, "service %s is published on the apiServerAddress", s.Service)
+	}
+
+	kv := testHD.Spec.NodePools[0].Spec.Platform.Kubevirt
+	assert.Equal(t, uint32(4), *kv.Compute.Cores)
+	assert.Equal(t, "fast", *kv.RootVolume.Persistent.StorageClass)
+	assert.Equal(t, "quay.io/containerdisks/rhcos:4.11", *kv.RootVolume.Image.ContainerDiskImage)
+}
+
+func TestCreateAgentInfra(t *testing.T) {
+	ctx := context.Background()
+	testHD := getAgentHD()
+	testHD.Spec.Infrastructure.Platform.Agent.AgentNamespace = ""
+	r := GetHypershiftDeploymentReconciler()
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+
+	t.Log("Test missing: Spec.Infrastructure.Platform.Agent.AgentNamespace")
+	_, err := r.createAgentInfra(testHD, getProviderSecret())
+	assert.Nil(t, err, "nil, when problem condition is written correctly")
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hypdeployment.PlatformConfigured))
+	assert.Equal(t, hypdeployment.MisConfiguredReason, c.Reason, "mis-configured when missing the agent namespace")
+
+	t.Log("Test with: Spec.Infrastructure.Platform.Agent.AgentNamespace")
+	r = GetHypershiftDeploymentReconciler()
+	testHD = getAgentHD()
+	assert.Nil(t, r.Client.Create(ctx, testHD))
+	_, err = r.createAgentInfra(testHD, getProviderSecret())
+	assert.Nil(t, err, "nil, when the platform is configured")
+	assert.True(t, meta.IsStatusConditionTrue(testHD.Status.Conditions, string(hypdeployment.PlatformConfigured)), "true, when the platform is configured")
+
+	hcSpec := testHD.Spec.HostedClusterSpec
+	assert.Equal(t, hyp.AgentPlatform, hcSpec.Platform.Type)
+	assert.Equal(t, "agents", hcSpec.Platform.Agent.AgentNamespace)
+	for _, s := range hcSpec.Services {
+		assert.Equal(t, "10.0.0.10", s.NodePort.Address, "service %s is published on the apiServerAddress", s.Service)
+	}
+
+	np := testHD.Spec.NodePools[0]
+	assert.Equal(t, hyp.AgentPlatform, np.Spec.Platform.Type)
+	assert.Equal(t, "test1", np.Spec.Platform.Agent.AgentLabelSelector.MatchLabels["cluster"])
+}
+
+func TestAgentManifestWorkPayload(t *testing.T) {
+	testHD := getAgentHD()
+	ScaffoldAgentHostedClusterSpec(testHD, "my-domain.com")
+
+	hc := &hyp.HostedCluster{
+		TypeMeta:   metav1.TypeMeta{Kind: "HostedCluster", APIVersion: hyp.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: testHD.Name},
+		Spec:       *testHD.Spec.HostedClusterSpec,
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(hc)
+	assert.Nil(t, err)
+	payload := []workv1.Manifest{{RawExtension: runtime.RawExtension{Object: &unstructured.Unstructured{Object: content}}}}
+
+	assert.Nil(t, appendAgentCAPIProviderRole(testHD, &payload))
+	assert.Len(t, payload, 2, "the capi-provider-role is added for Agent HostedClusters")
+
+	mw := &workv1.ManifestWork{}
+	setManifestWorkSelectivelyDeleteOption(mw, testHD)
+	assert.Contains(t, mw.Spec.DeleteOption.SelectivelyOrphan.OrphaningRules, workv1.OrphaningRule{
+		Group:     "rbac.authorization.k8s.io",
+		Resource:  "roles",
+		Namespace: "agents",
+		Name:      agentCAPIProviderRoleName,
+	}, "the shared role is orphaned when the manifestwork is deleted")
+}
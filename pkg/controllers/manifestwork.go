@@ -22,10 +22,13 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	hyp "github.com/openshift/hypershift/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	condmeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,6 +48,7 @@ import (
 	hydclient "github.com/stolostron/hypershift-deployment-controller/pkg/client"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/metrics"
 )
 
 // variables defined for manifestwork status sync
@@ -55,7 +59,15 @@ var (
 	StatusFlag            = "status"
 	Message               = "message"
 	Progress              = "progress"
+	DesiredImage          = "desiredImage"
+	Version               = "version"
 	OwnerReference        = "owner"
+	Replicas              = "replicas"
+	AutoScalingEnabled    = "autoScalingEnabled"
+	Kubeconfig            = "kubeconfig"
+	KubeadminPassword     = "kubeadminPassword"
+	APIHost               = "apiHost"
+	APIPort               = "apiPort"
 )
 
 //loadManifest will get hostedclsuter's crs and put them to the manifest array
@@ -108,12 +120,14 @@ func scaffoldManifestwork(hyd *hypdeployment.HypershiftDeployment) (*workv1.Mani
 
 func setManifestWorkSelectivelyDeleteOption(mw *workv1.ManifestWork, hyd *hypdeployment.HypershiftDeployment) {
 	hostingNamespace := helper.GetHostingNamespace(hyd)
+	policy := hyd.Spec.GetDeletionPolicy()
 
-	if hyd.Spec.Override == hypdeployment.InfraOverrideDestroy {
+	// Deleting the hosting namespace would delete an orphaned HostedCluster, the webhook rejects this policy
+	if policy.HostedCluster == hypdeployment.DeletionPolicyOrphan {
 		mw.Spec.DeleteOption = &workv1.DeleteOption{
 			PropagationPolicy: workv1.DeletePropagationPolicyTypeOrphan,
 		}
-	} else if hyd.Spec.Override == hypdeployment.DeleteHostingNamespace {
+	} else if policy.HostingNamespace == hypdeployment.DeletionPolicyDelete {
 		mw.Spec.DeleteOption = &workv1.DeleteOption{
 			PropagationPolicy: workv1.DeletePropagationPolicyTypeForeground,
 		}
@@ -129,6 +143,17 @@ func setManifestWorkSelectivelyDeleteOption(mw *workv1.ManifestWork, hyd *hypdep
 				},
 			},
 		}
+
+		// The capi-provider-role is shared by every HostedCluster using the same agent namespace
+		if hyd.Spec.HostedClusterSpec != nil && hyd.Spec.HostedClusterSpec.Platform.Agent != nil {
+			mw.Spec.DeleteOption.SelectivelyOrphan.OrphaningRules = append(mw.Spec.DeleteOption.SelectivelyOrphan.OrphaningRules,
+				workv1.OrphaningRule{
+					Group:     rbacv1.GroupName,
+					Resource:  "roles",
+					Namespace: hyd.Spec.HostedClusterSpec.Platform.Agent.AgentNamespace,
+					Name:      agentCAPIProviderRoleName,
+				})
+		}
 	}
 }
 
@@ -151,6 +176,9 @@ func syncManifestworkStatusToHypershiftDeployment(
 	feedback := getStatusFeedbackAsCondition(work, hyd)
 	conds = append(conds, feedback...)
 
+	syncHostedClusterFeedback(hyd, work)
+	hyd.Status.NodePools = getNodePoolStatus(work, hyd)
+
 	for _, cond := range conds {
 		setStatusCondition(
 			hyd,
@@ -162,17 +190,35 @@ func syncManifestworkStatusToHypershiftDeployment(
 	}
 }
 
-func (r *HypershiftDeploymentReconciler) validateHostedClusterAndNodePool(ctx context.Context, hcName string, hcSpec hyp.HostedClusterSpec, npSpec hyp.NodePoolSpec) error {
+var (
+	errPlatformTypeMismatch = errors.New("Platform.Type value mismatch")
+	errNodePoolClusterName  = errors.New("incorrect Spec.ClusterName in NodePool")
+)
+
+// checkHostedClusterAndNodePool is shared by the reconciler and the admission webhook
+func checkHostedClusterAndNodePool(hcName string, hcSpec hyp.HostedClusterSpec, npSpec hyp.NodePoolSpec) error {
 	// Platform.Type in NodePool matches the HostedCluster
 	if npSpec.Platform.Type != hcSpec.Platform.Type {
-		r.Log.Error(errors.New("Platform.Type value mismatch"), "Platform.Type in node pool(s) does not match value in HostedClusterSpec")
-		return errors.New("Platform.Type value mismatch")
+		return errPlatformTypeMismatch
 	}
 
 	// NodePool references the correct hostedCluster
 	if npSpec.ClusterName != hcName {
-		r.Log.Error(errors.New("incorrect Spec.ClusterName in NodePool"), "Spec.ClusterName in NodePool needs to match the referenced hostedCluster")
-		return errors.New("incorrect Spec.ClusterName in NodePool")
+		return errNodePoolClusterName
+	}
+
+	return nil
+}
+
+func (r *HypershiftDeploymentReconciler) validateHostedClusterAndNodePool(ctx context.Context, hcName string, hcSpec hyp.HostedClusterSpec, npSpec hyp.NodePoolSpec) error {
+	switch err := checkHostedClusterAndNodePool(hcName, hcSpec, npSpec); err {
+	case nil:
+	case errPlatformTypeMismatch:
+		r.Log.Error(err, "Platform.Type in node pool(s) does not match value in HostedClusterSpec")
+		return err
+	default:
+		r.Log.Error(err, "Spec.ClusterName in NodePool needs to match the referenced hostedCluster")
+		return err
 	}
 
 	// Release.Image in NodePool matches the HostedCluster
@@ -239,17 +285,18 @@ func (r *HypershiftDeploymentReconciler) validateSecurityConstraints(ctx context
 	}
 
 	// Check the managed cluster exists
+	hostingCluster := helper.GetHostingClusterName(hyd)
 	var managedCluster clusterv1.ManagedCluster
-	err = r.Get(ctx, types.NamespacedName{Name: hyd.Spec.HostingCluster}, &managedCluster)
+	err = r.Get(ctx, types.NamespacedName{Name: hostingCluster}, &managedCluster)
 	switch {
 	case apierrors.IsNotFound(err):
-		r.Log.Error(err, "fail to find ManagedCluster: "+hyd.Spec.HostingCluster)
+		r.Log.Error(err, "fail to find ManagedCluster: "+hostingCluster)
 		return false, r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse,
-			hyd.Spec.HostingCluster+" ManagedCluster is required. Retrying after a minute", hypdeployment.MisConfiguredReason)
+			hostingCluster+" ManagedCluster is required. Retrying after a minute", hypdeployment.MisConfiguredReason)
 	case err != nil:
-		r.Log.Error(err, "error while trying to find ManagedCluster: "+hyd.Spec.HostingCluster)
+		r.Log.Error(err, "error while trying to find ManagedCluster: "+hostingCluster)
 		return false, r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse,
-			hyd.Spec.HostingCluster+" ManagedCluster is required. Retrying after a minute", hypdeployment.MisConfiguredReason)
+			hostingCluster+" ManagedCluster is required. Retrying after a minute", hypdeployment.MisConfiguredReason)
 	}
 
 	if managedClusterSetName != "" {
@@ -258,9 +305,9 @@ func (r *HypershiftDeploymentReconciler) validateSecurityConstraints(ctx context
 
 	foundClusterSet, err := helper.IsClusterInClusterSet(r.Client, &managedCluster, clusterSets.List())
 	if err != nil {
-		r.Log.Error(err, "error while trying to determine if ManagedCluster: "+hyd.Spec.HostingCluster+" is in a ManagedClusterSet")
+		r.Log.Error(err, "error while trying to determine if ManagedCluster: "+hostingCluster+" is in a ManagedClusterSet")
 		return false, r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse,
-			hyd.Spec.HostingCluster+" ManagedClusterSet is required. Retrying after a minute", hypdeployment.MisConfiguredReason)
+			hostingCluster+" ManagedClusterSet is required. Retrying after a minute", hypdeployment.MisConfiguredReason)
 	}
 
 	if !foundClusterSet {
@@ -275,7 +322,7 @@ func (r *HypershiftDeploymentReconciler) validateSecurityConstraints(ctx context
 func (r *HypershiftDeploymentReconciler) createOrUpdateMainfestwork(ctx context.Context, req ctrl.Request, hyd *hypdeployment.HypershiftDeployment, providerSecret *corev1.Secret) (ctrl.Result, error) {
 
 	// We need a HostingCluster if we use ManifestWork
-	if len(hyd.Spec.HostingCluster) == 0 {
+	if len(helper.GetHostingClusterName(hyd)) == 0 {
 		r.Log.Error(errors.New(constant.HostingClusterMissing), "Spec.HostingCluster needs a ManagedCluster name")
 		return ctrl.Result{}, r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse, constant.HostingClusterMissing, hypdeployment.MisConfiguredReason)
 	}
@@ -324,6 +371,20 @@ func (r *HypershiftDeploymentReconciler) createOrUpdateMainfestwork(ctx context.
 		}
 	}
 
+	for _, np := range hyd.Spec.NodePools {
+		if np.Policy == nil {
+			continue
+		}
+		if msg := validateNodePoolPolicy(np.Policy); len(msg) != 0 {
+			return ctrl.Result{}, r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse,
+				fmt.Sprintf("NodePool %s policy is invalid: %s", np.Name, msg), hypdeployment.MisConfiguredReason)
+		}
+		if missing := nodePoolZonesWithoutSubnet(hyd, np); len(missing) != 0 {
+			return ctrl.Result{}, r.updateStatusConditionsOnChange(hyd, hypdeployment.WorkConfigured, metav1.ConditionFalse,
+				fmt.Sprintf("NodePool %s policy is invalid: zones %s have no subnet", np.Name, strings.Join(missing, ", ")), hypdeployment.MisConfiguredReason)
+		}
+	}
+
 	passedSecurity, statusUpdateErr := r.validateSecurityConstraints(ctx, hyd)
 	if !passedSecurity || statusUpdateErr != nil {
 		return ctrl.Result{RequeueAfter: time.Minute * 1}, statusUpdateErr
@@ -340,26 +401,27 @@ func (r *HypershiftDeploymentReconciler) createOrUpdateMainfestwork(ctx context.
 	// if the manifestwork is created, then move the status to hypershiftDeployment
 	if err := r.Get(ctx, getManifestWorkKey(hyd), m); err == nil {
 		syncManifestworkStatusToHypershiftDeployment(hyd, m)
-	}
 
-	payload := []workv1.Manifest{}
-
-	manifestFuncs := []loadManifest{
-		ensureTaregetNamespace,
-		r.appendHostedCluster(ctx),
-		r.appendNodePool(ctx),
-		r.appendHostedClusterReferenceSecrets(ctx, providerSecret),
-		r.ensureConfiguration(ctx, m),
-	}
+		// Only the first transition to Available is observed, not an Available HostedCluster after a controller restart
+		if !condmeta.IsStatusConditionTrue(inHyd.Status.Conditions, string(hypdeployment.HostedClusterAvailable)) &&
+			condmeta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.HostedClusterAvailable)) {
+			now := time.Now()
+			metrics.ObserveTransition(metrics.HostedClusterAvailableDuration.WithLabelValues(metrics.Platform(hyd)),
+				"hostedcluster-available", hyd.UID, now, now.Sub(hyd.CreationTimestamp.Time))
+		}
 
-	for _, f := range manifestFuncs {
-		err := f(hyd, &payload)
-		if err != nil {
-			r.Log.Error(err, "failed to load payload to manifestwork")
+		if err := r.reconcileUpgrade(hyd, m); err != nil {
+			r.Log.Error(err, "failed to reconcile the upgrade")
 			return ctrl.Result{}, err
 		}
 	}
 
+	payload, err := r.loadPayload(ctx, hyd, m, providerSecret)
+	if err != nil {
+		r.Log.Error(err, "failed to load payload to manifestwork")
+		return ctrl.Result{}, err
+	}
+
 	// the object in controllerutil.CreateOrUpdate will get override by a GET
 	// after the GET, the update will be called and the payload will be wrote to
 	// the in object, which will be send with a UPDATE
@@ -370,11 +432,23 @@ func (r *HypershiftDeploymentReconciler) createOrUpdateMainfestwork(ctx context.
 			return nil
 		}
 	}
-	if _, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, m, update(m, payload)); err != nil {
+	start := time.Now()
+	op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, m, update(m, payload))
+	if err != nil {
+		metrics.ManifestWorkApplyFailures.Inc()
 		r.Log.Error(err, fmt.Sprintf("failed to CreateOrUpdate the existing manifestwork %s", getManifestWorkKey(hyd)))
+		r.recordEvent(hyd, corev1.EventTypeWarning, ManifestWorkFailedEvent, "Failed to apply manifestwork %s: %v", getManifestWorkKey(hyd), err)
 		return ctrl.Result{}, err
 
 	}
+	metrics.ManifestWorkApplyDuration.Observe(time.Since(start).Seconds())
+
+	switch op {
+	case controllerutil.OperationResultCreated:
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkCreatedEvent, "Created manifestwork %s", getManifestWorkKey(hyd))
+	case controllerutil.OperationResultUpdated:
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkUpdatedEvent, "Updated manifestwork %s", getManifestWorkKey(hyd))
+	}
 
 	r.Log.Info(fmt.Sprintf("CreateOrUpdate manifestwork %s for hypershiftDeployment: %s at hostingCluster: %s", getManifestWorkKey(hyd), req, helper.GetHostingCluster(hyd)))
 
@@ -386,7 +460,36 @@ func (r *HypershiftDeploymentReconciler) createOrUpdateMainfestwork(ctx context.
 		hypdeployment.ConfiguredAsExpectedReason,
 	)
 
-	return ctrl.Result{}, r.Client.Status().Patch(r.ctx, hyd, client.MergeFrom(inHyd))
+	// The NodePools are resized when a scale down window opens or closes
+	return ctrl.Result{RequeueAfter: nextScaleDownWindowChange(hyd, time.Now())}, r.Client.Status().Patch(r.ctx, hyd, client.MergeFrom(inHyd))
+}
+
+// loadPayload runs the loadManifest pipeline, it is shared by the reconciler and RenderManifestWork
+func (r *HypershiftDeploymentReconciler) loadPayload(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, m *workv1.ManifestWork, providerSecret *corev1.Secret) ([]workv1.Manifest, error) {
+	payload := []workv1.Manifest{}
+
+	// the sources are recorded again while the payload is loaded
+	hyd.Status.CopiedSources = nil
+	if len(providerSecret.ResourceVersion) != 0 {
+		recordCopiedSource(hyd, sourceKindSecret, providerSecret)
+	}
+
+	manifestFuncs := []loadManifest{
+		ensureTaregetNamespace,
+		r.appendHostedCluster(ctx),
+		r.appendNodePool(ctx),
+		r.appendHostedClusterReferenceSecrets(ctx, providerSecret),
+		appendAgentCAPIProviderRole,
+		r.ensureConfiguration(ctx, m),
+	}
+
+	for _, f := range manifestFuncs {
+		if err := f(hyd, &payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return payload, nil
 }
 
 func (r *HypershiftDeploymentReconciler) deleteManifestworkWaitCleanUp(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (ctrl.Result, error) {
@@ -420,17 +523,25 @@ func (r *HypershiftDeploymentReconciler) deleteManifestworkWaitCleanUp(ctx conte
 
 			cond := condmeta.FindStatusCondition(m.Status.Conditions, string(workv1.WorkAvailable))
 			if cond == nil || cond.ObservedGeneration != m.Generation || cond.Status != metav1.ConditionTrue {
-				// Requeue the request, wait for the work agent to consume the delete option changes.
+				// Requeue the request, wait for the work agent to consume the delete option changes. The event is
+				// only recorded when the wait starts, the condition keeps the message for the next requeues
+				msg := fmt.Sprintf("Waiting for the work agent to apply the delete option of manifestwork %s", getManifestWorkKey(hyd))
+				if c := condmeta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.WorkConfigured)); c == nil || c.Message != msg {
+					r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkWaitingEvent, "%s", msg)
+				}
+				setStatusCondition(hyd, hypdeployment.WorkConfigured, metav1.ConditionTrue, msg, hypdeployment.RemovingReason)
 				return ctrl.Result{RequeueAfter: 1 * time.Second, Requeue: true}, nil
 			}
 		}
 
 		if err := r.Delete(ctx, m); err != nil {
 			if !apierrors.IsNotFound(err) {
+				r.recordEvent(hyd, corev1.EventTypeWarning, ManifestWorkFailedEvent, "Failed to delete manifestwork %s: %v", getManifestWorkKey(hyd), err)
 				return ctrl.Result{}, fmt.Errorf("failed to delete manifestwork, err: %v", err)
 			}
 		}
 		r.Log.Info("delete the manifestwork complete")
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkDeletedEvent, "Deleted manifestwork %s", getManifestWorkKey(hyd))
 	}
 
 	syncManifestworkStatusToHypershiftDeployment(hyd, m)
@@ -440,6 +551,50 @@ func (r *HypershiftDeploymentReconciler) deleteManifestworkWaitCleanUp(ctx conte
 	return ctrl.Result{RequeueAfter: 20 * time.Second, Requeue: true}, nil
 }
 
+// orphanManifestWork deletes the manifestwork with the orphan delete option, so the HostedCluster and NodePools are
+// left on the hosting cluster. It returns what it waits for, the message is empty once the manifestwork is removed
+func (r *HypershiftDeploymentReconciler) orphanManifestWork(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (string, error) {
+	m, err := scaffoldManifestwork(hyd)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.Get(ctx, getManifestWorkKey(hyd), m); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get manifestwork %s, err: %w", getManifestWorkKey(hyd), err)
+	}
+
+	if !m.DeletionTimestamp.IsZero() {
+		return "Waiting for the manifestwork to be removed from " + m.Namespace, nil
+	}
+
+	orphanHyd := hyd.DeepCopy()
+	orphanHyd.Spec.DeletionPolicy = &hypdeployment.DeletionPolicy{
+		HostedCluster:    hypdeployment.DeletionPolicyOrphan,
+		HostingNamespace: hypdeployment.DeletionPolicyOrphan,
+	}
+	dpm := m.DeepCopy()
+	setManifestWorkSelectivelyDeleteOption(m, orphanHyd)
+	if !reflect.DeepEqual(dpm.Spec.DeleteOption, m.Spec.DeleteOption) {
+		if err := r.Patch(ctx, m, client.MergeFrom(dpm)); err != nil {
+			return "", fmt.Errorf("failed to set the orphan delete option of manifestwork %s, err: %w", getManifestWorkKey(hyd), err)
+		}
+	}
+
+	// Wait for the work agent to consume the delete option
+	if !isWorkApplied(m) {
+		return "Waiting for the work agent to apply the orphan delete option on " + m.Namespace, nil
+	}
+
+	if err := r.Delete(ctx, m); err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to delete manifestwork %s, err: %w", getManifestWorkKey(hyd), err)
+	}
+	r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkDeletedEvent, "Deleted manifestwork %s", getManifestWorkKey(hyd))
+	return "Waiting for the manifestwork to be removed from " + m.Namespace, nil
+}
+
 func (r *HypershiftDeploymentReconciler) appendHostedClusterReferenceSecrets(ctx context.Context, providerSecret *corev1.Secret) loadManifest {
 	log := r.Log
 
@@ -457,7 +612,7 @@ func (r *HypershiftDeploymentReconciler) appendHostedClusterReferenceSecrets(ctx
 		if len(hcSpec.PullSecret.Name) != 0 {
 			var pullCreds *corev1.Secret
 			if !hyd.Spec.Infrastructure.Configure {
-				pullCreds, err = r.generateSecret(ctx,
+				pullCreds, err = r.generateSecret(ctx, hyd,
 					types.NamespacedName{Name: hcSpec.PullSecret.Name,
 						Namespace: hyd.GetNamespace()})
 
@@ -486,7 +641,7 @@ func (r *HypershiftDeploymentReconciler) appendHostedClusterReferenceSecrets(ctx
 		// the provider secret if it is provided.
 		sshKey := hcSpec.SSHKey
 		if len(sshKey.Name) != 0 {
-			s, err := r.generateSecret(ctx,
+			s, err := r.generateSecret(ctx, hyd,
 				types.NamespacedName{Name: sshKey.Name,
 					Namespace: hyd.GetNamespace()})
 
@@ -517,6 +672,19 @@ func (r *HypershiftDeploymentReconciler) appendHostedClusterReferenceSecrets(ctx
 	}
 }
 
+// appendAgentCAPIProviderRole grants the hosted control plane access to the Agents of an Agent platform HostedCluster
+func appendAgentCAPIProviderRole(hyd *hypdeployment.HypershiftDeployment, payload *[]workv1.Manifest) error {
+	hostedCluster := getHostedClusterInManifestPayload(payload)
+	if hostedCluster == nil || hostedCluster.Spec.Platform.Agent == nil || hostedCluster.Spec.Platform.Agent.AgentNamespace == "" {
+		return nil
+	}
+
+	role := ScaffoldAgentCAPIProviderRole(hostedCluster.Spec.Platform.Agent.AgentNamespace)
+	*payload = append(*payload, workv1.Manifest{RawExtension: runtime.RawExtension{Object: role}})
+
+	return nil
+}
+
 func (r *HypershiftDeploymentReconciler) appendHostedCluster(ctx context.Context) loadManifest {
 	return func(hyd *hypdeployment.HypershiftDeployment, payload *[]workv1.Manifest) error {
 
@@ -581,7 +749,13 @@ func (r *HypershiftDeploymentReconciler) appendNodePool(ctx context.Context) loa
 				*payload = append(*payload, workv1.Manifest{RawExtension: runtime.RawExtension{Object: np}})
 			}
 		} else {
-			for _, hdNp := range hyd.Spec.NodePools {
+			for _, np := range hyd.Spec.NodePools {
+				if missing := nodePoolZonesWithoutSubnet(hyd, np); len(missing) != 0 {
+					return fmt.Errorf("the policy of NodePool %s spreads it across zones %s that have no subnet", np.Name, strings.Join(missing, ", "))
+				}
+			}
+
+			for _, hdNp := range expandNodePools(hyd, time.Now()) {
 				usNpSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&hdNp.Spec)
 				if err != nil {
 					return fmt.Errorf(fmt.Sprintf("failed to transform HypershiftDeployment.Spec.NodePools from hypershiftDeployment: %v:%v", hyd.Namespace, hdNp.Name))
@@ -628,16 +802,45 @@ func getManifestWorkConfigs(hyd *hypdeployment.HypershiftDeployment) map[workv1.
 						Name: Progress,
 						Path: ".status.version.history[?(@.state!=\"\")].state",
 					},
+					{
+						Name: DesiredImage,
+						Path: ".status.version.desired.image",
+					},
+					{
+						Name: Version,
+						Path: ".status.version.history[0].version",
+					},
+					{
+						Name: Kubeconfig,
+						Path: ".status.kubeconfig.name",
+					},
+					{
+						Name: KubeadminPassword,
+						Path: ".status.kubeadminPassword.name",
+					},
+					{
+						Name: APIHost,
+						Path: ".status.controlPlaneEndpoint.host",
+					},
+					{
+						Name: APIPort,
+						Path: ".status.controlPlaneEndpoint.port",
+					},
 				},
 			},
 		},
 	}
 
+	nodePoolNames := []string{}
 	for _, np := range hyd.Spec.NodePools {
+		nodePoolNames = append(nodePoolNames, expandedNodePoolNames(hyd, np)...)
+	}
+
+	for _, name := range nodePoolNames {
 		k := workv1.ResourceIdentifier{
 			Group:     hyp.GroupVersion.Group,
 			Resource:  NodePoolResource,
-			Name:      np.Name,
+			Name:      name,
 			Namespace: helper.GetHostingNamespace(hyd),
 		}
 
@@ -659,6 +862,18 @@ func getManifestWorkConfigs(hyd *hypdeployment.HypershiftDeployment) map[workv1.
 							Name: Message,
 							Path: ".status.conditions[?(@.type==\"Ready\")].message",
 						},
+						{
+							Name: Version,
+							Path: ".status.version",
+						},
+						{
+							Name: Replicas,
+							Path: ".status.replicas",
+						},
+						{
+							Name: AutoScalingEnabled,
+							Path: ".status.conditions[?(@.type==\"AutoscalingEnabled\")].status",
+						},
 					},
 				},
 			},
@@ -720,6 +935,15 @@ func enableManifestStatusFeedback(m *workv1.ManifestWork, hyd *hypdeployment.Hyp
 		cfg = append(cfg, v)
 	}
 
+	// a stable order keeps the ManifestWork from being updated, and rendered, differently every time
+	sort.Slice(cfg, func(i, j int) bool {
+		a, b := cfg[i].ResourceIdentifier, cfg[j].ResourceIdentifier
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Name < b.Name
+	})
+
 	m.Spec.ManifestConfigs = cfg
 
 	return cfg
@@ -732,6 +956,7 @@ func getStatusFeedbackAsCondition(m *workv1.ManifestWork, hyd *hypdeployment.Hyp
 	}
 
 	out := []metav1.Condition{}
+	notReady := []string{}
 
 	for _, obj := range m.Status.ResourceStatus.Manifests {
 		rMeta := resourceMeta(obj.ResourceMeta)
@@ -747,13 +972,16 @@ func getStatusFeedbackAsCondition(m *workv1.ManifestWork, hyd *hypdeployment.Hyp
 				continue
 			}
 
-			// find and set failed nodepool to condition
+			// find and set failed nodepool to condition, Status.NodePools has the details of each NodePool
 			st := condmeta.FindStatusCondition(out, string(hypdeployment.Nodepool))
 			if st == nil {
 				condmeta.SetStatusCondition(&out, npCond)
 			} else if npCond.Status != "True" {
 				condmeta.SetStatusCondition(&out, npCond)
 			}
+			if npCond.Status != "True" {
+				notReady = append(notReady, id.Name)
+			}
 		}
 
 		if id.Resource == HostedClusterResource {
@@ -769,6 +997,13 @@ func getStatusFeedbackAsCondition(m *workv1.ManifestWork, hyd *hypdeployment.Hyp
 		}
 	}
 
+	if len(notReady) > 1 {
+		last := notReady[len(notReady)-1]
+		sort.Strings(notReady)
+		st := condmeta.FindStatusCondition(out, string(hypdeployment.Nodepool))
+		st.Message = fmt.Sprintf("NodePools %s are not ready, %s: %s", strings.Join(notReady, ", "), last, st.Message)
+	}
+
 	// if there's nodepool condition and it's not false, then all nodepool are ready
 	st := condmeta.FindStatusCondition(out, string(hypdeployment.Nodepool))
 	if st != nil && st.Status == "True" {
@@ -1802,3 +1802,49 @@ func TestDeleteManifestworkWaitCleanUp(t *testing.T) {
 	err = client.Get(ctx, types.NamespacedName{Name: mw.Name, Namespace: mw.Namespace}, mw)
 	assert.True(t, apierrors.IsNotFound(err), "true when ManifestWork is removed")
 }
+
+func TestManifestWorkDeleteOption(t *testing.T) {
+	cases := []struct {
+		name     string
+		override hyd.InfraOverride
+		policy   *hyd.DeletionPolicy
+		expected workv1.DeletePropagationPolicyType
+	}{
+		{name: "default", expected: workv1.DeletePropagationPolicyTypeSelectivelyOrphan},
+		{name: "orphan override", override: hyd.InfraOverrideDestroy, expected: workv1.DeletePropagationPolicyTypeOrphan},
+		{name: "delete hosting namespace override", override: hyd.DeleteHostingNamespace, expected: workv1.DeletePropagationPolicyTypeForeground},
+		{
+			name:     "orphan hosted cluster",
+			policy:   &hyd.DeletionPolicy{HostedCluster: hyd.DeletionPolicyOrphan},
+			expected: workv1.DeletePropagationPolicyTypeOrphan,
+		},
+		{
+			name:     "delete hosting namespace",
+			policy:   &hyd.DeletionPolicy{HostingNamespace: hyd.DeletionPolicyDelete},
+			expected: workv1.DeletePropagationPolicyTypeForeground,
+		},
+		{
+			name:     "deletion policy wins over the override",
+			override: hyd.InfraOverrideDestroy,
+			policy:   &hyd.DeletionPolicy{HostedCluster: hyd.DeletionPolicyDelete},
+			expected: workv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+		},
+		{
+			name:     "orphan only the infrastructure",
+			policy:   &hyd.DeletionPolicy{Infrastructure: hyd.DeletionPolicyOrphan, IAM: hyd.DeletionPolicyOrphan},
+			expected: workv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testHD := getHDforManifestWork()
+			testHD.Spec.Override = c.override
+			testHD.Spec.DeletionPolicy = c.policy
+
+			mw := &workv1.ManifestWork{}
+			setManifestWorkSelectivelyDeleteOption(mw, testHD)
+			assert.Equal(t, c.expected, mw.Spec.DeleteOption.PropagationPolicy)
+		})
+	}
+}
@@ -0,0 +1,880 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	condmeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+const (
+	// Keys of the Spec.Migration.BackupSecret
+	BackupUploadURLKey  = "upload-url"
+	BackupRestoreURLKey = "restore-url"
+
+	defaultMigrationStepTimeout = 30 * time.Minute
+	migrationRequeueInterval    = 15 * time.Second
+
+	// backupJobBackoffLimit is the number of retries of the etcd backup Job
+	backupJobBackoffLimit = 2
+	backupJobSucceeded    = "succeeded"
+	backupJobFailed       = "failed"
+)
+
+// DNSSwitcher moves the DNS records of the HostedCluster endpoints to the target hosting cluster. Without one,
+// the records are expected to follow the services of the HostedCluster, as with external-dns
+type DNSSwitcher interface {
+	SwitchDNS(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, source, target string) error
+}
+
+// migrationSteps are run in order, the condition of a step is true once it completed
+var migrationSteps = []struct {
+	step      hypdeployment.MigrationStep
+	condition hypdeployment.ConditionType
+}{
+	{hypdeployment.MigrationStepPausing, hypdeployment.MigrationSourcePaused},
+	{hypdeployment.MigrationStepBackingUp, hypdeployment.MigrationBackedUp},
+	{hypdeployment.MigrationStepApplying, hypdeployment.MigrationTargetApplied},
+	{hypdeployment.MigrationStepRestoring, hypdeployment.MigrationRestored},
+	{hypdeployment.MigrationStepSwitchingDNS, hypdeployment.MigrationDNSSwitched},
+	{hypdeployment.MigrationStepCleaningUp, hypdeployment.MigrationSourceRemoved},
+}
+
+// migrationStepResult is the outcome of one pass of a step. A step is retried until it is done, a failure
+// rolls the migration back
+type migrationStepResult struct {
+	done    bool
+	skipped bool
+	message string
+	failure string
+}
+
+func migrationInProgress(hyd *hypdeployment.HypershiftDeployment) bool {
+	ms := hyd.Status.Migration
+	return ms != nil && ms.Step != hypdeployment.MigrationStepCompleted && ms.Step != hypdeployment.MigrationStepRolledBack
+}
+
+func migrationStepTimeout(hyd *hypdeployment.HypershiftDeployment) time.Duration {
+	if hyd.Spec.Migration != nil && hyd.Spec.Migration.StepTimeout != nil {
+		return hyd.Spec.Migration.StepTimeout.Duration
+	}
+	return defaultMigrationStepTimeout
+}
+
+// migrationTarget is a copy of the HypershiftDeployment rendered for the target hosting cluster
+func migrationTarget(hyd *hypdeployment.HypershiftDeployment) *hypdeployment.HypershiftDeployment {
+	targetHyd := hyd.DeepCopy()
+	targetHyd.Status.HostingCluster = hyd.Status.Migration.Target
+	return targetHyd
+}
+
+// reconcileMigration moves the HostedCluster when Spec.HostingCluster no longer matches Status.HostingCluster.
+// It returns true while a migration is in progress, the caller returns the result without updating the
+// manifestwork. A deletion rolls the migration back first
+func (r *HypershiftDeploymentReconciler) reconcileMigration(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (ctrl.Result, bool, error) {
+	if !migrationInProgress(hyd) {
+		if hyd.DeletionTimestamp != nil {
+			return ctrl.Result{}, false, nil
+		}
+		return r.startMigration(ctx, hyd)
+	}
+
+	ms := hyd.Status.Migration
+	if ms.Step == hypdeployment.MigrationStepRollingBack {
+		return r.rollbackMigration(ctx, hyd)
+	}
+
+	if hyd.DeletionTimestamp != nil {
+		return r.failMigration(ctx, hyd, "The HypershiftDeployment is being deleted")
+	}
+
+	if timeout := migrationStepTimeout(hyd); time.Since(ms.StepStartTime.Time) > timeout {
+		return r.failMigration(ctx, hyd, fmt.Sprintf("Step %s did not complete in %s", ms.Step, timeout))
+	}
+
+	var res migrationStepResult
+	var err error
+	switch ms.Step {
+	case hypdeployment.MigrationStepPausing:
+		res, err = r.pauseMigrationSource(ctx, hyd)
+	case hypdeployment.MigrationStepBackingUp:
+		res, err = r.backupMigrationEtcd(ctx, hyd)
+	case hypdeployment.MigrationStepApplying:
+		res, err = r.applyMigrationTarget(ctx, hyd)
+	case hypdeployment.MigrationStepRestoring:
+		res, err = r.waitMigrationRestore(ctx, hyd)
+	case hypdeployment.MigrationStepSwitchingDNS:
+		res, err = r.switchMigrationDNS(ctx, hyd)
+	case hypdeployment.MigrationStepCleaningUp:
+		res, err = r.removeMigrationSource(ctx, hyd)
+	default:
+		res.failure = fmt.Sprintf("Unknown migration step %s", ms.Step)
+	}
+	if err != nil {
+		return ctrl.Result{}, true, err
+	}
+
+	if len(res.failure) != 0 {
+		return r.failMigration(ctx, hyd, res.failure)
+	}
+
+	// A step that patches the spec reads the status back
+	ms = hyd.Status.Migration
+
+	current := 0
+	for i, s := range migrationSteps {
+		if s.step == ms.Step {
+			current = i
+		}
+	}
+
+	if !res.done {
+		return ctrl.Result{RequeueAfter: migrationRequeueInterval}, true, r.updateStatusConditionsOnChange(hyd,
+			migrationSteps[current].condition, metav1.ConditionFalse, res.message, hypdeployment.BeingConfiguredReason)
+	}
+
+	inHyd := hyd.DeepCopy()
+	reason := hypdeployment.ConfiguredAsExpectedReason
+	if res.skipped {
+		reason = hypdeployment.NotApplicableReason
+	}
+	setStatusCondition(hyd, migrationSteps[current].condition, metav1.ConditionTrue, res.message, reason)
+
+	now := metav1.Now()
+	if current == len(migrationSteps)-1 {
+		ms.Step = hypdeployment.MigrationStepCompleted
+		ms.CompletionTime = &now
+		ms.SourceLeftovers = migrationSourceLeftovers(hyd)
+		hyd.Status.HostingCluster = ms.Target
+		setStatusCondition(hyd, hypdeployment.Migrating, metav1.ConditionFalse,
+			fmt.Sprintf("Migrated the HostedCluster from %s to %s", ms.Source, ms.Target), hypdeployment.ConfiguredAsExpectedReason)
+	} else {
+		ms.Step = migrationSteps[current+1].step
+		ms.StepStartTime = now
+		setStatusCondition(hyd, migrationSteps[current+1].condition, metav1.ConditionFalse, "", hypdeployment.BeingConfiguredReason)
+	}
+
+	if err := r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		return ctrl.Result{}, true, fmt.Errorf("failed to record the migration step %s, err: %w", ms.Step, err)
+	}
+
+	r.Log.Info("Migration step completed", "step", migrationSteps[current].step, "message", res.message)
+	if ms.Step == hypdeployment.MigrationStepCompleted {
+		r.recordEvent(hyd, corev1.EventTypeNormal, MigrationCompletedEvent,
+			"Migrated the HostedCluster from %s to %s", ms.Source, ms.Target)
+		r.recordEvent(hyd, corev1.EventTypeWarning, MigrationSourceOrphanedEvent,
+			"The resources of the HostedCluster are orphaned on %s and must be removed there: %s", ms.Source, strings.Join(ms.SourceLeftovers, ", "))
+	}
+
+	return ctrl.Result{Requeue: true}, true, nil
+}
+
+// startMigration records the hosting cluster of Spec.HostingCluster, and starts a migration when it changes
+// after the manifestwork was applied
+func (r *HypershiftDeploymentReconciler) startMigration(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (ctrl.Result, bool, error) {
+	source := hyd.Status.HostingCluster
+	target := hyd.Spec.HostingCluster
+	ms := hyd.Status.Migration
+
+	inHyd := hyd.DeepCopy()
+	switch {
+	case len(target) == 0:
+		return ctrl.Result{}, false, nil
+
+	case target == source:
+		// A rolled back migration is forgotten once Spec.HostingCluster is set back to the source
+		if ms == nil || ms.Step != hypdeployment.MigrationStepRolledBack {
+			return ctrl.Result{}, false, nil
+		}
+		hyd.Status.Migration = nil
+
+	case ms != nil && ms.Step == hypdeployment.MigrationStepRolledBack && ms.Target == target:
+		// The failed target is not tried again until Spec.HostingCluster changes
+		return ctrl.Result{}, false, nil
+
+	case len(source) == 0:
+		hyd.Status.HostingCluster = target
+
+	default:
+		m, err := scaffoldManifestwork(hyd)
+		if err != nil {
+			return ctrl.Result{}, false, err
+		}
+
+		err = r.Get(ctx, getManifestWorkKey(hyd), m)
+		switch {
+		case apierrors.IsNotFound(err):
+			// Nothing runs on the source hosting cluster, the manifestwork is applied to the target
+			r.Log.Info("Hosting cluster changed before the manifestwork was applied", "source", source, "target", target)
+			hyd.Status.HostingCluster = target
+		case err != nil:
+			return ctrl.Result{}, false, fmt.Errorf("failed to get manifestwork %s, err: %w", getManifestWorkKey(hyd), err)
+		default:
+			return r.beginMigration(ctx, hyd, source, target)
+		}
+	}
+
+	return ctrl.Result{}, false, r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd))
+}
+
+func (r *HypershiftDeploymentReconciler) beginMigration(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, source, target string) (ctrl.Result, bool, error) {
+	inHyd := hyd.DeepCopy()
+
+	now := metav1.Now()
+	hyd.Status.Migration = &hypdeployment.MigrationStatus{
+		Source:        source,
+		Target:        target,
+		Step:          hypdeployment.MigrationStepPausing,
+		StepStartTime: now,
+		StartTime:     &now,
+	}
+	for _, s := range migrationSteps {
+		condmeta.RemoveStatusCondition(&hyd.Status.Conditions, string(s.condition))
+	}
+
+	message := fmt.Sprintf("Migrating the HostedCluster from %s to %s", source, target)
+	setStatusCondition(hyd, hypdeployment.Migrating, metav1.ConditionTrue, message, hypdeployment.BeingConfiguredReason)
+	setStatusCondition(hyd, hypdeployment.MigrationSourcePaused, metav1.ConditionFalse, "", hypdeployment.BeingConfiguredReason)
+	if err := r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		return ctrl.Result{}, true, fmt.Errorf("failed to start the migration to %s, err: %w", target, err)
+	}
+
+	r.Log.Info("Migration started", "source", source, "target", target)
+	r.recordEvent(hyd, corev1.EventTypeNormal, MigrationStartedEvent, message)
+	return ctrl.Result{Requeue: true}, true, nil
+}
+
+// failMigration starts the rollback of the migration
+func (r *HypershiftDeploymentReconciler) failMigration(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, failure string) (ctrl.Result, bool, error) {
+	inHyd := hyd.DeepCopy()
+	ms := hyd.Status.Migration
+
+	for _, s := range migrationSteps {
+		if s.step == ms.Step {
+			setStatusCondition(hyd, s.condition, metav1.ConditionFalse, failure, hypdeployment.MigrationRolledBackReason)
+		}
+	}
+
+	ms.FailedStep = ms.Step
+	ms.Step = hypdeployment.MigrationStepRollingBack
+	ms.StepStartTime = metav1.Now()
+	setStatusCondition(hyd, hypdeployment.Migrating, metav1.ConditionTrue,
+		fmt.Sprintf("Rolling back the migration to %s: %s", ms.Target, failure), hypdeployment.BeingConfiguredReason)
+	if err := r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		return ctrl.Result{}, true, fmt.Errorf("failed to roll back the migration to %s, err: %w", ms.Target, err)
+	}
+
+	r.Log.Info("Rolling back the migration", "step", ms.FailedStep, "failure", failure)
+	r.recordEvent(hyd, corev1.EventTypeWarning, MigrationFailedEvent,
+		"Migration to %s failed at step %s: %s", ms.Target, ms.FailedStep, failure)
+	return ctrl.Result{Requeue: true}, true, nil
+}
+
+// rollbackMigration pauses and orphans the HostedCluster applied to the target, and removes the etcd backup.
+// The source is resumed by the next manifestwork update, its payload is rendered without spec.pausedUntil
+func (r *HypershiftDeploymentReconciler) rollbackMigration(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (ctrl.Result, bool, error) {
+	ms := hyd.Status.Migration
+
+	if err := r.deleteBackupManifestWork(ctx, hyd); err != nil {
+		return ctrl.Result{}, true, err
+	}
+
+	targetHyd := migrationTarget(hyd)
+	m, err := scaffoldManifestwork(targetHyd)
+	if err != nil {
+		return ctrl.Result{}, true, err
+	}
+
+	err = r.Get(ctx, getManifestWorkKey(targetHyd), m)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, true, fmt.Errorf("failed to get manifestwork %s, err: %w", getManifestWorkKey(targetHyd), err)
+	}
+
+	if err == nil {
+		// The HostedCluster on the target is paused before it is orphaned, unless the work agent does not respond
+		if m.DeletionTimestamp.IsZero() && time.Since(ms.StepStartTime.Time) < migrationStepTimeout(hyd) {
+			paused, err := r.pauseManifestWork(ctx, m)
+			if err != nil {
+				return ctrl.Result{}, true, err
+			}
+			if !paused {
+				return ctrl.Result{RequeueAfter: migrationRequeueInterval}, true, r.updateStatusConditionsOnChange(hyd,
+					hypdeployment.Migrating, metav1.ConditionTrue,
+					"Waiting for the HostedCluster to be paused on "+ms.Target, hypdeployment.BeingConfiguredReason)
+			}
+		}
+
+		// The manifestwork keeps the orphan delete option it was created with
+		if err := r.Delete(ctx, m); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, true, fmt.Errorf("failed to delete manifestwork %s, err: %w", getManifestWorkKey(targetHyd), err)
+		}
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkDeletedEvent, "Deleted manifestwork %s", getManifestWorkKey(targetHyd))
+	}
+
+	inHyd := hyd.DeepCopy()
+	now := metav1.Now()
+	ms.Step = hypdeployment.MigrationStepRolledBack
+	ms.CompletionTime = &now
+	message := fmt.Sprintf("Migration to %s failed at step %s, the HostedCluster is resumed on %s. "+
+		"The resources applied to %s are orphaned", ms.Target, ms.FailedStep, ms.Source, ms.Target)
+	setStatusCondition(hyd, hypdeployment.Migrating, metav1.ConditionFalse, message, hypdeployment.MigrationRolledBackReason)
+	if err := r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		return ctrl.Result{}, true, fmt.Errorf("failed to record the rollback of the migration, err: %w", err)
+	}
+
+	r.Log.Info("Migration rolled back", "source", ms.Source, "target", ms.Target)
+	r.recordEvent(hyd, corev1.EventTypeWarning, MigrationRolledBackEvent, message)
+	return ctrl.Result{Requeue: true}, true, nil
+}
+
+// pauseMigrationSource sets spec.pausedUntil on the HostedCluster of the source, so the control plane is not
+// reconciled while it is copied
+func (r *HypershiftDeploymentReconciler) pauseMigrationSource(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (migrationStepResult, error) {
+	source := hyd.Status.Migration.Source
+
+	m, err := scaffoldManifestwork(hyd)
+	if err != nil {
+		return migrationStepResult{}, err
+	}
+
+	if err := r.Get(ctx, getManifestWorkKey(hyd), m); err != nil {
+		if apierrors.IsNotFound(err) {
+			return migrationStepResult{failure: fmt.Sprintf("Manifestwork %s was removed", getManifestWorkKey(hyd))}, nil
+		}
+		return migrationStepResult{}, fmt.Errorf("failed to get manifestwork %s, err: %w", getManifestWorkKey(hyd), err)
+	}
+
+	paused, err := r.pauseManifestWork(ctx, m)
+	if err != nil || !paused {
+		return migrationStepResult{message: "Waiting for the HostedCluster to be paused on " + source}, err
+	}
+
+	return migrationStepResult{done: true, message: "Paused the HostedCluster on " + source}, nil
+}
+
+// backupMigrationEtcd uploads a snapshot of the managed etcd with a Job applied to the source hosting cluster
+func (r *HypershiftDeploymentReconciler) backupMigrationEtcd(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (migrationStepResult, error) {
+	source := hyd.Status.Migration.Source
+
+	managed, err := r.usesManagedEtcd(ctx, hyd)
+	if err != nil {
+		return migrationStepResult{}, err
+	}
+	if !managed {
+		return migrationStepResult{done: true, skipped: true, message: "The HostedCluster does not use a managed etcd"}, nil
+	}
+
+	if hyd.Spec.HostedClusterSpec == nil || hyd.Spec.HostedClusterSpec.Etcd.Managed == nil {
+		return migrationStepResult{failure: "A managed etcd is only migrated with Spec.HostedClusterSpec.Etcd.Managed"}, nil
+	}
+
+	if hyd.Spec.Migration == nil || len(hyd.Spec.Migration.BackupImage) == 0 {
+		return migrationStepResult{failure: "Spec.Migration.BackupImage is required to back up the managed etcd"}, nil
+	}
+
+	uploadURL, failure, err := r.migrationBackupURL(ctx, hyd, BackupUploadURLKey)
+	if err != nil || len(failure) != 0 {
+		return migrationStepResult{failure: failure}, err
+	}
+
+	bw := scaffoldBackupManifestWork(hyd, source)
+	payload := backupPayload(hyd, uploadURL)
+	cfg := backupFeedbackConfig(hyd)
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, bw, func() error {
+		bw.Spec.Workload.Manifests = payload
+		bw.Spec.ManifestConfigs = cfg
+		return nil
+	})
+	if err != nil {
+		return migrationStepResult{}, fmt.Errorf("failed to apply the etcd backup manifestwork to %s, err: %w", source, err)
+	}
+	if op == controllerutil.OperationResultCreated {
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkCreatedEvent, "Created manifestwork %s/%s", bw.Namespace, bw.Name)
+	}
+
+	succeeded, failed := backupJobFeedback(bw, hyd)
+	switch {
+	case failed > backupJobBackoffLimit:
+		return migrationStepResult{failure: "The etcd backup job failed on " + source}, nil
+	case succeeded == 0:
+		return migrationStepResult{message: "Waiting for the etcd backup job on " + source}, nil
+	}
+
+	return migrationStepResult{done: true, message: "Uploaded the etcd snapshot from " + source}, nil
+}
+
+// applyMigrationTarget applies the manifestwork to the target hosting cluster, a managed etcd is restored from
+// the snapshot of the source
+func (r *HypershiftDeploymentReconciler) applyMigrationTarget(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (migrationStepResult, error) {
+	target := hyd.Status.Migration.Target
+	targetHyd := migrationTarget(hyd)
+
+	passed, err := r.validateSecurityConstraints(ctx, targetHyd)
+	if err != nil {
+		return migrationStepResult{}, err
+	}
+	if !passed {
+		failure := "Hosting cluster " + target + " does not pass the security constraints"
+		if c := condmeta.FindStatusCondition(targetHyd.Status.Conditions, string(hypdeployment.WorkConfigured)); c != nil {
+			failure += ": " + c.Message
+		}
+		return migrationStepResult{failure: failure}, nil
+	}
+
+	if r.CapacityScheduler != nil {
+		if err := r.Get(ctx, getManifestWorkKey(targetHyd), &workv1.ManifestWork{}); err != nil && !apierrors.IsNotFound(err) {
+			return migrationStepResult{}, err
+		} else if err != nil {
+			message, err := r.CapacityScheduler.Admit(ctx, hyd, target)
+			if err != nil {
+				return migrationStepResult{}, err
+			}
+			if len(message) != 0 && r.CapacityScheduler.Reject {
+				return migrationStepResult{failure: message}, nil
+			}
+			if len(message) != 0 {
+				// The step times out when no capacity is freed on the target
+				return migrationStepResult{message: message}, nil
+			}
+		}
+	}
+
+	m, err := scaffoldManifestwork(targetHyd)
+	if err != nil {
+		return migrationStepResult{}, err
+	}
+
+	providerSecret := &corev1.Secret{}
+	if name := hyd.Spec.Infrastructure.CloudProvider.Name; len(name) != 0 {
+		if err := r.Get(ctx, types.NamespacedName{Namespace: hyd.Namespace, Name: name}, providerSecret); err != nil {
+			return migrationStepResult{}, fmt.Errorf("failed to get the provider secret %s, err: %w", name, err)
+		}
+	}
+
+	payload, err := r.loadPayload(ctx, targetHyd, m, providerSecret)
+	if err != nil {
+		return migrationStepResult{}, err
+	}
+
+	if restoresEtcd(hyd) {
+		restoreURL, failure, err := r.migrationBackupURL(ctx, hyd, BackupRestoreURLKey)
+		if err != nil || len(failure) != 0 {
+			return migrationStepResult{failure: failure}, err
+		}
+		if err := setRestoreSnapshotURL(payload, restoreURL); err != nil {
+			return migrationStepResult{}, err
+		}
+	}
+
+	cfg := enableManifestStatusFeedback(m, targetHyd)
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, m, func() error {
+		m.Spec.Workload.Manifests = payload
+		m.Spec.ManifestConfigs = cfg
+		return nil
+	})
+	if err != nil {
+		r.recordEvent(hyd, corev1.EventTypeWarning, ManifestWorkFailedEvent, "Failed to apply manifestwork %s: %v", getManifestWorkKey(targetHyd), err)
+		return migrationStepResult{}, fmt.Errorf("failed to apply manifestwork %s, err: %w", getManifestWorkKey(targetHyd), err)
+	}
+	if op == controllerutil.OperationResultCreated {
+		r.recordEvent(hyd, corev1.EventTypeNormal, ManifestWorkCreatedEvent, "Created manifestwork %s", getManifestWorkKey(targetHyd))
+	}
+
+	if !isWorkApplied(m) {
+		return migrationStepResult{message: "Waiting for the manifestwork to be applied on " + target}, nil
+	}
+	return migrationStepResult{done: true, message: "Applied the manifestwork on " + target}, nil
+}
+
+// waitMigrationRestore waits for the HostedCluster on the target to be available
+func (r *HypershiftDeploymentReconciler) waitMigrationRestore(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (migrationStepResult, error) {
+	target := hyd.Status.Migration.Target
+	targetHyd := migrationTarget(hyd)
+
+	m := &workv1.ManifestWork{}
+	if err := r.Get(ctx, getManifestWorkKey(targetHyd), m); err != nil {
+		if apierrors.IsNotFound(err) {
+			return migrationStepResult{failure: fmt.Sprintf("Manifestwork %s was removed", getManifestWorkKey(targetHyd))}, nil
+		}
+		return migrationStepResult{}, fmt.Errorf("failed to get manifestwork %s, err: %w", getManifestWorkKey(targetHyd), err)
+	}
+
+	conds := getStatusFeedbackAsCondition(m, targetHyd)
+	if !condmeta.IsStatusConditionTrue(conds, string(hypdeployment.HostedClusterAvailable)) {
+		return migrationStepResult{message: "Waiting for the HostedCluster to be available on " + target}, nil
+	}
+	return migrationStepResult{done: true, message: "The HostedCluster is available on " + target}, nil
+}
+
+func (r *HypershiftDeploymentReconciler) switchMigrationDNS(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (migrationStepResult, error) {
+	ms := hyd.Status.Migration
+	if r.DNSSwitcher == nil {
+		return migrationStepResult{done: true, skipped: true,
+			message: "No DNSSwitcher is configured, the DNS records follow the services of the HostedCluster"}, nil
+	}
+
+	// An error is retried until the step times out
+	if err := r.DNSSwitcher.SwitchDNS(ctx, hyd, ms.Source, ms.Target); err != nil {
+		r.Log.Error(err, "failed to switch the DNS records", "target", ms.Target)
+		return migrationStepResult{message: "Failed to switch the DNS records: " + err.Error()}, nil
+	}
+	return migrationStepResult{done: true, message: "Switched the DNS records to " + ms.Target}, nil
+}
+
+// removeMigrationSource deletes the manifestwork of the source hosting cluster with the orphan delete option,
+// so the cloud resources of the HostedCluster are not destroyed by the source
+func (r *HypershiftDeploymentReconciler) removeMigrationSource(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (migrationStepResult, error) {
+	source := hyd.Status.Migration.Source
+
+	if err := r.deleteBackupManifestWork(ctx, hyd); err != nil {
+		return migrationStepResult{}, err
+	}
+
+	waiting, err := r.orphanManifestWork(ctx, hyd)
+	if err != nil || len(waiting) != 0 {
+		return migrationStepResult{message: waiting}, err
+	}
+
+	// restoreSnapshotURL is immutable, it is kept in the spec so the manifestwork of the target is not changed
+	if restoresEtcd(hyd) && hyd.Spec.HostedClusterSpec != nil && hyd.Spec.HostedClusterSpec.Etcd.Managed != nil {
+		restoreURL, failure, err := r.migrationBackupURL(ctx, hyd, BackupRestoreURLKey)
+		if err != nil || len(failure) != 0 {
+			// The target is running, the URL is written once the backup secret is fixed
+			return migrationStepResult{message: failure}, err
+		}
+
+		storage := &hyd.Spec.HostedClusterSpec.Etcd.Managed.Storage
+		if !reflect.DeepEqual(storage.RestoreSnapshotURL, []string{restoreURL}) {
+			storage.RestoreSnapshotURL = []string{restoreURL}
+
+			inStatus := hyd.Status.DeepCopy()
+			if err := r.patchHypershiftDeploymentResource(hyd); err != nil {
+				return migrationStepResult{}, fmt.Errorf("failed to record the etcd restoreSnapshotURL, err: %w", err)
+			}
+			// The status is kept for the caller's status patch
+			hyd.Status = *inStatus
+		}
+	}
+
+	return migrationStepResult{done: true,
+		message: "Removed the manifestwork from " + source + ", the resources of the HostedCluster are orphaned there"}, nil
+}
+
+// migrationSourceLeftovers lists the HostedCluster, NodePools and control plane namespace left on the source. They
+// are not deleted by the controller, the hypershift operator would destroy the cloud resources the target now uses
+func migrationSourceLeftovers(hyd *hypdeployment.HypershiftDeployment) []string {
+	namespace := helper.GetHostingNamespace(hyd)
+	leftovers := []string{fmt.Sprintf("HostedCluster %s/%s", namespace, hyd.Name)}
+	for _, np := range hyd.Spec.NodePools {
+		for _, name := range expandedNodePoolNames(hyd, np) {
+			leftovers = append(leftovers, fmt.Sprintf("NodePool %s/%s", namespace, name))
+		}
+	}
+	return append(leftovers, "Namespace "+hostedControlPlaneNamespace(hyd))
+}
+
+// pauseManifestWork sets spec.pausedUntil on the HostedCluster of a manifestwork, it returns true once the work
+// agent applied it
+func (r *HypershiftDeploymentReconciler) pauseManifestWork(ctx context.Context, m *workv1.ManifestWork) (bool, error) {
+	dpm := m.DeepCopy()
+	if err := pauseHostedCluster(m.Spec.Workload.Manifests); err != nil {
+		return false, err
+	}
+
+	if !reflect.DeepEqual(dpm.Spec.Workload.Manifests, m.Spec.Workload.Manifests) {
+		if err := r.Patch(ctx, m, client.MergeFrom(dpm)); err != nil {
+			return false, fmt.Errorf("failed to pause the HostedCluster of manifestwork %s/%s, err: %w", m.Namespace, m.Name, err)
+		}
+	}
+
+	return isWorkApplied(m), nil
+}
+
+func (r *HypershiftDeploymentReconciler) deleteBackupManifestWork(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) error {
+	bw := scaffoldBackupManifestWork(hyd, hyd.Status.Migration.Source)
+	if err := r.Delete(ctx, bw); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the etcd backup manifestwork %s/%s, err: %w", bw.Namespace, bw.Name, err)
+	}
+	return nil
+}
+
+func (r *HypershiftDeploymentReconciler) usesManagedEtcd(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (bool, error) {
+	if hyd.Spec.HostedClusterSpec != nil {
+		return hyd.Spec.HostedClusterSpec.Etcd.ManagementType == hyp.Managed, nil
+	}
+
+	// OK to use typed client since it's just for validation
+	hc := &hyp.HostedCluster{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: hyd.Namespace, Name: hyd.Spec.HostedClusterRef.Name}, hc); err != nil {
+		return false, fmt.Errorf("failed to get HostedClusterRef %s, err: %w", hyd.Spec.HostedClusterRef.Name, err)
+	}
+	return hc.Spec.Etcd.ManagementType == hyp.Managed, nil
+}
+
+// migrationBackupURL reads a pre-signed URL of Spec.Migration.BackupSecret, a missing secret or key is returned
+// as a failure message
+func (r *HypershiftDeploymentReconciler) migrationBackupURL(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, key string) (string, string, error) {
+	if hyd.Spec.Migration == nil || hyd.Spec.Migration.BackupSecret == nil {
+		return "", "Spec.Migration.BackupSecret is required to migrate a managed etcd", nil
+	}
+
+	name := hyd.Spec.Migration.BackupSecret.Name
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: hyd.Namespace, Name: name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", "The backup secret " + name + " is not found", nil
+		}
+		return "", "", fmt.Errorf("failed to get the backup secret %s, err: %w", name, err)
+	}
+
+	if len(secret.Data[key]) == 0 {
+		return "", "The backup secret " + name + " has no " + key, nil
+	}
+	return string(secret.Data[key]), "", nil
+}
+
+// restoresEtcd is true when the managed etcd of the source was backed up, and is restored on the target
+func restoresEtcd(hyd *hypdeployment.HypershiftDeployment) bool {
+	c := condmeta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.MigrationBackedUp))
+	return c != nil && c.Status == metav1.ConditionTrue && c.Reason != hypdeployment.NotApplicableReason
+}
+
+func isWorkApplied(m *workv1.ManifestWork) bool {
+	cond := condmeta.FindStatusCondition(m.Status.Conditions, workv1.WorkApplied)
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == m.Generation
+}
+
+// manifestObject decodes a manifest read from the API server, or returns the object of a rendered payload
+func manifestObject(manifest workv1.Manifest) (*unstructured.Unstructured, error) {
+	if u, ok := manifest.Object.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+
+	u := &unstructured.Unstructured{}
+	raw := manifest.Raw
+	if manifest.Object != nil {
+		var err error
+		if raw, err = json.Marshal(manifest.Object); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// updateHostedClusterManifest applies a change to the HostedCluster of the manifests
+func updateHostedClusterManifest(manifests []workv1.Manifest, update func(hc *unstructured.Unstructured) error) error {
+	for i, manifest := range manifests {
+		u, err := manifestObject(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to decode a manifest, err: %w", err)
+		}
+		if u.GetKind() != "HostedCluster" {
+			continue
+		}
+
+		before := u.DeepCopy()
+		if err := update(u); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(before.Object, u.Object) {
+			manifests[i] = workv1.Manifest{RawExtension: runtime.RawExtension{Object: u}}
+		}
+	}
+	return nil
+}
+
+func pauseHostedCluster(manifests []workv1.Manifest) error {
+	return updateHostedClusterManifest(manifests, func(hc *unstructured.Unstructured) error {
+		return unstructured.SetNestedField(hc.Object, "true", "spec", "pausedUntil")
+	})
+}
+
+func setRestoreSnapshotURL(manifests []workv1.Manifest, url string) error {
+	return updateHostedClusterManifest(manifests, func(hc *unstructured.Unstructured) error {
+		return unstructured.SetNestedStringSlice(hc.Object, []string{url}, "spec", "etcd", "managed", "storage", "restoreSnapshotURL")
+	})
+}
+
+// hostedControlPlaneNamespace mirrors the namespace HyperShift creates for the control plane of a HostedCluster
+func hostedControlPlaneNamespace(hyd *hypdeployment.HypershiftDeployment) string {
+	return fmt.Sprintf("%s-%s", helper.GetHostingNamespace(hyd), strings.ReplaceAll(hyd.Name, ".", "-"))
+}
+
+func backupJobName(hyd *hypdeployment.HypershiftDeployment) string {
+	return hyd.Name + "-etcd-backup"
+}
+
+func scaffoldBackupManifestWork(hyd *hypdeployment.HypershiftDeployment, hostingCluster string) *workv1.ManifestWork {
+	return &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateManifestName(hyd) + "-etcd-backup",
+			Namespace: hostingCluster,
+			Annotations: map[string]string{
+				constant.CreatedByHypershiftDeployment: fmt.Sprintf("%s%s%s",
+					hyd.GetNamespace(),
+					constant.NamespaceNameSeperator,
+					hyd.GetName()),
+			},
+		},
+	}
+}
+
+// backupPayload is a Job in the control plane namespace that saves an etcd snapshot and uploads it to the
+// pre-signed URL
+func backupPayload(hyd *hypdeployment.HypershiftDeployment, uploadURL string) []workv1.Manifest {
+	namespace := hostedControlPlaneNamespace(hyd)
+	name := backupJobName(hyd)
+	image := hyd.Spec.Migration.BackupImage
+	backoffLimit := int32(backupJobBackoffLimit)
+
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: corev1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string][]byte{BackupUploadURLKey: []byte(uploadURL)},
+	}
+
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{Kind: "Job", APIVersion: batchv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{
+						{
+							Name:  "snapshot",
+							Image: image,
+							Command: []string{"etcdctl",
+								"--cacert", "/etc/etcd/tls/client/etcd-client-ca.crt",
+								"--cert", "/etc/etcd/tls/client/etcd-client.crt",
+								"--key", "/etc/etcd/tls/client/etcd-client.key",
+								"--endpoints", "https://etcd-client:2379",
+								"snapshot", "save", "/backup/snapshot.db"},
+							Env: []corev1.EnvVar{{Name: "ETCDCTL_API", Value: "3"}},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "client-tls", MountPath: "/etc/etcd/tls/client"},
+								{Name: "backup", MountPath: "/backup"},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "upload",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", `curl -sSf -X PUT -T /backup/snapshot.db "$UPLOAD_URL"`},
+							Env: []corev1.EnvVar{
+								{
+									Name: "UPLOAD_URL",
+									ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+										LocalObjectReference: corev1.LocalObjectReference{Name: name},
+										Key:                  BackupUploadURLKey,
+									}},
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{{Name: "backup", MountPath: "/backup"}},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name:         "client-tls",
+							VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "etcd-client-tls"}},
+						},
+						{
+							Name:         "backup",
+							VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return []workv1.Manifest{
+		{RawExtension: runtime.RawExtension{Object: secret}},
+		{RawExtension: runtime.RawExtension{Object: job}},
+	}
+}
+
+func backupFeedbackConfig(hyd *hypdeployment.HypershiftDeployment) []workv1.ManifestConfigOption {
+	return []workv1.ManifestConfigOption{
+		{
+			ResourceIdentifier: workv1.ResourceIdentifier{
+				Group:     batchv1.GroupName,
+				Resource:  "jobs",
+				Name:      backupJobName(hyd),
+				Namespace: hostedControlPlaneNamespace(hyd),
+			},
+			FeedbackRules: []workv1.FeedbackRule{
+				{
+					Type: workv1.JSONPathsType,
+					JsonPaths: []workv1.JsonPath{
+						{Name: backupJobSucceeded, Path: ".status.succeeded"},
+						{Name: backupJobFailed, Path: ".status.failed"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func backupJobFeedback(bw *workv1.ManifestWork, hyd *hypdeployment.HypershiftDeployment) (int64, int64) {
+	var succeeded, failed int64
+	for _, obj := range bw.Status.ResourceStatus.Manifests {
+		if obj.ResourceMeta.Resource != "jobs" || obj.ResourceMeta.Name != backupJobName(hyd) {
+			continue
+		}
+
+		for _, v := range obj.StatusFeedbacks.Values {
+			if v.Value.Integer == nil {
+				continue
+			}
+			switch v.Name {
+			case backupJobSucceeded:
+				succeeded = *v.Value.Integer
+			case backupJobFailed:
+				failed = *v.Value.Integer
+			}
+		}
+	}
+	return succeeded, failed
+}
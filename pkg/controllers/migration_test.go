@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+type fakeDNSSwitcher struct {
+	source, target string
+}
+
+func (f *fakeDNSSwitcher) SwitchDNS(ctx context.Context, hyd *hyd.HypershiftDeployment, source, target string) error {
+	f.source, f.target = source, target
+	return nil
+}
+
+// setWorkApplied sets the Applied condition of a manifestwork and its status feedback, as the work agent does
+func setWorkApplied(t *testing.T, c client.Client, key types.NamespacedName, feedback ...workv1.ManifestCondition) {
+	m := &workv1.ManifestWork{}
+	assert.Nil(t, c.Get(context.Background(), key, m))
+	meta.SetStatusCondition(&m.Status.Conditions, metav1.Condition{
+		Type:               workv1.WorkApplied,
+		Status:             metav1.ConditionTrue,
+		Reason:             "AppliedManifestWorkComplete",
+		ObservedGeneration: m.Generation,
+	})
+	m.Status.ResourceStatus.Manifests = feedback
+	assert.Nil(t, c.Status().Update(context.Background(), m))
+}
+
+func getManifestWorkHostedCluster(t *testing.T, c client.Client, key types.NamespacedName) *unstructured.Unstructured {
+	m := &workv1.ManifestWork{}
+	assert.Nil(t, c.Get(context.Background(), key, m))
+	for _, manifest := range m.Spec.Workload.Manifests {
+		u, err := manifestObject(manifest)
+		assert.Nil(t, err)
+		if u.GetKind() == "HostedCluster" {
+			return u
+		}
+	}
+	return nil
+}
+
+func stringFeedback(name, value string) workv1.FeedbackValue {
+	return workv1.FeedbackValue{Name: name, Value: workv1.FieldValue{Type: workv1.String, String: &value}}
+}
+
+func TestMigration(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+	assert.Nil(t, client.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: testHD.Namespace},
+		Data: map[string][]byte{
+			BackupUploadURLKey:  []byte("https://bucket/snapshot?put"),
+			BackupRestoreURLKey: []byte("https://bucket/snapshot?get"),
+		},
+	}))
+
+	dns := &fakeDNSSwitcher{}
+	hdr := &HypershiftDeploymentReconciler{
+		Client:      client,
+		Log:         ctrl.Log.WithName("tester"),
+		DNSSwitcher: dns,
+	}
+
+	reconcile := func() *hyd.HypershiftDeployment {
+		_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+		assert.Nil(t, err, "err nil when reconcile was successfull")
+
+		resultHD := &hyd.HypershiftDeployment{}
+		assert.Nil(t, client.Get(ctx, getNN, resultHD))
+		return resultHD
+	}
+	step := func(resultHD *hyd.HypershiftDeployment) hyd.MigrationStep {
+		if resultHD.Status.Migration == nil {
+			return ""
+		}
+		return resultHD.Status.Migration.Step
+	}
+
+	resultHD := reconcile()
+	assert.Equal(t, "local-cluster", resultHD.Status.HostingCluster, "the hosting cluster is recorded")
+	sourceKey := getManifestWorkKey(resultHD)
+	assert.Equal(t, "local-cluster", sourceKey.Namespace)
+
+	resultHD.Spec.HostingCluster = "cluster2"
+	resultHD.Spec.Migration = &hyd.MigrationSpec{
+		BackupSecret: &corev1.LocalObjectReference{Name: "backup"},
+		BackupImage:  "etcd:latest",
+	}
+	assert.Nil(t, client.Update(ctx, resultHD))
+	targetKey := types.NamespacedName{Namespace: "cluster2", Name: sourceKey.Name}
+
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepPausing, step(resultHD))
+	assert.Equal(t, hyd.PhaseMigrating, resultHD.Status.Phase)
+	assert.Equal(t, "local-cluster", resultHD.Status.HostingCluster, "the source is used until the migration completes")
+
+	// Pausing
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepPausing, step(resultHD))
+	paused, _, _ := unstructured.NestedString(getManifestWorkHostedCluster(t, client, sourceKey).Object, "spec", "pausedUntil")
+	assert.Equal(t, "true", paused)
+
+	setWorkApplied(t, client, sourceKey)
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepBackingUp, step(resultHD))
+	assert.True(t, meta.IsStatusConditionTrue(resultHD.Status.Conditions, string(hyd.MigrationSourcePaused)))
+
+	// BackingUp
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepBackingUp, step(resultHD))
+	backupKey := types.NamespacedName{Namespace: "local-cluster", Name: sourceKey.Name + "-etcd-backup"}
+	bw := &workv1.ManifestWork{}
+	assert.Nil(t, client.Get(ctx, backupKey, bw))
+	assert.Len(t, bw.Spec.Workload.Manifests, 2, "a secret and a job")
+
+	succeeded := int64(1)
+	setWorkApplied(t, client, backupKey, workv1.ManifestCondition{
+		ResourceMeta: workv1.ManifestResourceMeta{Group: "batch", Resource: "jobs", Name: "test1-etcd-backup", Namespace: "default-test1"},
+		StatusFeedbacks: workv1.StatusFeedbackResult{Values: []workv1.FeedbackValue{
+			{Name: backupJobSucceeded, Value: workv1.FieldValue{Type: workv1.Integer, Integer: &succeeded}},
+		}},
+	})
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepApplying, step(resultHD))
+
+	// Applying
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepApplying, step(resultHD))
+	restoreURLs, _, _ := unstructured.NestedStringSlice(getManifestWorkHostedCluster(t, client, targetKey).Object,
+		"spec", "etcd", "managed", "storage", "restoreSnapshotURL")
+	assert.Equal(t, []string{"https://bucket/snapshot?get"}, restoreURLs)
+
+	setWorkApplied(t, client, targetKey)
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepRestoring, step(resultHD))
+
+	// Restoring
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepRestoring, step(resultHD))
+
+	setWorkApplied(t, client, targetKey, workv1.ManifestCondition{
+		ResourceMeta: workv1.ManifestResourceMeta{Group: "hypershift.openshift.io", Resource: HostedClusterResource, Name: "test1", Namespace: "default"},
+		StatusFeedbacks: workv1.StatusFeedbackResult{Values: []workv1.FeedbackValue{
+			stringFeedback(Reason, "HostedClusterAsExpected"),
+			stringFeedback(StatusFlag, "True"),
+		}},
+	})
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepSwitchingDNS, step(resultHD))
+
+	// SwitchingDNS
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepCleaningUp, step(resultHD))
+	assert.Equal(t, "local-cluster", dns.source)
+	assert.Equal(t, "cluster2", dns.target)
+
+	// CleaningUp
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepCleaningUp, step(resultHD))
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, backupKey, bw)), "the backup manifestwork is deleted")
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, sourceKey, &workv1.ManifestWork{})), "the source manifestwork is deleted")
+
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepCompleted, step(resultHD))
+	assert.Equal(t, "cluster2", resultHD.Status.HostingCluster)
+	assert.Equal(t, []string{"https://bucket/snapshot?get"}, resultHD.Spec.HostedClusterSpec.Etcd.Managed.Storage.RestoreSnapshotURL)
+	assert.Contains(t, resultHD.Status.Migration.SourceLeftovers, "HostedCluster default/test1", "the orphaned HostedCluster is reported")
+	assert.Contains(t, resultHD.Status.Migration.SourceLeftovers, "Namespace "+hostedControlPlaneNamespace(resultHD))
+	for _, s := range migrationSteps {
+		assert.True(t, meta.IsStatusConditionTrue(resultHD.Status.Conditions, string(s.condition)), s.condition)
+	}
+	assert.True(t, meta.IsStatusConditionFalse(resultHD.Status.Conditions, string(hyd.Migrating)))
+
+	// The manifestwork of the target is reconciled as usual, without pausing the HostedCluster
+	resultHD = reconcile()
+	assert.Equal(t, targetKey, getManifestWorkKey(resultHD))
+	hc := getManifestWorkHostedCluster(t, client, targetKey)
+	_, found, _ := unstructured.NestedString(hc.Object, "spec", "pausedUntil")
+	assert.False(t, found)
+	restoreURLs, _, _ = unstructured.NestedStringSlice(hc.Object, "spec", "etcd", "managed", "storage", "restoreSnapshotURL")
+	assert.Equal(t, []string{"https://bucket/snapshot?get"}, restoreURLs)
+}
+
+func TestMigrationRollback(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	reconcile := func() *hyd.HypershiftDeployment {
+		_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+		assert.Nil(t, err, "err nil when reconcile was successfull")
+
+		resultHD := &hyd.HypershiftDeployment{}
+		assert.Nil(t, client.Get(ctx, getNN, resultHD))
+		return resultHD
+	}
+
+	resultHD := reconcile()
+	sourceKey := getManifestWorkKey(resultHD)
+
+	resultHD.Spec.HostingCluster = "cluster2"
+	assert.Nil(t, client.Update(ctx, resultHD))
+
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepPausing, resultHD.Status.Migration.Step)
+
+	// The work agent does not apply the paused HostedCluster in time
+	resultHD.Status.Migration.StepStartTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	assert.Nil(t, client.Status().Update(ctx, resultHD))
+
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepRollingBack, resultHD.Status.Migration.Step)
+	assert.Equal(t, hyd.MigrationStepPausing, resultHD.Status.Migration.FailedStep)
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.MigrationSourcePaused))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, hyd.MigrationRolledBackReason, c.Reason)
+		assert.Contains(t, c.Message, "did not complete in 30m0s")
+	}
+
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepRolledBack, resultHD.Status.Migration.Step)
+	c = meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.Migrating))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, metav1.ConditionFalse, c.Status)
+		assert.Equal(t, hyd.MigrationRolledBackReason, c.Reason)
+	}
+
+	// The failed target is not tried again, and the HostedCluster is resumed on the source
+	resultHD = reconcile()
+	assert.Equal(t, hyd.MigrationStepRolledBack, resultHD.Status.Migration.Step)
+	assert.Equal(t, "local-cluster", resultHD.Status.HostingCluster)
+	_, found, _ := unstructured.NestedString(getManifestWorkHostedCluster(t, client, sourceKey).Object, "spec", "pausedUntil")
+	assert.False(t, found)
+
+	// Setting the hosting cluster back forgets the migration
+	resultHD.Spec.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Update(ctx, resultHD))
+
+	resultHD = reconcile()
+	assert.Nil(t, resultHD.Status.Migration)
+}
+
+func TestMigrationWithoutManifestWork(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "cluster2"
+	testHD.Status.HostingCluster = "local-cluster"
+	assert.Nil(t, client.Create(ctx, testHD))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+		ctx:    ctx,
+	}
+
+	_, migrating, err := hdr.reconcileMigration(ctx, testHD)
+	assert.Nil(t, err)
+	assert.False(t, migrating, "nothing runs on the source hosting cluster")
+	assert.Nil(t, testHD.Status.Migration)
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.Equal(t, "cluster2", resultHD.Status.HostingCluster)
+}
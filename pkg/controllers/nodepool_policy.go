@@ -0,0 +1,277 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+const scaleDownWindowLayout = "15:04"
+
+var scaleDownWindowDays = map[string]time.Weekday{
+	"Sun": time.Sunday,
+	"Mon": time.Monday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+	"Thu": time.Thursday,
+	"Fri": time.Friday,
+	"Sat": time.Saturday,
+}
+
+// nodePoolZones are the AWS zones a NodePool policy is spread across, in the order of
+// Spec.Infrastructure.Platform.AWS.Zones. The subnet is known once the infrastructure is created
+func nodePoolZones(hyd *hypdeployment.HypershiftDeployment) []hypdeployment.AWSZoneStatus {
+	created := []hypdeployment.AWSZoneStatus{}
+	if hyd.Status.Infrastructure != nil && hyd.Status.Infrastructure.AWS != nil {
+		created = hyd.Status.Infrastructure.AWS.Zones
+	}
+
+	platform := hyd.Spec.Infrastructure.Platform
+	if platform == nil || platform.AWS == nil || len(platform.AWS.Zones) == 0 {
+		return created
+	}
+
+	zones := []hypdeployment.AWSZoneStatus{}
+	for _, name := range platform.AWS.Zones {
+		zone := hypdeployment.AWSZoneStatus{Name: name}
+		for _, c := range created {
+			if c.Name == name {
+				zone.SubnetID = c.SubnetID
+			}
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
+// expandedNodePoolNames are the names of the NodePools applied for a HypershiftNodePools entry. The NodePool of the
+// first zone keeps the name of the entry, so adding a policy to an applied NodePool does not replace it and its
+// nodes, the other zones are named <name>-<zone>
+func expandedNodePoolNames(hyd *hypdeployment.HypershiftDeployment, np *hypdeployment.HypershiftNodePools) []string {
+	zones := nodePoolZones(hyd)
+	if np.Policy == nil || len(zones) < 2 {
+		return []string{np.Name}
+	}
+
+	names := []string{np.Name}
+	for _, zone := range zones[1:] {
+		names = append(names, np.Name+"-"+zone.Name)
+	}
+	return names
+}
+
+// nodePoolZonesWithoutSubnet lists the zones of a NodePool with a policy that have no subnet, the NodePools of these
+// zones would share the subnet of the NodePool spec
+func nodePoolZonesWithoutSubnet(hyd *hypdeployment.HypershiftDeployment, np *hypdeployment.HypershiftNodePools) []string {
+	zones := nodePoolZones(hyd)
+	if np.Policy == nil || np.Spec.Platform.AWS == nil || len(zones) < 2 {
+		return nil
+	}
+
+	missing := []string{}
+	for _, zone := range zones {
+		if len(zone.SubnetID) == 0 {
+			missing = append(missing, zone.Name)
+		}
+	}
+	return missing
+}
+
+// expandNodePools returns the NodePools applied to the hosting cluster, the NodePools with a policy are expanded
+// per zone and sized for the time given
+func expandNodePools(hyd *hypdeployment.HypershiftDeployment, now time.Time) []*hypdeployment.HypershiftNodePools {
+	nodePools := []*hypdeployment.HypershiftNodePools{}
+	for _, np := range hyd.Spec.NodePools {
+		if np.Policy == nil {
+			nodePools = append(nodePools, np)
+			continue
+		}
+		nodePools = append(nodePools, expandNodePool(hyd, np, now)...)
+	}
+	return nodePools
+}
+
+func expandNodePool(hyd *hypdeployment.HypershiftDeployment, np *hypdeployment.HypershiftNodePools, now time.Time) []*hypdeployment.HypershiftNodePools {
+	zones := nodePoolZones(hyd)
+	names := expandedNodePoolNames(hyd, np)
+
+	var replicas, minNodes, maxNodes []int32
+	switch window := openScaleDownWindow(np.Policy, now); {
+	case window != nil:
+		replicas = spreadNodes(window.Replicas, len(names), 0)
+	case np.Policy.AutoScaling != nil:
+		minNodes = spreadNodes(np.Policy.AutoScaling.Min, len(names), 1)
+		maxNodes = spreadNodes(np.Policy.AutoScaling.Max, len(names), 1)
+	case np.Policy.Replicas != nil:
+		replicas = spreadNodes(*np.Policy.Replicas, len(names), 0)
+	}
+
+	nodePools := []*hypdeployment.HypershiftNodePools{}
+	for i, name := range names {
+		spec := np.Spec.DeepCopy()
+		spec.Replicas = nil
+		spec.AutoScaling = nil
+		if replicas != nil {
+			spec.Replicas = &replicas[i]
+		}
+		if minNodes != nil {
+			if maxNodes[i] < minNodes[i] {
+				maxNodes[i] = minNodes[i]
+			}
+			spec.AutoScaling = &hyp.NodePoolAutoScaling{Min: minNodes[i], Max: maxNodes[i]}
+		}
+
+		// Each zone has its own subnet
+		if len(names) > 1 && spec.Platform.AWS != nil && len(zones[i].SubnetID) != 0 {
+			subnetID := zones[i].SubnetID
+			spec.Platform.AWS.Subnet = &hyp.AWSResourceReference{ID: &subnetID}
+		}
+
+		nodePools = append(nodePools, &hypdeployment.HypershiftNodePools{Name: name, Spec: *spec})
+	}
+	return nodePools
+}
+
+// spreadNodes divides total nodes across n NodePools, the first NodePools get the remainder. Each NodePool gets at
+// least the minimum
+func spreadNodes(total int32, n int, minimum int32) []int32 {
+	nodes := make([]int32, n)
+	for i := range nodes {
+		nodes[i] = total / int32(n)
+		if int32(i) < total%int32(n) {
+			nodes[i]++
+		}
+		if nodes[i] < minimum {
+			nodes[i] = minimum
+		}
+	}
+	return nodes
+}
+
+// scaleDownWindowTimes returns when the window opens and closes for a start on the day of t
+func scaleDownWindowTimes(w hypdeployment.ScaleDownWindow, t time.Time) (time.Time, time.Time, error) {
+	start, err := time.Parse(scaleDownWindowLayout, w.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start %q, err: %w", w.Start, err)
+	}
+	end, err := time.Parse(scaleDownWindowLayout, w.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end %q, err: %w", w.End, err)
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	opens := day.Add(time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute)
+	closes := day.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute)
+	if !closes.After(opens) {
+		closes = closes.Add(24 * time.Hour)
+	}
+	return opens, closes, nil
+}
+
+func scaleDownWindowOpensOn(w hypdeployment.ScaleDownWindow, day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if wd, ok := scaleDownWindowDays[d]; ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// openScaleDownWindow returns the first window of the policy that is open at the time given
+func openScaleDownWindow(policy *hypdeployment.NodePoolPolicy, now time.Time) *hypdeployment.ScaleDownWindow {
+	now = now.UTC()
+	for i, w := range policy.ScaleDownWindows {
+		// A window that opened the day before can still be open
+		for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+			if !scaleDownWindowOpensOn(w, day.Weekday()) {
+				continue
+			}
+			opens, closes, err := scaleDownWindowTimes(w, day)
+			if err == nil && !now.Before(opens) && now.Before(closes) {
+				return &policy.ScaleDownWindows[i]
+			}
+		}
+	}
+	return nil
+}
+
+// nextScaleDownWindowChange is the time until a scale down window of the NodePools opens or closes, 0 when there
+// is no window
+func nextScaleDownWindowChange(hyd *hypdeployment.HypershiftDeployment, now time.Time) time.Duration {
+	now = now.UTC()
+	next := time.Duration(0)
+	for _, np := range hyd.Spec.NodePools {
+		if np.Policy == nil {
+			continue
+		}
+		for _, w := range np.Policy.ScaleDownWindows {
+			for d := -1; d <= 7; d++ {
+				day := now.AddDate(0, 0, d)
+				if !scaleDownWindowOpensOn(w, day.Weekday()) {
+					continue
+				}
+				opens, closes, err := scaleDownWindowTimes(w, day)
+				if err != nil {
+					break
+				}
+				for _, t := range []time.Time{opens, closes} {
+					if after := t.Sub(now); after > 0 && (next == 0 || after < next) {
+						next = after
+					}
+				}
+			}
+		}
+	}
+	return next
+}
+
+// validateNodePoolPolicy returns why the policy of a NodePool is invalid, or an empty string
+func validateNodePoolPolicy(policy *hypdeployment.NodePoolPolicy) string {
+	switch {
+	case policy.Replicas == nil && policy.AutoScaling == nil:
+		return "one of replicas or autoScaling is required"
+	case policy.Replicas != nil && policy.AutoScaling != nil:
+		return "replicas and autoScaling can not be used together"
+	case policy.Replicas != nil && *policy.Replicas < 0:
+		return "replicas can not be negative"
+	case policy.AutoScaling != nil && (policy.AutoScaling.Min < 1 || policy.AutoScaling.Max < policy.AutoScaling.Min):
+		return "autoScaling needs 1 <= min <= max"
+	}
+
+	for _, w := range policy.ScaleDownWindows {
+		if _, _, err := scaleDownWindowTimes(w, time.Now()); err != nil {
+			return "scaleDownWindows: " + err.Error()
+		}
+		for _, d := range w.Days {
+			if _, ok := scaleDownWindowDays[d]; !ok {
+				return fmt.Sprintf("scaleDownWindows: invalid day %q", d)
+			}
+		}
+		if w.Replicas < 0 {
+			return "scaleDownWindows: replicas can not be negative"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func getHDwithNodePoolPolicy(policy *hyd.NodePoolPolicy) *hyd.HypershiftDeployment {
+	testHD := getHDforManifestWork()
+	testHD.Spec.Infrastructure.Platform.AWS.Zones = []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+	testHD.Status.Infrastructure = &hyd.InfrastructureStatus{AWS: &hyd.AWSInfrastructureStatus{
+		Zones: []hyd.AWSZoneStatus{
+			{Name: "us-east-1c", SubnetID: "subnet-c"},
+			{Name: "us-east-1a", SubnetID: "subnet-a"},
+			{Name: "us-east-1b", SubnetID: "subnet-b"},
+		},
+	}}
+	testHD.Spec.NodePools[0].Name = "workers"
+	testHD.Spec.NodePools[0].Policy = policy
+	return testHD
+}
+
+func TestSpreadNodes(t *testing.T) {
+	assert.Equal(t, []int32{3, 2, 2}, spreadNodes(7, 3, 0))
+	assert.Equal(t, []int32{1, 1, 0}, spreadNodes(2, 3, 0))
+	assert.Equal(t, []int32{1, 1, 1}, spreadNodes(2, 3, 1), "each zone has the minimum")
+	assert.Equal(t, []int32{4}, spreadNodes(4, 1, 0))
+}
+
+func TestExpandNodePoolsReplicas(t *testing.T) {
+	testHD := getHDwithNodePoolPolicy(&hyd.NodePoolPolicy{Replicas: int32Ptr(5)})
+	testHD.Spec.NodePools[0].Spec.Replicas = int32Ptr(9)
+
+	nodePools := expandNodePools(testHD, time.Now())
+	if assert.Len(t, nodePools, 3) {
+		assert.Equal(t, "workers", nodePools[0].Name, "the first zone keeps the name of the NodePool")
+		assert.Equal(t, "workers-us-east-1b", nodePools[1].Name)
+		assert.Equal(t, "workers-us-east-1c", nodePools[2].Name)
+
+		assert.Equal(t, int32(2), *nodePools[0].Spec.Replicas)
+		assert.Equal(t, int32(2), *nodePools[1].Spec.Replicas)
+		assert.Equal(t, int32(1), *nodePools[2].Spec.Replicas)
+
+		assert.Equal(t, "subnet-a", *nodePools[0].Spec.Platform.AWS.Subnet.ID)
+		assert.Equal(t, "subnet-c", *nodePools[2].Spec.Platform.AWS.Subnet.ID)
+	}
+	assert.Equal(t, int32(9), *testHD.Spec.NodePools[0].Spec.Replicas, "the spec is not changed")
+
+	assert.Equal(t, []string{"workers", "workers-us-east-1b", "workers-us-east-1c"},
+		expandedNodePoolNames(testHD, testHD.Spec.NodePools[0]))
+}
+
+func TestExpandNodePoolsWithoutZones(t *testing.T) {
+	testHD := getHDwithNodePoolPolicy(&hyd.NodePoolPolicy{Replicas: int32Ptr(5)})
+	testHD.Spec.Infrastructure.Platform.AWS.Zones = nil
+	testHD.Status.Infrastructure = nil
+
+	nodePools := expandNodePools(testHD, time.Now())
+	if assert.Len(t, nodePools, 1) {
+		assert.Equal(t, "workers", nodePools[0].Name)
+		assert.Equal(t, int32(5), *nodePools[0].Spec.Replicas)
+	}
+
+	testHD.Spec.NodePools[0].Policy = nil
+	assert.Equal(t, testHD.Spec.NodePools, expandNodePools(testHD, time.Now()), "a NodePool without a policy is unchanged")
+}
+
+func TestNodePoolZonesWithoutSubnet(t *testing.T) {
+	testHD := getHDwithNodePoolPolicy(&hyd.NodePoolPolicy{Replicas: int32Ptr(5)})
+	assert.Empty(t, nodePoolZonesWithoutSubnet(testHD, testHD.Spec.NodePools[0]))
+
+	testHD.Status.Infrastructure.AWS.Zones = testHD.Status.Infrastructure.AWS.Zones[:2]
+	assert.Equal(t, []string{"us-east-1b"}, nodePoolZonesWithoutSubnet(testHD, testHD.Spec.NodePools[0]))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: initClient(),
+		Log:    ctrl.Log.WithName("tester"),
+	}
+	payload := []workv1.Manifest{}
+	assert.NotNil(t, hdr.appendNodePool(context.Background())(testHD, &payload), "the NodePools would share a subnet")
+
+	testHD.Spec.NodePools[0].Policy = nil
+	assert.Empty(t, nodePoolZonesWithoutSubnet(testHD, testHD.Spec.NodePools[0]), "a NodePool without a policy is not spread")
+}
+
+func TestExpandNodePoolsAutoScaling(t *testing.T) {
+	testHD := getHDwithNodePoolPolicy(&hyd.NodePoolPolicy{AutoScaling: &hyd.NodePoolAutoScalingPolicy{Min: 2, Max: 7}})
+
+	nodePools := expandNodePools(testHD, time.Now())
+	if assert.Len(t, nodePools, 3) {
+		for i, limits := range [][2]int32{{1, 3}, {1, 2}, {1, 2}} {
+			assert.Nil(t, nodePools[i].Spec.Replicas)
+			assert.Equal(t, limits[0], nodePools[i].Spec.AutoScaling.Min)
+			assert.Equal(t, limits[1], nodePools[i].Spec.AutoScaling.Max)
+		}
+	}
+}
+
+func TestExpandNodePoolsScaleDownWindow(t *testing.T) {
+	testHD := getHDwithNodePoolPolicy(&hyd.NodePoolPolicy{
+		AutoScaling: &hyd.NodePoolAutoScalingPolicy{Min: 3, Max: 9},
+		ScaleDownWindows: []hyd.ScaleDownWindow{
+			{Start: "20:00", End: "06:00", Replicas: 3},
+			{Days: []string{"Sat", "Sun"}, Start: "00:00", End: "00:00", Replicas: 0},
+		},
+	})
+
+	// Friday 2022-09-16
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2022, time.September, 16, hour, minute, 0, 0, time.UTC)
+	}
+
+	nodePools := expandNodePools(testHD, friday(12, 0))
+	assert.NotNil(t, nodePools[0].Spec.AutoScaling, "no window is open")
+	assert.Equal(t, 8*time.Hour, nextScaleDownWindowChange(testHD, friday(12, 0)))
+
+	nodePools = expandNodePools(testHD, friday(22, 0))
+	assert.Nil(t, nodePools[0].Spec.AutoScaling)
+	assert.Equal(t, int32(1), *nodePools[0].Spec.Replicas)
+	assert.Equal(t, 2*time.Hour, nextScaleDownWindowChange(testHD, friday(22, 0)), "the weekend window opens at midnight")
+
+	nodePools = expandNodePools(testHD, friday(5, 0))
+	assert.Equal(t, int32(1), *nodePools[0].Spec.Replicas, "the window of thursday evening is still open")
+
+	saturday := friday(12, 0).AddDate(0, 0, 1)
+	nodePools = expandNodePools(testHD, saturday)
+	assert.Equal(t, int32(0), *nodePools[0].Spec.Replicas, "the weekend window")
+
+	testHD.Spec.NodePools[0].Policy.ScaleDownWindows = nil
+	assert.Zero(t, nextScaleDownWindowChange(testHD, saturday))
+}
+
+func TestValidateNodePoolPolicy(t *testing.T) {
+	assert.Empty(t, validateNodePoolPolicy(&hyd.NodePoolPolicy{Replicas: int32Ptr(3)}))
+	assert.NotEmpty(t, validateNodePoolPolicy(&hyd.NodePoolPolicy{}))
+	assert.NotEmpty(t, validateNodePoolPolicy(&hyd.NodePoolPolicy{
+		Replicas:    int32Ptr(3),
+		AutoScaling: &hyd.NodePoolAutoScalingPolicy{Min: 1, Max: 2},
+	}))
+	assert.NotEmpty(t, validateNodePoolPolicy(&hyd.NodePoolPolicy{AutoScaling: &hyd.NodePoolAutoScalingPolicy{Min: 3, Max: 2}}))
+	assert.NotEmpty(t, validateNodePoolPolicy(&hyd.NodePoolPolicy{
+		Replicas:         int32Ptr(3),
+		ScaleDownWindows: []hyd.ScaleDownWindow{{Start: "25:00", End: "06:00"}},
+	}))
+	assert.NotEmpty(t, validateNodePoolPolicy(&hyd.NodePoolPolicy{
+		Replicas:         int32Ptr(3),
+		ScaleDownWindows: []hyd.ScaleDownWindow{{Days: []string{"Friday"}, Start: "20:00", End: "06:00"}},
+	}))
+}
+
+func TestManifestWorkNodePoolPolicy(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDwithNodePoolPolicy(&hyd.NodePoolPolicy{Replicas: int32Ptr(6)})
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	payload := []workv1.Manifest{}
+	assert.Nil(t, hdr.appendNodePool(ctx)(testHD, &payload))
+
+	names := []string{}
+	for _, manifest := range payload {
+		u, err := manifestObject(manifest)
+		assert.Nil(t, err)
+		names = append(names, u.GetName())
+	}
+	assert.Equal(t, []string{"workers", "workers-us-east-1b", "workers-us-east-1c"}, names)
+
+	configs := getManifestWorkConfigs(testHD)
+	for _, name := range names {
+		_, ok := configs[workv1.ResourceIdentifier{
+			Group:     "hypershift.openshift.io",
+			Resource:  NodePoolResource,
+			Name:      name,
+			Namespace: helper.GetHostingNamespace(testHD),
+		}]
+		assert.True(t, ok, "status feedback is collected for "+name)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// pauseReconcile sets the Paused condition, the caller returns without touching the infrastructure or the
+// ManifestWork. The DestroyFinalizer is kept, so a deletion waits until the HypershiftDeployment is resumed
+func (r *HypershiftDeploymentReconciler) pauseReconcile(hyd *hypdeployment.HypershiftDeployment) error {
+	message := "Reconciliation is paused, set Spec.Paused to false to resume"
+	if hyd.DeletionTimestamp != nil {
+		message = "Deletion is blocked until Spec.Paused is set to false"
+	}
+
+	if !meta.IsStatusConditionTrue(hyd.Status.Conditions, string(hypdeployment.Paused)) {
+		r.recordEvent(hyd, corev1.EventTypeNormal, ReconcilePausedEvent, message)
+	}
+
+	return r.updateStatusConditionsOnChange(hyd, hypdeployment.Paused, metav1.ConditionTrue, message,
+		hypdeployment.ReconcilePausedReason)
+}
+
+// resumeReconcile removes the Paused condition once Spec.Paused is unset
+func (r *HypershiftDeploymentReconciler) resumeReconcile(hyd *hypdeployment.HypershiftDeployment) error {
+	if meta.FindStatusCondition(hyd.Status.Conditions, string(hypdeployment.Paused)) == nil {
+		return nil
+	}
+
+	r.recordEvent(hyd, corev1.EventTypeNormal, ReconcileResumedEvent, "Reconciliation is resumed")
+
+	inHyd := hyd.DeepCopy()
+	meta.RemoveStatusCondition(&hyd.Status.Conditions, string(hypdeployment.Paused))
+	setPhase(hyd)
+	return r.Client.Status().Patch(r.ctx, hyd, client.MergeFrom(inHyd))
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+func TestPausedReconcile(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Paused = true
+
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Create(ctx, getPullSecret(testHD)))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err, "err nil when reconcile was successfull")
+
+	mw := &workv1.ManifestWork{}
+	assert.True(t, apierrors.IsNotFound(client.Get(ctx, getManifestWorkKey(testHD), mw)),
+		"the manifestwork is not applied while paused")
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.True(t, meta.IsStatusConditionTrue(resultHD.Status.Conditions, string(hyd.Paused)))
+	assert.Equal(t, hyd.PhasePaused, resultHD.Status.Phase)
+
+	// Resume
+	resultHD.Spec.Paused = false
+	assert.Nil(t, client.Update(ctx, resultHD))
+
+	_, err = hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err, "err nil when reconcile was successfull")
+
+	assert.Nil(t, client.Get(ctx, getManifestWorkKey(testHD), mw))
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.Nil(t, meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.Paused)))
+	assert.NotEqual(t, hyd.PhasePaused, resultHD.Status.Phase)
+}
+
+func TestPausedDeletion(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.Paused = true
+	controllerutil.AddFinalizer(testHD, constant.DestroyFinalizer)
+
+	assert.Nil(t, client.Create(ctx, testHD))
+	assert.Nil(t, client.Delete(ctx, testHD))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+	}
+
+	_, err := hdr.Reconcile(ctx, ctrl.Request{NamespacedName: getNN})
+	assert.Nil(t, err, "err nil when reconcile was successfull")
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD), "the deletion is blocked while paused")
+	assert.Contains(t, resultHD.Finalizers, constant.DestroyFinalizer)
+
+	c := meta.FindStatusCondition(resultHD.Status.Conditions, string(hyd.Paused))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, metav1.ConditionTrue, c.Status)
+		assert.Equal(t, "Deletion is blocked until Spec.Paused is set to false", c.Message)
+	}
+}
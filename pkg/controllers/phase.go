@@ -0,0 +1,119 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// failedConditionTypes are the conditions set with MisConfiguredReason when the HypershiftDeployment
+// can not make progress without a change from the user
+var failedConditionTypes = []hypdeployment.ConditionType{
+	hypdeployment.ProviderSecretConfigured,
+	hypdeployment.PlatformConfigured,
+	hypdeployment.PlatformIAMConfigured,
+	hypdeployment.WorkConfigured,
+	hypdeployment.HostedClusterAdopted,
+	hypdeployment.Detached,
+}
+
+// computePhase derives the phase of the HypershiftDeployment from its conditions
+func computePhase(hyd *hypdeployment.HypershiftDeployment) hypdeployment.CurrentPhase {
+	conds := hyd.Status.Conditions
+
+	hasReason := func(t hypdeployment.ConditionType, reason string) bool {
+		c := meta.FindStatusCondition(conds, string(t))
+		return c != nil && c.Reason == reason
+	}
+
+	// A paused deletion waits for the HypershiftDeployment to be resumed
+	if meta.IsStatusConditionTrue(conds, string(hypdeployment.Paused)) {
+		return hypdeployment.PhasePaused
+	}
+
+	if hyd.DeletionTimestamp != nil {
+		if hasReason(hypdeployment.PlatformConfigured, hypdeployment.PlatfromDestroyReason) ||
+			hasReason(hypdeployment.PlatformIAMConfigured, hypdeployment.RemovingReason) {
+			return hypdeployment.PhaseDestroyingInfra
+		}
+		return hypdeployment.PhaseDeleting
+	}
+
+	if len(conds) == 0 {
+		return hypdeployment.PhasePending
+	}
+
+	if meta.IsStatusConditionTrue(conds, string(hypdeployment.InfrastructureFailed)) {
+		return hypdeployment.PhaseFailed
+	}
+
+	for _, t := range failedConditionTypes {
+		if meta.IsStatusConditionFalse(conds, string(t)) && hasReason(t, hypdeployment.MisConfiguredReason) {
+			return hypdeployment.PhaseFailed
+		}
+	}
+
+	if meta.IsStatusConditionTrue(conds, string(hypdeployment.Detached)) {
+		return hypdeployment.PhaseDetached
+	}
+
+	if meta.IsStatusConditionTrue(conds, string(hypdeployment.Migrating)) {
+		return hypdeployment.PhaseMigrating
+	}
+
+	if meta.IsStatusConditionTrue(conds, string(hypdeployment.UpgradeProgressing)) {
+		return hypdeployment.PhaseUpgrading
+	}
+
+	if meta.IsStatusConditionTrue(conds, string(hypdeployment.HostedClusterAvailable)) {
+		return hypdeployment.PhaseAvailable
+	}
+
+	if hyd.Spec.Infrastructure.Configure {
+		if !meta.IsStatusConditionTrue(conds, string(hypdeployment.PlatformConfigured)) {
+			return hypdeployment.PhaseConfiguringInfra
+		}
+		if !meta.IsStatusConditionTrue(conds, string(hypdeployment.PlatformIAMConfigured)) {
+			return hypdeployment.PhaseConfiguringIAM
+		}
+		// There is no HostedCluster to wait for
+		if hyd.Spec.IsInfrastructureOnly() {
+			return hypdeployment.PhaseAvailable
+		}
+	}
+
+	if !meta.IsStatusConditionTrue(conds, string(hypdeployment.WorkConfigured)) {
+		return hypdeployment.PhaseApplyingWork
+	}
+
+	return hypdeployment.PhaseProvisioning
+}
+
+// setPhase updates Status.Phase from the conditions, the transition time only moves when the phase changes
+func setPhase(hyd *hypdeployment.HypershiftDeployment) {
+	phase := computePhase(hyd)
+	if phase == hyd.Status.Phase && hyd.Status.PhaseTransitionTime != nil {
+		return
+	}
+
+	now := metav1.Now()
+	hyd.Status.Phase = phase
+	hyd.Status.PhaseTransitionTime = &now
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+func TestComputePhase(t *testing.T) {
+	cases := []struct {
+		name      string
+		configure bool
+		override  hyd.InfraOverride
+		deleting  bool
+		conds     []metav1.Condition
+		expected  hyd.CurrentPhase
+	}{
+		{
+			name:     "no conditions",
+			expected: hyd.PhasePending,
+		},
+		{
+			name:      "configuring infrastructure",
+			configure: true,
+			conds: []metav1.Condition{
+				{Type: string(hyd.ProviderSecretConfigured), Status: metav1.ConditionTrue, Reason: hyd.AsExpectedReason},
+				{Type: string(hyd.PlatformConfigured), Status: metav1.ConditionFalse, Reason: hyd.BeingConfiguredReason},
+			},
+			expected: hyd.PhaseConfiguringInfra,
+		},
+		{
+			name:      "configuring IAM",
+			configure: true,
+			conds: []metav1.Condition{
+				{Type: string(hyd.PlatformConfigured), Status: metav1.ConditionTrue, Reason: hyd.ConfiguredAsExpectedReason},
+				{Type: string(hyd.PlatformIAMConfigured), Status: metav1.ConditionFalse, Reason: hyd.BeingConfiguredReason},
+			},
+			expected: hyd.PhaseConfiguringIAM,
+		},
+		{
+			name:      "infrastructure only",
+			configure: true,
+			override:  hyd.InfraConfigureOnly,
+			conds: []metav1.Condition{
+				{Type: string(hyd.PlatformConfigured), Status: metav1.ConditionTrue, Reason: hyd.ConfiguredAsExpectedReason},
+				{Type: string(hyd.PlatformIAMConfigured), Status: metav1.ConditionTrue, Reason: hyd.ConfiguredAsExpectedReason},
+			},
+			expected: hyd.PhaseAvailable,
+		},
+		{
+			name: "applying the manifestwork",
+			conds: []metav1.Condition{
+				{Type: string(hyd.PlatformConfigured), Status: metav1.ConditionFalse, Reason: hyd.NotApplicableReason},
+				{Type: string(hyd.PlatformIAMConfigured), Status: metav1.ConditionFalse, Reason: hyd.NotApplicableReason},
+			},
+			expected: hyd.PhaseApplyingWork,
+		},
+		{
+			name: "provisioning",
+			conds: []metav1.Condition{
+				{Type: string(hyd.WorkConfigured), Status: metav1.ConditionTrue, Reason: hyd.ConfiguredAsExpectedReason},
+				{Type: string(hyd.HostedClusterAvailable), Status: metav1.ConditionFalse, Reason: "WaitingForAvailable"},
+			},
+			expected: hyd.PhaseProvisioning,
+		},
+		{
+			name: "available",
+			conds: []metav1.Condition{
+				{Type: string(hyd.WorkConfigured), Status: metav1.ConditionTrue, Reason: hyd.ConfiguredAsExpectedReason},
+				{Type: string(hyd.HostedClusterAvailable), Status: metav1.ConditionTrue, Reason: "HostedClusterAsExpected"},
+				{Type: string(hyd.UpgradeProgressing), Status: metav1.ConditionFalse, Reason: hyd.UpgradeCompletedReason},
+			},
+			expected: hyd.PhaseAvailable,
+		},
+		{
+			name: "upgrading",
+			conds: []metav1.Condition{
+				{Type: string(hyd.HostedClusterAvailable), Status: metav1.ConditionTrue, Reason: "HostedClusterAsExpected"},
+				{Type: string(hyd.UpgradeProgressing), Status: metav1.ConditionTrue, Reason: hyd.ControlPlaneUpgradingReason},
+			},
+			expected: hyd.PhaseUpgrading,
+		},
+		{
+			name: "migrating",
+			conds: []metav1.Condition{
+				{Type: string(hyd.HostedClusterAvailable), Status: metav1.ConditionTrue, Reason: "HostedClusterAsExpected"},
+				{Type: string(hyd.Migrating), Status: metav1.ConditionTrue, Reason: hyd.BeingConfiguredReason},
+			},
+			expected: hyd.PhaseMigrating,
+		},
+		{
+			name: "detached",
+			conds: []metav1.Condition{
+				{Type: string(hyd.HostedClusterAvailable), Status: metav1.ConditionTrue, Reason: "HostedClusterAsExpected"},
+				{Type: string(hyd.Detached), Status: metav1.ConditionTrue, Reason: hyd.DetachedReason},
+			},
+			expected: hyd.PhaseDetached,
+		},
+		{
+			name: "misconfigured",
+			conds: []metav1.Condition{
+				{Type: string(hyd.WorkConfigured), Status: metav1.ConditionFalse, Reason: hyd.MisConfiguredReason},
+			},
+			expected: hyd.PhaseFailed,
+		},
+		{
+			name:     "deleting",
+			deleting: true,
+			conds: []metav1.Condition{
+				{Type: string(hyd.WorkConfigured), Status: metav1.ConditionTrue, Reason: hyd.RemovingReason},
+			},
+			expected: hyd.PhaseDeleting,
+		},
+		{
+			name:      "destroying infrastructure",
+			configure: true,
+			deleting:  true,
+			conds: []metav1.Condition{
+				{Type: string(hyd.WorkConfigured), Status: metav1.ConditionFalse, Reason: hyd.RemovingReason},
+				{Type: string(hyd.PlatformConfigured), Status: metav1.ConditionFalse, Reason: hyd.PlatfromDestroyReason},
+			},
+			expected: hyd.PhaseDestroyingInfra,
+		},
+		{
+			name:     "paused deletion",
+			deleting: true,
+			conds: []metav1.Condition{
+				{Type: string(hyd.WorkConfigured), Status: metav1.ConditionTrue, Reason: hyd.ConfiguredAsExpectedReason},
+				{Type: string(hyd.Paused), Status: metav1.ConditionTrue, Reason: hyd.ReconcilePausedReason},
+			},
+			expected: hyd.PhasePaused,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testHD := getHypershiftDeployment("default", "test1", c.configure)
+			testHD.Spec.Override = c.override
+			testHD.Status.Conditions = c.conds
+			if c.deleting {
+				now := metav1.Now()
+				testHD.DeletionTimestamp = &now
+			}
+
+			assert.Equal(t, c.expected, computePhase(testHD))
+		})
+	}
+}
+
+func TestSetPhaseTransitionTime(t *testing.T) {
+	testHD := getHypershiftDeployment("default", "test1", true)
+
+	setStatusCondition(testHD, hyd.PlatformConfigured, metav1.ConditionFalse, "", hyd.BeingConfiguredReason)
+	assert.Equal(t, hyd.PhaseConfiguringInfra, testHD.Status.Phase)
+	assert.NotNil(t, testHD.Status.PhaseTransitionTime)
+
+	transition := metav1.NewTime(time.Now().Add(-time.Hour))
+	testHD.Status.PhaseTransitionTime = &transition
+	setStatusCondition(testHD, hyd.PlatformConfigured, metav1.ConditionFalse, "still configuring", hyd.BeingConfiguredReason)
+	assert.Equal(t, transition, *testHD.Status.PhaseTransitionTime, "the transition time is kept when the phase does not change")
+
+	setStatusCondition(testHD, hyd.PlatformConfigured, metav1.ConditionTrue, "", hyd.ConfiguredAsExpectedReason)
+	assert.Equal(t, hyd.PhaseConfiguringIAM, testHD.Status.Phase)
+	assert.True(t, testHD.Status.PhaseTransitionTime.After(transition.Time), "the transition time moves with the phase")
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	hydclient "github.com/stolostron/hypershift-deployment-controller/pkg/client"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+// placementLog is used by enqueueForPlacementDecision, it runs on the informer goroutines, outside of Reconcile
+var placementLog = ctrl.Log.WithName("placement")
+
+// selectHostingCluster chooses the hosting cluster from the PlacementDecisions of Spec.HostingClusterPlacement and
+// records it in Status.HostingCluster. The choice is kept afterwards, so it returns true without reading the
+// decisions once a cluster is recorded, or when Spec.HostingCluster is used
+func (r *HypershiftDeploymentReconciler) selectHostingCluster(ctx context.Context, hyd *hypdeployment.HypershiftDeployment) (bool, error) {
+	placement := hyd.Spec.HostingClusterPlacement
+	if len(hyd.Spec.HostingCluster) != 0 || placement == nil || len(hyd.Status.HostingCluster) != 0 {
+		return true, nil
+	}
+
+	clusters, err := r.decidedClusters(ctx, hyd.Namespace, placement.Name)
+	if err != nil {
+		return false, err
+	}
+
+	if len(clusters) == 0 {
+		r.Log.Info("Waiting for a PlacementDecision", "placement", placement.Name)
+		return false, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostingClusterSelected, metav1.ConditionFalse,
+			"Placement "+placement.Name+" has not selected a ManagedCluster", hypdeployment.WaitingForPlacementReason)
+	}
+
+	// The clusters passed the predicates and prioritizers of the Placement, the Placement may use a ManagedClusterSet
+	// the namespace is not allowed to host on, so they are filtered like Spec.HostingCluster is validated before the
+	// manifestwork is applied. The first one with capacity is chosen
+	clusters, err = r.permittedClusters(ctx, hyd, clusters)
+	if err != nil {
+		return false, err
+	}
+	if len(clusters) == 0 {
+		r.Log.Info("Waiting for a permitted hosting cluster", "placement", placement.Name)
+		return false, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostingClusterSelected, metav1.ConditionFalse,
+			"No ManagedCluster of placement "+placement.Name+" is a member of a ManagedClusterSet bound to namespace "+hyd.Namespace,
+			hypdeployment.WaitingForPlacementReason)
+	}
+
+	selected, err := r.firstClusterWithCapacity(ctx, hyd, clusters)
+	if err != nil {
+		return false, err
+	}
+	if len(selected) == 0 {
+		r.Log.Info("Waiting for a hosting cluster with capacity", "placement", placement.Name)
+		return false, r.updateStatusConditionsOnChange(hyd, hypdeployment.HostingClusterSelected, metav1.ConditionFalse,
+			"No ManagedCluster of placement "+placement.Name+" has capacity for the HostedCluster", hypdeployment.WaitingForCapacityReason)
+	}
+
+	inHyd := hyd.DeepCopy()
+	hyd.Status.HostingCluster = selected
+	message := fmt.Sprintf("Selected hosting cluster %s from placement %s", hyd.Status.HostingCluster, placement.Name)
+	setStatusCondition(hyd, hypdeployment.HostingClusterSelected, metav1.ConditionTrue, message, hypdeployment.ConfiguredAsExpectedReason)
+	if err := r.Client.Status().Patch(ctx, hyd, client.MergeFrom(inHyd)); err != nil {
+		return false, fmt.Errorf("failed to record the hosting cluster %s, err: %w", selected, err)
+	}
+
+	r.recordEvent(hyd, corev1.EventTypeNormal, HostingClusterSelectedEvent, message)
+	return true, nil
+}
+
+// decidedClusters lists the ManagedClusters of the PlacementDecisions of a Placement, in the order of the decisions
+func (r *HypershiftDeploymentReconciler) decidedClusters(ctx context.Context, namespace, placement string) ([]string, error) {
+	decisions := &clusterv1beta1.PlacementDecisionList{}
+	if err := r.List(ctx, decisions, client.InNamespace(namespace),
+		client.MatchingLabels{clusterv1beta1.PlacementLabel: placement}); err != nil {
+		return nil, fmt.Errorf("failed to list the PlacementDecisions of placement %s, err: %w", placement, err)
+	}
+
+	sort.Slice(decisions.Items, func(i, j int) bool {
+		return decisions.Items[i].Name < decisions.Items[j].Name
+	})
+
+	clusters := []string{}
+	for _, pd := range decisions.Items {
+		for _, d := range pd.Status.Decisions {
+			if len(d.ClusterName) != 0 {
+				clusters = append(clusters, d.ClusterName)
+			}
+		}
+	}
+	return clusters, nil
+}
+
+// permittedClusters keeps the clusters that are members of a ManagedClusterSet bound to the namespace, and of
+// Spec.HostedManagedClusterSet when it is set, the constraints validateSecurityConstraints enforces on the hosting cluster
+func (r *HypershiftDeploymentReconciler) permittedClusters(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, clusters []string) ([]string, error) {
+	if !r.ValidateClusterSecurity {
+		return clusters, nil
+	}
+
+	bindings, err := clusterv1beta1.GetBoundManagedClusterSetBindings(hyd.Namespace, hydclient.ClusterSetBindingsGetter{Client: r.Client})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the ManagedClusterSetBindings of namespace %s, err: %w", hyd.Namespace, err)
+	}
+	clusterSets := []string{}
+	for _, binding := range bindings {
+		if len(hyd.Spec.HostedManagedClusterSet) == 0 || binding.Name == hyd.Spec.HostedManagedClusterSet {
+			clusterSets = append(clusterSets, binding.Name)
+		}
+	}
+
+	permitted := []string{}
+	for _, cluster := range clusters {
+		managedCluster := &clusterv1.ManagedCluster{}
+		if err := r.Get(ctx, types.NamespacedName{Name: cluster}, managedCluster); err != nil {
+			if apierrors.IsNotFound(err) {
+				r.Log.V(1).Info("Skipping hosting cluster, the ManagedCluster is not found", "cluster", cluster)
+				continue
+			}
+			return nil, err
+		}
+
+		found, err := helper.IsClusterInClusterSet(r.Client, managedCluster, clusterSets)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			r.Log.V(1).Info("Skipping hosting cluster, it is not a member of a ManagedClusterSet bound to the namespace", "cluster", cluster)
+			continue
+		}
+		permitted = append(permitted, cluster)
+	}
+	return permitted, nil
+}
+
+// firstClusterWithCapacity returns the first of the clusters the CapacityScheduler admits the HostedCluster to, or an
+// empty name when none has capacity
+func (r *HypershiftDeploymentReconciler) firstClusterWithCapacity(ctx context.Context, hyd *hypdeployment.HypershiftDeployment, clusters []string) (string, error) {
+	if r.CapacityScheduler == nil {
+		return clusters[0], nil
+	}
+
+	for _, cluster := range clusters {
+		message, err := r.CapacityScheduler.Admit(ctx, hyd, cluster)
+		if err != nil {
+			return "", err
+		}
+		if len(message) == 0 {
+			return cluster, nil
+		}
+		r.Log.V(1).Info("Skipping hosting cluster", "cluster", cluster, "message", message)
+	}
+	return "", nil
+}
+
+// enqueueForPlacementDecision enqueues the HypershiftDeployments waiting for a hosting cluster from the Placement
+// of a PlacementDecision
+func (r *HypershiftDeploymentReconciler) enqueueForPlacementDecision(obj client.Object) []reconcile.Request {
+	placement := obj.GetLabels()[clusterv1beta1.PlacementLabel]
+	if len(placement) == 0 {
+		return []reconcile.Request{}
+	}
+
+	hydList := &hypdeployment.HypershiftDeploymentList{}
+	if err := r.List(context.Background(), hydList, client.InNamespace(obj.GetNamespace())); err != nil {
+		placementLog.Error(err, "failed to list the HypershiftDeployments of placement "+placement)
+		return []reconcile.Request{}
+	}
+
+	reqs := []reconcile.Request{}
+	for _, hyd := range hydList.Items {
+		ref := hyd.Spec.HostingClusterPlacement
+		if ref == nil || ref.Name != placement || len(hyd.Status.HostingCluster) != 0 {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: hyd.Namespace, Name: hyd.Name},
+		})
+	}
+	return reqs
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+func getPlacementDecision(namespace, name, placement string, clusters ...string) *clusterv1beta1.PlacementDecision {
+	pd := &clusterv1beta1.PlacementDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{clusterv1beta1.PlacementLabel: placement},
+		},
+	}
+	for _, c := range clusters {
+		pd.Status.Decisions = append(pd.Status.Decisions, clusterv1beta1.ClusterDecision{ClusterName: c})
+	}
+	return pd
+}
+
+func TestSelectHostingCluster(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingClusterPlacement = &corev1.LocalObjectReference{Name: "hosting"}
+	assert.Nil(t, client.Create(ctx, testHD))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client: client,
+		Log:    ctrl.Log.WithName("tester"),
+		ctx:    ctx,
+	}
+
+	selected, err := hdr.selectHostingCluster(ctx, testHD)
+	assert.Nil(t, err)
+	assert.False(t, selected, "no PlacementDecision")
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hyd.HostingClusterSelected))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, hyd.WaitingForPlacementReason, c.Reason)
+	}
+
+	assert.Nil(t, client.Create(ctx, getPlacementDecision(testHD.Namespace, "other-decision-1", "other", "cluster0")))
+	assert.Nil(t, client.Create(ctx, getPlacementDecision(testHD.Namespace, "hosting-decision-2", "hosting", "cluster3")))
+	assert.Nil(t, client.Create(ctx, getPlacementDecision(testHD.Namespace, "hosting-decision-1", "hosting", "cluster1", "cluster2")))
+
+	assert.Len(t, hdr.enqueueForPlacementDecision(getPlacementDecision(testHD.Namespace, "d", "hosting")), 1)
+	assert.Empty(t, hdr.enqueueForPlacementDecision(getPlacementDecision(testHD.Namespace, "d", "other")))
+
+	selected, err = hdr.selectHostingCluster(ctx, testHD)
+	assert.Nil(t, err)
+	assert.True(t, selected)
+	assert.Equal(t, "cluster1", testHD.Status.HostingCluster, "the first decision is used")
+	assert.True(t, meta.IsStatusConditionTrue(testHD.Status.Conditions, string(hyd.HostingClusterSelected)))
+
+	resultHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, client.Get(ctx, getNN, resultHD))
+	assert.Equal(t, "cluster1", resultHD.Status.HostingCluster)
+	assert.Equal(t, "cluster1", getManifestWorkKey(resultHD).Namespace)
+	assert.Empty(t, hdr.enqueueForPlacementDecision(getPlacementDecision(testHD.Namespace, "d", "hosting")),
+		"the HypershiftDeployment no longer waits for a decision")
+
+	// A new decision does not move the HostedCluster
+	pd := &clusterv1beta1.PlacementDecision{}
+	assert.Nil(t, client.Get(ctx, types.NamespacedName{Namespace: testHD.Namespace, Name: "hosting-decision-1"}, pd))
+	pd.Status.Decisions = []clusterv1beta1.ClusterDecision{{ClusterName: "cluster4"}}
+	assert.Nil(t, client.Update(ctx, pd))
+
+	selected, err = hdr.selectHostingCluster(ctx, resultHD)
+	assert.Nil(t, err)
+	assert.True(t, selected)
+	assert.Equal(t, "cluster1", resultHD.Status.HostingCluster)
+}
+
+func TestSelectHostingClusterSecurity(t *testing.T) {
+	client := initClient()
+	ctx := context.Background()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingClusterPlacement = &corev1.LocalObjectReference{Name: "hosting"}
+	assert.Nil(t, client.Create(ctx, testHD))
+
+	hdr := &HypershiftDeploymentReconciler{
+		Client:                  client,
+		Log:                     ctrl.Log.WithName("tester"),
+		ctx:                     ctx,
+		ValidateClusterSecurity: true,
+	}
+
+	binding := getClusterSetBinding(testHD.Namespace, "dev")
+	assert.Nil(t, client.Create(ctx, binding))
+	binding.Status.Conditions = []metav1.Condition{{Type: clusterv1beta1.ClusterSetBindingBoundType, Status: metav1.ConditionTrue}}
+	assert.Nil(t, client.Status().Update(ctx, binding))
+	assert.Nil(t, client.Create(ctx, getClusterSet("dev")))
+	assert.Nil(t, client.Create(ctx, getClusterSet("prod")))
+
+	cluster1 := getCluster("cluster1")
+	cluster1.Labels = map[string]string{clusterv1beta1.ClusterSetLabel: "prod"}
+	assert.Nil(t, client.Create(ctx, cluster1))
+	assert.Nil(t, client.Create(ctx, getPlacementDecision(testHD.Namespace, "hosting-decision-1", "hosting", "cluster1")))
+
+	selected, err := hdr.selectHostingCluster(ctx, testHD)
+	assert.Nil(t, err)
+	assert.False(t, selected, "the ManagedClusterSet of cluster1 is not bound to the namespace")
+	assert.Empty(t, testHD.Status.HostingCluster)
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hyd.HostingClusterSelected))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, hyd.WaitingForPlacementReason, c.Reason)
+		assert.Contains(t, c.Message, "bound to namespace "+testHD.Namespace)
+	}
+
+	cluster2 := getCluster("cluster2")
+	cluster2.Labels = map[string]string{clusterv1beta1.ClusterSetLabel: "dev"}
+	assert.Nil(t, client.Create(ctx, cluster2))
+	assert.Nil(t, client.Create(ctx, getPlacementDecision(testHD.Namespace, "hosting-decision-2", "hosting", "cluster2")))
+
+	selected, err = hdr.selectHostingCluster(ctx, testHD)
+	assert.Nil(t, err)
+	assert.True(t, selected)
+	assert.Equal(t, "cluster2", testHD.Status.HostingCluster, "the first permitted cluster is used")
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver"
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+const (
+	// releaseChannelPrefix marks a ConfigMap value as a release channel instead of a pull spec, ie: channel:stable-4.12
+	releaseChannelPrefix = "channel:"
+
+	defaultReleaseKey          = "default"
+	defaultReleaseArchitecture = "amd64"
+	defaultReleaseGraphURL     = "https://api.openshift.com/api/upgrades_info/v1/graph"
+	defaultReleaseCacheTTL     = 1 * time.Hour
+)
+
+// ReleaseImageResolver finds the OCP release image used for scaffolded HostedClusters and NodePools.
+// The ConfigMap keys are looked up in order: <platform>-<architecture>, <platform> and default. Platform
+// names are lower case (aws, azure). A value is either a release pull spec or a channel, ie: channel:stable-4.12,
+// which resolves to the latest z-stream release in that channel.
+// When the ConfigMap or a matching key is not found, constant.ReleaseImage is used.
+type ReleaseImageResolver struct {
+	Client        client.Client
+	Namespace     string
+	ConfigMapName string
+	Architecture  string
+	GraphURL      string
+	CacheTTL      time.Duration
+
+	// HTTPGet retrieves the release graph, defaults to an http.Get when nil
+	HTTPGet func(ctx context.Context, url string) ([]byte, error)
+
+	mu    sync.Mutex
+	cache map[string]cachedReleaseImage
+}
+
+type cachedReleaseImage struct {
+	pullSpec string
+	expires  time.Time
+}
+
+type releaseGraph struct {
+	Nodes []releaseGraphNode `json:"nodes"`
+}
+
+type releaseGraphNode struct {
+	Version string `json:"version"`
+	Payload string `json:"payload"`
+}
+
+// Resolve returns the release pull spec for the platform, it is safe to call on a nil resolver
+func (r *ReleaseImageResolver) Resolve(ctx context.Context, platform hyp.PlatformType) (string, error) {
+	if r == nil || r.Client == nil || len(r.Namespace) == 0 {
+		return constant.ReleaseImage, nil
+	}
+
+	configMapName := r.ConfigMapName
+	if len(configMapName) == 0 {
+		configMapName = constant.ReleaseImageConfigMapName
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: configMapName}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return constant.ReleaseImage, nil
+		}
+		return "", fmt.Errorf("failed to get release image configmap %s/%s: %w", r.Namespace, configMapName, err)
+	}
+
+	arch := r.architecture()
+	p := strings.ToLower(string(platform))
+	for _, key := range []string{p + "-" + arch, p, defaultReleaseKey} {
+		value := strings.TrimSpace(cm.Data[key])
+		if len(value) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(value, releaseChannelPrefix) {
+			return r.resolveChannel(ctx, strings.TrimPrefix(value, releaseChannelPrefix), arch)
+		}
+		return value, nil
+	}
+
+	return constant.ReleaseImage, nil
+}
+
+func (r *ReleaseImageResolver) architecture() string {
+	if len(r.Architecture) == 0 {
+		return defaultReleaseArchitecture
+	}
+	return r.Architecture
+}
+
+// resolveChannel finds the latest release in a channel. A channel such as stable-4.12 can also contain
+// 4.11 releases to upgrade from, so only releases matching the channel's minor version are considered
+func (r *ReleaseImageResolver) resolveChannel(ctx context.Context, channel string, arch string) (string, error) {
+	cacheKey := channel + "/" + arch
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.cache[cacheKey]; ok && time.Now().Before(cached.expires) {
+		return cached.pullSpec, nil
+	}
+
+	graphURL := r.GraphURL
+	if len(graphURL) == 0 {
+		graphURL = defaultReleaseGraphURL
+	}
+	query := url.Values{"channel": []string{channel}, "arch": []string{arch}}
+
+	httpGet := r.HTTPGet
+	if httpGet == nil {
+		httpGet = getReleaseGraph
+	}
+
+	body, err := httpGet(ctx, graphURL+"?"+query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to get the release graph for channel %s: %w", channel, err)
+	}
+
+	graph := releaseGraph{}
+	if err := json.Unmarshal(body, &graph); err != nil {
+		return "", fmt.Errorf("failed to parse the release graph for channel %s: %w", channel, err)
+	}
+
+	minor := ""
+	if i := strings.LastIndex(channel, "-"); i >= 0 {
+		minor = channel[i+1:] + "."
+	}
+
+	var latest *semver.Version
+	pullSpec := ""
+	for _, node := range graph.Nodes {
+		if !strings.HasPrefix(node.Version, minor) {
+			continue
+		}
+		v, err := semver.Parse(node.Version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || v.GT(*latest) {
+			latest = &v
+			pullSpec = node.Payload
+		}
+	}
+
+	if len(pullSpec) == 0 {
+		return "", fmt.Errorf("no release found in channel %s for architecture %s", channel, arch)
+	}
+
+	ttl := r.CacheTTL
+	if ttl == 0 {
+		ttl = defaultReleaseCacheTTL
+	}
+	if r.cache == nil {
+		r.cache = map[string]cachedReleaseImage{}
+	}
+	r.cache[cacheKey] = cachedReleaseImage{pullSpec: pullSpec, expires: time.Now().Add(ttl)}
+
+	return pullSpec, nil
+}
+
+func getReleaseGraph(ctx context.Context, graphURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, graphURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// ensureReleaseImage sets the resolved release image when the HostedClusterSpec does not supply one, this
+// is called before the HostedClusterSpec and NodePools are scaffolded so both use the same release
+func (r *HypershiftDeploymentReconciler) ensureReleaseImage(hyd *hypdeployment.HypershiftDeployment) error {
+	if hyd.Spec.HostedClusterSpec != nil && len(hyd.Spec.HostedClusterSpec.Release.Image) != 0 {
+		return nil
+	}
+
+	platform := hyp.NonePlatform
+	switch {
+	case hyd.Spec.HostedClusterSpec != nil && len(hyd.Spec.HostedClusterSpec.Platform.Type) != 0:
+		platform = hyd.Spec.HostedClusterSpec.Platform.Type
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.AWS != nil:
+		platform = hyp.AWSPlatform
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.Azure != nil:
+		platform = hyp.AzurePlatform
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.KubeVirt != nil:
+		platform = hyp.KubevirtPlatform
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.Agent != nil:
+		platform = hyp.AgentPlatform
+	}
+
+	releaseImage, err := r.ReleaseImageResolver.Resolve(r.ctx, platform)
+	if err != nil {
+		return err
+	}
+
+	if hyd.Spec.HostedClusterSpec == nil {
+		scaffoldHostedClusterSpec(hyd)
+	}
+	hyd.Spec.HostedClusterSpec.Release.Image = releaseImage
+	setNetworkTypeForRelease(hyd.Spec.HostedClusterSpec)
+
+	r.Log.V(1).Info("Using release image", "platform", platform, "image", releaseImage)
+	return nil
+}
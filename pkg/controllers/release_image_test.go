@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+const testReleaseGraph = `{"nodes":[
+	{"version":"4.11.20","payload":"quay.io/openshift-release-dev/ocp-release@sha256:411"},
+	{"version":"4.12.2","payload":"quay.io/openshift-release-dev/ocp-release@sha256:4122"},
+	{"version":"4.12.10","payload":"quay.io/openshift-release-dev/ocp-release@sha256:41210"},
+	{"version":"4.12.9","payload":"quay.io/openshift-release-dev/ocp-release@sha256:4129"}
+]}`
+
+func getReleaseImageConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constant.ReleaseImageConfigMapName,
+			Namespace: "open-cluster-management",
+		},
+		Data: data,
+	}
+}
+
+func getReleaseImageResolver(objects ...*corev1.ConfigMap) *ReleaseImageResolver {
+	cb := clientfake.NewClientBuilder().WithScheme(s)
+	for _, o := range objects {
+		cb.WithObjects(o)
+	}
+	return &ReleaseImageResolver{
+		Client:    cb.Build(),
+		Namespace: "open-cluster-management",
+	}
+}
+
+func TestReleaseImageResolverDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	var nilResolver *ReleaseImageResolver
+	image, err := nilResolver.Resolve(ctx, hyp.AWSPlatform)
+	assert.Nil(t, err, "err is nil for a nil resolver")
+	assert.Equal(t, constant.ReleaseImage, image, "the compiled in release is used for a nil resolver")
+
+	image, err = getReleaseImageResolver().Resolve(ctx, hyp.AWSPlatform)
+	assert.Nil(t, err, "err is nil when the configmap is missing")
+	assert.Equal(t, constant.ReleaseImage, image, "the compiled in release is used when the configmap is missing")
+
+	image, err = getReleaseImageResolver(getReleaseImageConfigMap(map[string]string{"azure": "quay.io/azure"})).Resolve(ctx, hyp.AWSPlatform)
+	assert.Nil(t, err, "err is nil when no key matches")
+	assert.Equal(t, constant.ReleaseImage, image, "the compiled in release is used when no key matches")
+}
+
+func TestReleaseImageResolverKeys(t *testing.T) {
+	ctx := context.Background()
+
+	r := getReleaseImageResolver(getReleaseImageConfigMap(map[string]string{
+		"default":     "quay.io/default",
+		"aws":         "quay.io/aws",
+		"azure-arm64": "quay.io/azure-arm64",
+	}))
+
+	image, err := r.Resolve(ctx, hyp.AWSPlatform)
+	assert.Nil(t, err, "err is nil when resolving the aws release")
+	assert.Equal(t, "quay.io/aws", image, "the platform key is used")
+
+	image, err = r.Resolve(ctx, hyp.AzurePlatform)
+	assert.Nil(t, err, "err is nil when resolving the azure release")
+	assert.Equal(t, "quay.io/default", image, "the default key is used when the architecture does not match")
+
+	r.Architecture = "arm64"
+	image, err = r.Resolve(ctx, hyp.AzurePlatform)
+	assert.Nil(t, err, "err is nil when resolving the azure arm64 release")
+	assert.Equal(t, "quay.io/azure-arm64", image, "the platform and architecture key is used first")
+}
+
+func TestReleaseImageResolverChannel(t *testing.T) {
+	ctx := context.Background()
+
+	r := getReleaseImageResolver(getReleaseImageConfigMap(map[string]string{
+		"default": "channel:stable-4.12",
+	}))
+
+	requests := 0
+	r.HTTPGet = func(ctx context.Context, url string) ([]byte, error) {
+		requests++
+		assert.Contains(t, url, "channel=stable-4.12")
+		assert.Contains(t, url, "arch=amd64")
+		return []byte(testReleaseGraph), nil
+	}
+
+	image, err := r.Resolve(ctx, hyp.AWSPlatform)
+	assert.Nil(t, err, "err is nil when resolving a channel")
+	assert.Equal(t, "quay.io/openshift-release-dev/ocp-release@sha256:41210", image, "the latest 4.12.z release is used")
+
+	_, err = r.Resolve(ctx, hyp.AzurePlatform)
+	assert.Nil(t, err, "err is nil when resolving a cached channel")
+	assert.Equal(t, 1, requests, "the resolved channel is cached")
+
+	r = getReleaseImageResolver(getReleaseImageConfigMap(map[string]string{
+		"default": "channel:stable-4.13",
+	}))
+	r.HTTPGet = func(ctx context.Context, url string) ([]byte, error) {
+		return []byte(testReleaseGraph), nil
+	}
+	_, err = r.Resolve(ctx, hyp.AWSPlatform)
+	assert.NotNil(t, err, "err when the channel has no release for its minor version")
+
+	r.HTTPGet = func(ctx context.Context, url string) ([]byte, error) {
+		return nil, errors.New("connection refused")
+	}
+	_, err = r.Resolve(ctx, hyp.AWSPlatform)
+	assert.NotNil(t, err, "err when the release graph can not be retrieved")
+}
+
+func TestEnsureReleaseImage(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+	r.ReleaseImageResolver = getReleaseImageResolver(getReleaseImageConfigMap(map[string]string{
+		"aws": "quay.io/openshift-release-dev/ocp-release:4.10.30-x86_64",
+	}))
+
+	testHD := getValidAWSHypershiftDeployment()
+	assert.Nil(t, r.ensureReleaseImage(testHD), "err is nil when the release image is resolved")
+	assert.Equal(t, "quay.io/openshift-release-dev/ocp-release:4.10.30-x86_64", testHD.Spec.HostedClusterSpec.Release.Image)
+	assert.Equal(t, hyp.OpenShiftSDN, testHD.Spec.HostedClusterSpec.Networking.NetworkType, "4.10 releases use OpenShiftSDN")
+
+	ScaffoldAWSNodePoolSpec(testHD, getAWSInfrastructureOut())
+	assert.Equal(t, testHD.Spec.HostedClusterSpec.Release.Image, testHD.Spec.NodePools[0].Spec.Release.Image,
+		"the scaffolded nodepools use the resolved release")
+
+	testHD.Spec.HostedClusterSpec.Release.Image = "quay.io/openshift-release-dev/ocp-release:4.11.5-x86_64"
+	assert.Nil(t, r.ensureReleaseImage(testHD), "err is nil when the release image is supplied")
+	assert.Equal(t, "quay.io/openshift-release-dev/ocp-release:4.11.5-x86_64", testHD.Spec.HostedClusterSpec.Release.Image,
+		"a supplied release image is not replaced")
+}
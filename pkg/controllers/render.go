@@ -0,0 +1,132 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+// RedactedValue replaces the data of the Secrets in a rendered ManifestWork
+const RedactedValue = "REDACTED"
+
+// RenderManifestWork builds the ManifestWork the reconciler would apply for the HypershiftDeployment, without a hub.
+// The referenced Secrets, ConfigMaps, HostedCluster and NodePools are read from objects, and the Secret data
+// is redacted. The values the reconciler defaults on the spec, such as the clusterID or the release image, are only
+// rendered when they are set
+func RenderManifestWork(ctx context.Context, scheme *runtime.Scheme, hyd *hypdeployment.HypershiftDeployment, objects []runtime.Object) (*workv1.ManifestWork, error) {
+	hyd = hyd.DeepCopy()
+	if len(hyd.Namespace) == 0 {
+		hyd.Namespace = "default"
+	}
+	if len(helper.GetHostingClusterName(hyd)) == 0 {
+		return nil, errors.New(constant.HostingClusterMissing)
+	}
+	// the reconciler generates a random suffix
+	if len(hyd.Spec.InfraID) == 0 {
+		hyd.Spec.InfraID = hyd.Name
+	}
+
+	copies := make([]runtime.Object, 0, len(objects))
+	for _, obj := range objects {
+		obj = obj.DeepCopyObject()
+		copies = append(copies, obj)
+
+		if o, ok := obj.(client.Object); ok && len(o.GetNamespace()) == 0 {
+			o.SetNamespace(hyd.Namespace)
+		}
+
+		// the API server would merge the stringData
+		if secret, ok := obj.(*corev1.Secret); ok && len(secret.StringData) != 0 {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			for k, v := range secret.StringData {
+				secret.Data[k] = []byte(v)
+			}
+			secret.StringData = nil
+		}
+	}
+
+	r := &HypershiftDeploymentReconciler{
+		Client:        clientfake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(append(copies, hyd)...).Build(),
+		DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme, copies...),
+		Scheme:        scheme,
+		ctx:           ctx,
+		Log:           ctrl.Log.WithName("render"),
+	}
+
+	providerSecret := &corev1.Secret{}
+	if name := hyd.Spec.Infrastructure.CloudProvider.Name; len(name) != 0 {
+		if err := r.Get(ctx, types.NamespacedName{Namespace: hyd.Namespace, Name: name}, providerSecret); err != nil {
+			return nil, fmt.Errorf("failed to get the cloud provider secret %s, err: %w", name, err)
+		}
+	}
+
+	m, err := scaffoldManifestwork(hyd)
+	if err != nil {
+		return nil, err
+	}
+	m.TypeMeta.Kind = "ManifestWork"
+	m.TypeMeta.APIVersion = workv1.GroupVersion.String()
+
+	payload, err := r.loadPayload(ctx, hyd, m, providerSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Spec.Workload.Manifests = redactSecrets(payload)
+	enableManifestStatusFeedback(m, hyd)
+
+	return m, nil
+}
+
+// redactSecrets keeps the keys of the Secret data as readable stringData, the payload shares the data with the
+// source Secrets so they are copied
+func redactSecrets(payload []workv1.Manifest) []workv1.Manifest {
+	for i, manifest := range payload {
+		secret, ok := manifest.Object.(*corev1.Secret)
+		if !ok {
+			continue
+		}
+
+		out := secret.DeepCopy()
+		out.Data = nil
+		out.StringData = map[string]string{}
+		for k := range secret.Data {
+			out.StringData[k] = RedactedValue
+		}
+
+		payload[i].Object = out
+	}
+
+	return payload
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
+)
+
+func TestRenderManifestWork(t *testing.T) {
+	testHD := getHypershiftDeployment("default", "test1", false)
+	testHD.Spec.HostingCluster = "local-host"
+	testHD.Spec.InfraID = ""
+	testHD.Spec.HostedClusterSpec = getHostedClusterForManifestworkTest(getHDforManifestWork()).Spec.DeepCopy()
+	testHD.Spec.HostedClusterSpec.PullSecret.Name = "pull-secret"
+
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret"},
+		StringData: map[string]string{".dockerconfigjson": "docker-pull-secret"},
+	}
+
+	m, err := RenderManifestWork(context.Background(), s, testHD, []runtime.Object{pullSecret})
+	assert.Nil(t, err)
+	assert.Equal(t, "local-host", m.Namespace)
+	assert.Equal(t, testHD.Name, m.Name, "the name is used without an infra-id")
+	assert.Equal(t, "ManifestWork", m.Kind)
+	assert.NotEmpty(t, m.Spec.ManifestConfigs)
+
+	kinds := []string{}
+	for _, manifest := range m.Spec.Workload.Manifests {
+		kinds = append(kinds, manifest.Object.GetObjectKind().GroupVersionKind().Kind)
+
+		if secret, ok := manifest.Object.(*corev1.Secret); ok {
+			assert.Nil(t, secret.Data, "the secret data is redacted")
+			assert.Equal(t, map[string]string{".dockerconfigjson": RedactedValue}, secret.StringData)
+		}
+	}
+	assert.Equal(t, []string{"Namespace", "HostedCluster", "Secret"}, kinds)
+
+	assert.Equal(t, "docker-pull-secret", pullSecret.StringData[".dockerconfigjson"],
+		"the source secret is not redacted")
+}
+
+func TestRenderManifestWorkErrors(t *testing.T) {
+	testHD := getHypershiftDeployment("default", "test1", false)
+	testHD.Spec.HostingCluster = ""
+
+	_, err := RenderManifestWork(context.Background(), s, testHD, nil)
+	assert.EqualError(t, err, constant.HostingClusterMissing)
+
+	testHD.Spec.HostingCluster = "local-host"
+	testHD.Spec.Infrastructure.CloudProvider.Name = "missing"
+	_, err = RenderManifestWork(context.Background(), s, testHD, nil)
+	assert.NotNil(t, err, "the cloud provider secret is required when it is referenced")
+}
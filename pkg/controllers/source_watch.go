@@ -0,0 +1,150 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+const (
+	// sourceRefIndex indexes the HypershiftDeployments by the Secrets and ConfigMaps they copy into the ManifestWork
+	sourceRefIndex = "spec.sourceRefs"
+
+	sourceKindSecret    = "Secret"
+	sourceKindConfigMap = "ConfigMap"
+)
+
+// sourceWatchLog is used by the map functions, they run on the informer goroutines, outside of Reconcile
+var sourceWatchLog = ctrl.Log.WithName("source-watch")
+
+func sourceRefKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// referencedSources returns the index keys of the Secrets and ConfigMaps, in the HypershiftDeployment namespace,
+// referenced by the spec. The sources of a HostedClusterRef are not included
+func referencedSources(hyd *hypdeployment.HypershiftDeployment) []string {
+	keys := []string{}
+	add := func(kind, name string) {
+		if len(name) != 0 {
+			keys = append(keys, sourceRefKey(kind, name))
+		}
+	}
+
+	add(sourceKindSecret, hyd.Spec.Infrastructure.CloudProvider.Name)
+
+	if hcSpec := hyd.Spec.HostedClusterSpec; hcSpec != nil {
+		add(sourceKindSecret, hcSpec.PullSecret.Name)
+		add(sourceKindSecret, hcSpec.SSHKey.Name)
+
+		if hcSpec.Configuration != nil {
+			for _, ref := range hcSpec.Configuration.SecretRefs {
+				add(sourceKindSecret, ref.Name)
+			}
+			for _, ref := range hcSpec.Configuration.ConfigMapRefs {
+				add(sourceKindConfigMap, ref.Name)
+			}
+		}
+
+		if encr := hcSpec.SecretEncryption; encr != nil {
+			if encr.AESCBC != nil {
+				add(sourceKindSecret, encr.AESCBC.ActiveKey.Name)
+				if encr.AESCBC.BackupKey != nil {
+					add(sourceKindSecret, encr.AESCBC.BackupKey.Name)
+				}
+			}
+			if encr.Type == hyp.KMS && encr.KMS != nil && encr.KMS.AWS != nil {
+				add(sourceKindSecret, encr.KMS.AWS.Auth.Credentials.Name)
+			}
+		}
+
+		if hcSpec.AdditionalTrustBundle != nil {
+			add(sourceKindConfigMap, hcSpec.AdditionalTrustBundle.Name)
+		}
+
+		if hcSpec.ServiceAccountSigningKey != nil {
+			add(sourceKindSecret, hcSpec.ServiceAccountSigningKey.Name)
+		}
+	}
+
+	for _, np := range hyd.Spec.NodePools {
+		for _, ref := range np.Spec.Config {
+			add(sourceKindConfigMap, ref.Name)
+		}
+	}
+
+	return keys
+}
+
+func indexReferencedSources(obj client.Object) []string {
+	hyd, ok := obj.(*hypdeployment.HypershiftDeployment)
+	if !ok {
+		return nil
+	}
+	return referencedSources(hyd)
+}
+
+// recordCopiedSource adds the source to Status.CopiedSources, a change of its resourceVersion is then visible on
+// the HypershiftDeployment
+func recordCopiedSource(hyd *hypdeployment.HypershiftDeployment, kind string, obj metav1.Object) {
+	if hyd == nil {
+		return
+	}
+
+	for i, s := range hyd.Status.CopiedSources {
+		if s.Kind == kind && s.Name == obj.GetName() {
+			hyd.Status.CopiedSources[i].ResourceVersion = obj.GetResourceVersion()
+			return
+		}
+	}
+
+	hyd.Status.CopiedSources = append(hyd.Status.CopiedSources, hypdeployment.CopiedSourceStatus{
+		Kind:            kind,
+		Name:            obj.GetName(),
+		ResourceVersion: obj.GetResourceVersion(),
+	})
+}
+
+// enqueueForSource maps a Secret or ConfigMap to the HypershiftDeployments in its namespace that reference it
+func (r *HypershiftDeploymentReconciler) enqueueForSource(kind string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		hydList := &hypdeployment.HypershiftDeploymentList{}
+		if err := r.List(context.Background(), hydList, client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{sourceRefIndex: sourceRefKey(kind, obj.GetName())}); err != nil {
+			sourceWatchLog.Error(err, "failed to list the HypershiftDeployments referencing "+sourceRefKey(kind, obj.GetName()))
+			return []reconcile.Request{}
+		}
+
+		reqs := []reconcile.Request{}
+		for _, hyd := range hydList.Items {
+			reqs = append(reqs, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: hyd.Namespace, Name: hyd.Name},
+			})
+		}
+		return reqs
+	}
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+func TestReferencedSources(t *testing.T) {
+	testHD := getHDforManifestWork()
+	testHD.Spec.Infrastructure.CloudProvider.Name = "providersecret"
+	testHD.Spec.HostedClusterSpec.Configuration = &hyp.ClusterConfiguration{
+		SecretRefs:    []corev1.LocalObjectReference{{Name: "oauth-secret"}},
+		ConfigMapRefs: []corev1.LocalObjectReference{{Name: "oauth-cm"}},
+	}
+	testHD.Spec.HostedClusterSpec.AdditionalTrustBundle = &corev1.LocalObjectReference{Name: "trust-bundle"}
+	testHD.Spec.NodePools[0].Spec.Config = []corev1.LocalObjectReference{{Name: "np-config"}}
+
+	keys := referencedSources(testHD)
+	assert.Contains(t, keys, "Secret/providersecret")
+	assert.Contains(t, keys, "Secret/"+testHD.Spec.HostedClusterSpec.PullSecret.Name)
+	assert.Contains(t, keys, "Secret/oauth-secret")
+	assert.Contains(t, keys, "ConfigMap/oauth-cm")
+	assert.Contains(t, keys, "ConfigMap/trust-bundle")
+	assert.Contains(t, keys, "ConfigMap/np-config")
+
+	assert.Empty(t, indexReferencedSources(&corev1.Secret{}), "only HypershiftDeployments are indexed")
+}
+
+func TestRecordCopiedSource(t *testing.T) {
+	testHD := getHDforManifestWork()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s1", ResourceVersion: "1"}}
+	recordCopiedSource(testHD, sourceKindSecret, secret)
+	recordCopiedSource(testHD, sourceKindConfigMap, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "s1", ResourceVersion: "2"}})
+
+	secret.ResourceVersion = "3"
+	recordCopiedSource(testHD, sourceKindSecret, secret)
+
+	assert.Equal(t, []hypdeployment.CopiedSourceStatus{
+		{Kind: sourceKindSecret, Name: "s1", ResourceVersion: "3"},
+		{Kind: sourceKindConfigMap, Name: "s1", ResourceVersion: "2"},
+	}, testHD.Status.CopiedSources, "a source is recorded once with its latest resourceVersion")
+}
+
+func TestEnsureConfigurationRecordsCopiedSources(t *testing.T) {
+	ctx := context.Background()
+	r := GetHypershiftDeploymentReconciler()
+
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostingCluster = "local-host"
+	testHD.Spec.HostedClusterSpec.Configuration = &hyp.ClusterConfiguration{
+		ConfigMapRefs: []corev1.LocalObjectReference{{Name: "oauth-cm"}},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-cm", Namespace: testHD.Namespace},
+		Data:       map[string]string{"ca.crt": "v1"},
+	}
+	assert.Nil(t, r.Create(ctx, cm))
+
+	m, err := scaffoldManifestwork(testHD)
+	assert.Nil(t, err)
+
+	render := func() *corev1.ConfigMap {
+		testHD.Status.CopiedSources = nil
+		payload := []workv1.Manifest{}
+		assert.Nil(t, r.appendHostedCluster(ctx)(testHD, &payload))
+		assert.Nil(t, r.ensureConfiguration(ctx, m)(testHD, &payload))
+
+		for _, wl := range payload {
+			if out, ok := wl.Object.(*corev1.ConfigMap); ok && out.Name == cm.Name {
+				return out
+			}
+		}
+		return nil
+	}
+
+	out := render()
+	assert.NotNil(t, out, "the configmap is copied to the payload")
+	assert.Equal(t, "v1", out.Data["ca.crt"], "the configmap data is copied")
+	assert.Len(t, testHD.Status.CopiedSources, 1)
+	firstVersion := testHD.Status.CopiedSources[0].ResourceVersion
+	assert.NotEmpty(t, firstVersion)
+
+	cm.Data["ca.crt"] = "v2"
+	assert.Nil(t, r.Update(ctx, cm))
+
+	out = render()
+	assert.Equal(t, "v2", out.Data["ca.crt"], "the rotated configmap data is copied")
+	assert.NotEqual(t, firstVersion, testHD.Status.CopiedSources[0].ResourceVersion,
+		"the new resourceVersion of the configmap is recorded")
+}
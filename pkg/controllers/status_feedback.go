@@ -0,0 +1,129 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+// resourceFeedback returns the status feedback values of a resource of the ManifestWork by name
+func resourceFeedback(m *workv1.ManifestWork, id workv1.ResourceIdentifier) map[string]workv1.FieldValue {
+	values := map[string]workv1.FieldValue{}
+	if m == nil {
+		return values
+	}
+
+	for _, obj := range m.Status.ResourceStatus.Manifests {
+		if resourceMeta(obj.ResourceMeta).ToIdentifier() != id {
+			continue
+		}
+		for _, v := range obj.StatusFeedbacks.Values {
+			values[v.Name] = v.Value
+		}
+	}
+	return values
+}
+
+func feedbackString(values map[string]workv1.FieldValue, name string) string {
+	if v, ok := values[name]; ok && v.String != nil {
+		return *v.String
+	}
+	return ""
+}
+
+func feedbackInteger(values map[string]workv1.FieldValue, name string) (int64, bool) {
+	if v, ok := values[name]; ok && v.Integer != nil {
+		return *v.Integer, true
+	}
+	return 0, false
+}
+
+// syncHostedClusterFeedback records the kubeconfig, endpoints and version the HostedCluster reports. A value that
+// is not reported is kept
+func syncHostedClusterFeedback(hyd *hypdeployment.HypershiftDeployment, m *workv1.ManifestWork) {
+	values := resourceFeedback(m, workv1.ResourceIdentifier{
+		Group:     hyp.GroupVersion.Group,
+		Resource:  HostedClusterResource,
+		Name:      hyd.Name,
+		Namespace: helper.GetHostingNamespace(hyd),
+	})
+
+	if name := feedbackString(values, Kubeconfig); len(name) != 0 {
+		hyd.Status.Kubeconfig = &corev1.LocalObjectReference{Name: name}
+	}
+	if name := feedbackString(values, KubeadminPassword); len(name) != 0 {
+		hyd.Status.KubeadminPassword = &corev1.LocalObjectReference{Name: name}
+	}
+	if version := feedbackString(values, Version); len(version) != 0 {
+		hyd.Status.Version = version
+	}
+
+	if host := feedbackString(values, APIHost); len(host) != 0 {
+		hyd.Status.APIEndpoint = "https://" + host
+		if port, ok := feedbackInteger(values, APIPort); ok {
+			hyd.Status.APIEndpoint = fmt.Sprintf("https://%s:%d", host, port)
+		}
+	}
+}
+
+// getNodePoolStatus reports each NodePool applied with the ManifestWork, the desired size is read from the
+// NodePools as they are applied
+func getNodePoolStatus(m *workv1.ManifestWork, hyd *hypdeployment.HypershiftDeployment) []hypdeployment.NodePoolStatus {
+	nodePools := []hypdeployment.NodePoolStatus{}
+	for _, np := range expandNodePools(hyd, time.Now()) {
+		values := resourceFeedback(m, workv1.ResourceIdentifier{
+			Group:     hyp.GroupVersion.Group,
+			Resource:  NodePoolResource,
+			Name:      np.Name,
+			Namespace: helper.GetHostingNamespace(hyd),
+		})
+
+		status := hypdeployment.NodePoolStatus{
+			Name:               np.Name,
+			Ready:              metav1.ConditionStatus(feedbackString(values, StatusFlag)),
+			Reason:             feedbackString(values, Reason),
+			Message:            feedbackString(values, Message),
+			Version:            feedbackString(values, Version),
+			AutoScalingEnabled: metav1.ConditionStatus(feedbackString(values, AutoScalingEnabled)),
+		}
+		if replicas, ok := feedbackInteger(values, Replicas); ok {
+			status.AvailableReplicas = int32(replicas)
+		}
+		if np.Spec.AutoScaling != nil {
+			status.AutoScaling = np.Spec.AutoScaling.DeepCopy()
+		} else if np.Spec.Replicas != nil {
+			replicas := *np.Spec.Replicas
+			status.Replicas = &replicas
+		}
+
+		nodePools = append(nodePools, status)
+	}
+
+	if len(nodePools) == 0 {
+		return nil
+	}
+	return nodePools
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"testing"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+func integerFeedback(name string, value int64) workv1.FeedbackValue {
+	return workv1.FeedbackValue{Name: name, Value: workv1.FieldValue{Type: workv1.Integer, Integer: &value}}
+}
+
+func getStatusFeedbackManifestWork(testHD *hyd.HypershiftDeployment, hc []workv1.FeedbackValue, nodePools map[string][]workv1.FeedbackValue) *workv1.ManifestWork {
+	m := &workv1.ManifestWork{}
+	m.Status.ResourceStatus.Manifests = append(m.Status.ResourceStatus.Manifests, workv1.ManifestCondition{
+		ResourceMeta: workv1.ManifestResourceMeta{
+			Group:     hyp.GroupVersion.Group,
+			Resource:  HostedClusterResource,
+			Name:      testHD.Name,
+			Namespace: helper.GetHostingNamespace(testHD),
+		},
+		StatusFeedbacks: workv1.StatusFeedbackResult{Values: hc},
+	})
+
+	for name, values := range nodePools {
+		m.Status.ResourceStatus.Manifests = append(m.Status.ResourceStatus.Manifests, workv1.ManifestCondition{
+			ResourceMeta: workv1.ManifestResourceMeta{
+				Group:     hyp.GroupVersion.Group,
+				Resource:  NodePoolResource,
+				Name:      name,
+				Namespace: helper.GetHostingNamespace(testHD),
+			},
+			StatusFeedbacks: workv1.StatusFeedbackResult{Values: values},
+		})
+	}
+	return m
+}
+
+func TestSyncHostedClusterFeedback(t *testing.T) {
+	testHD := getHDforManifestWork()
+	testHD.Spec.HostedClusterSpec.DNS.BaseDomain = "example.com"
+
+	m := getStatusFeedbackManifestWork(testHD, append(feedbackValues(map[string]string{
+		Version:           "4.11.2",
+		Kubeconfig:        "test1-admin-kubeconfig",
+		KubeadminPassword: "test1-kubeadmin-password",
+		APIHost:           "api.test1.example.com",
+	}), integerFeedback(APIPort, 6443)), nil)
+
+	syncManifestworkStatusToHypershiftDeployment(testHD, m)
+	assert.Equal(t, "4.11.2", testHD.Status.Version)
+	assert.Equal(t, "test1-admin-kubeconfig", testHD.Status.Kubeconfig.Name)
+	assert.Equal(t, "test1-kubeadmin-password", testHD.Status.KubeadminPassword.Name)
+	assert.Equal(t, "https://api.test1.example.com:6443", testHD.Status.APIEndpoint)
+	assert.Equal(t, helper.GetHostingNamespace(testHD)+"-test1-admin-kubeconfig", helper.HostedKubeconfigName(testHD))
+
+	// A value missing from the feedback is kept
+	syncManifestworkStatusToHypershiftDeployment(testHD, getStatusFeedbackManifestWork(testHD, nil, nil))
+	assert.Equal(t, "4.11.2", testHD.Status.Version)
+	assert.Equal(t, "https://api.test1.example.com:6443", testHD.Status.APIEndpoint)
+}
+
+func TestNodePoolStatus(t *testing.T) {
+	testHD := getHDforManifestWork()
+	testHD.Spec.NodePools = []*hyd.HypershiftNodePools{
+		{Name: "np1", Spec: hyp.NodePoolSpec{Replicas: int32Ptr(2)}},
+		{Name: "np2", Spec: hyp.NodePoolSpec{AutoScaling: &hyp.NodePoolAutoScaling{Min: 1, Max: 3}}},
+		{Name: "np3", Spec: hyp.NodePoolSpec{Replicas: int32Ptr(1)}},
+	}
+
+	m := getStatusFeedbackManifestWork(testHD, nil, map[string][]workv1.FeedbackValue{
+		"np1": append(feedbackValues(map[string]string{StatusFlag: "True", Reason: "AsExpected", Version: "4.11.2"}),
+			integerFeedback(Replicas, 2)),
+		"np2": append(feedbackValues(map[string]string{StatusFlag: "False", Reason: "WaitingForNodes", Message: "no nodes", AutoScalingEnabled: "True"}),
+			integerFeedback(Replicas, 0)),
+		"np3": feedbackValues(map[string]string{StatusFlag: "False", Reason: "InvalidAMI", Message: "ami not found"}),
+	})
+
+	syncManifestworkStatusToHypershiftDeployment(testHD, m)
+	if assert.Len(t, testHD.Status.NodePools, 3) {
+		np1 := testHD.Status.NodePools[0]
+		assert.Equal(t, "np1", np1.Name)
+		assert.Equal(t, metav1.ConditionTrue, np1.Ready)
+		assert.Equal(t, int32(2), *np1.Replicas)
+		assert.Equal(t, int32(2), np1.AvailableReplicas)
+		assert.Equal(t, "4.11.2", np1.Version)
+
+		np2 := testHD.Status.NodePools[1]
+		assert.Equal(t, metav1.ConditionFalse, np2.Ready)
+		assert.Equal(t, "no nodes", np2.Message)
+		assert.Nil(t, np2.Replicas)
+		assert.Equal(t, int32(3), np2.AutoScaling.Max)
+		assert.Equal(t, metav1.ConditionTrue, np2.AutoScalingEnabled)
+
+		assert.Equal(t, "InvalidAMI", testHD.Status.NodePools[2].Reason)
+	}
+
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hyd.Nodepool))
+	if assert.NotNil(t, c) {
+		assert.Equal(t, metav1.ConditionFalse, c.Status)
+		assert.Contains(t, c.Message, "np2, np3 are not ready")
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+// completedUpdateState mirrors the configv1.CompletedUpdate state of the HostedCluster version history
+const completedUpdateState = "Completed"
+
+// releaseFeedback is the release the HostedCluster or a NodePool reports through the ManifestWork status feedback
+type releaseFeedback struct {
+	image   string
+	version string
+	state   string
+	ready   string
+}
+
+func getReleaseFeedback(m *workv1.ManifestWork, hyd *hypdeployment.HypershiftDeployment) (releaseFeedback, map[string]releaseFeedback) {
+	hcFeedback := releaseFeedback{}
+	npFeedback := map[string]releaseFeedback{}
+
+	if m == nil {
+		return hcFeedback, npFeedback
+	}
+
+	idMap := getManifestWorkConfigs(hyd)
+	for _, obj := range m.Status.ResourceStatus.Manifests {
+		id := resourceMeta(obj.ResourceMeta).ToIdentifier()
+		if _, ok := idMap[id]; !ok {
+			continue
+		}
+
+		fb := releaseFeedback{}
+		for _, v := range obj.StatusFeedbacks.Values {
+			if v.Value.String == nil {
+				continue
+			}
+
+			switch v.Name {
+			case DesiredImage:
+				fb.image = *v.Value.String
+			case Version:
+				fb.version = *v.Value.String
+			case Progress:
+				fb.state = *v.Value.String
+			case StatusFlag:
+				fb.ready = *v.Value.String
+			}
+		}
+
+		switch id.Resource {
+		case HostedClusterResource:
+			hcFeedback = fb
+		case NodePoolResource:
+			npFeedback[id.Name] = fb
+		}
+	}
+
+	return hcFeedback, npFeedback
+}
+
+// reconcileUpgrade rolls a HostedClusterSpec.Release.Image change out to the NodePools once the control plane
+// has settled. The NodePools are upgraded by setting their release image in the HypershiftDeployment spec,
+// Spec.Upgrade.NodePoolBatchSize at a time. The Status.Upgrade changes are written with the caller's status patch
+func (r *HypershiftDeploymentReconciler) reconcileUpgrade(hyd *hypdeployment.HypershiftDeployment, m *workv1.ManifestWork) error {
+	if hyd.Spec.Upgrade == nil || hyd.Spec.HostedClusterSpec == nil {
+		return nil
+	}
+
+	target := hyd.Spec.HostedClusterSpec.Release.Image
+	pending := []*hypdeployment.HypershiftNodePools{}
+	for _, np := range hyd.Spec.NodePools {
+		if np.Spec.Release.Image != target {
+			pending = append(pending, np)
+		}
+	}
+
+	us := hyd.Status.Upgrade
+	if us == nil || us.TargetImage != target {
+		if len(pending) == 0 {
+			return nil
+		}
+
+		now := metav1.Now()
+		us = &hypdeployment.UpgradeStatus{
+			TargetImage: target,
+			Stage:       hypdeployment.UpgradeStageControlPlane,
+			StartTime:   &now,
+		}
+		hyd.Status.Upgrade = us
+		r.Log.Info("Upgrade detected", "targetImage", target)
+	}
+
+	if us.Stage == hypdeployment.UpgradeStageCompleted {
+		return nil
+	}
+
+	hcFeedback, npFeedback := getReleaseFeedback(m, hyd)
+
+	if us.Stage == hypdeployment.UpgradeStageControlPlane {
+		if hcFeedback.image != target || hcFeedback.state != completedUpdateState {
+			setStatusCondition(hyd, hypdeployment.UpgradeProgressing, metav1.ConditionTrue,
+				"Waiting for the control plane to complete the upgrade to "+target, hypdeployment.ControlPlaneUpgradingReason)
+			return nil
+		}
+
+		r.Log.Info("Control plane upgrade completed", "version", hcFeedback.version)
+		us.Stage = hypdeployment.UpgradeStageNodePools
+	}
+
+	us.UpdatingNodePools = []string{}
+	us.UpdatedNodePools = []string{}
+	for _, np := range hyd.Spec.NodePools {
+		if np.Spec.Release.Image != target {
+			continue
+		}
+
+		// A NodePool expanded per zone is updated once all its zones are
+		updated := true
+		for _, name := range expandedNodePoolNames(hyd, np) {
+			fb := npFeedback[name]
+			if fb.version != hcFeedback.version || fb.ready != string(metav1.ConditionTrue) {
+				updated = false
+			}
+		}
+		if updated {
+			us.UpdatedNodePools = append(us.UpdatedNodePools, np.Name)
+		} else {
+			us.UpdatingNodePools = append(us.UpdatingNodePools, np.Name)
+		}
+	}
+
+	switch {
+	case len(us.UpdatingNodePools) != 0:
+		setStatusCondition(hyd, hypdeployment.UpgradeProgressing, metav1.ConditionTrue,
+			"Upgrading NodePools: "+strings.Join(us.UpdatingNodePools, ", "), hypdeployment.NodePoolsUpgradingReason)
+
+	case len(pending) == 0:
+		now := metav1.Now()
+		us.Stage = hypdeployment.UpgradeStageCompleted
+		us.CompletionTime = &now
+		setStatusCondition(hyd, hypdeployment.UpgradeProgressing, metav1.ConditionFalse,
+			"Upgrade to "+target+" completed", hypdeployment.UpgradeCompletedReason)
+		r.Log.Info("Upgrade completed", "targetImage", target)
+
+	case hyd.Spec.Upgrade.Paused:
+		setStatusCondition(hyd, hypdeployment.UpgradeProgressing, metav1.ConditionTrue,
+			fmt.Sprintf("Upgrade is paused with %d NodePool(s) remaining", len(pending)), hypdeployment.UpgradePausedReason)
+
+	default:
+		batchSize := hyd.Spec.Upgrade.NodePoolBatchSize
+		if batchSize < 1 {
+			batchSize = 1
+		}
+		if batchSize > len(pending) {
+			batchSize = len(pending)
+		}
+
+		for _, np := range pending[:batchSize] {
+			np.Spec.Release.Image = target
+			us.UpdatingNodePools = append(us.UpdatingNodePools, np.Name)
+		}
+
+		inStatus := hyd.Status.DeepCopy()
+		if err := r.patchHypershiftDeploymentResource(hyd); err != nil {
+			return fmt.Errorf("failed to upgrade NodePools %v, error: %w", us.UpdatingNodePools, err)
+		}
+		// The status is kept for the caller's status patch
+		hyd.Status = *inStatus
+
+		setStatusCondition(hyd, hypdeployment.UpgradeProgressing, metav1.ConditionTrue,
+			"Upgrading NodePools: "+strings.Join(hyd.Status.Upgrade.UpdatingNodePools, ", "), hypdeployment.NodePoolsUpgradingReason)
+		r.Log.Info("Upgrading NodePools", "nodePools", hyd.Status.Upgrade.UpdatingNodePools)
+	}
+
+	return nil
+}
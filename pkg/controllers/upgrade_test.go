@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	hyp "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	hyd "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/helper"
+)
+
+const (
+	testOldRelease = "quay.io/openshift-release-dev/ocp-release:4.11.2-x86_64"
+	testNewRelease = "quay.io/openshift-release-dev/ocp-release:4.11.5-x86_64"
+)
+
+func getUpgradeHD() *hyd.HypershiftDeployment {
+	testHD := getHypershiftDeployment("default", "test1", false)
+	testHD.Spec.InfraID = "test1-abcde"
+	testHD.Spec.HostingCluster = "local-cluster"
+	testHD.Spec.HostedClusterSpec = &hyp.HostedClusterSpec{
+		Platform: hyp.PlatformSpec{Type: hyp.AWSPlatform},
+		Release:  hyp.Release{Image: testNewRelease},
+	}
+	testHD.Spec.Upgrade = &hyd.UpgradeSpec{}
+	for _, name := range []string{"np1", "np2", "np3"} {
+		testHD.Spec.NodePools = append(testHD.Spec.NodePools, &hyd.HypershiftNodePools{
+			Name: name,
+			Spec: hyp.NodePoolSpec{
+				ClusterName: testHD.Name,
+				Release:     hyp.Release{Image: testOldRelease},
+				Platform:    hyp.NodePoolPlatform{Type: hyp.AWSPlatform},
+			},
+		})
+	}
+	return testHD
+}
+
+func feedbackValues(values map[string]string) []workv1.FeedbackValue {
+	out := []workv1.FeedbackValue{}
+	for k, v := range values {
+		value := v
+		out = append(out, workv1.FeedbackValue{
+			Name:  k,
+			Value: workv1.FieldValue{Type: workv1.String, String: &value},
+		})
+	}
+	return out
+}
+
+func getUpgradeManifestWork(testHD *hyd.HypershiftDeployment, hcImage, hcState, hcVersion string, npVersions map[string]string) *workv1.ManifestWork {
+	m := &workv1.ManifestWork{}
+	m.Status.ResourceStatus.Manifests = append(m.Status.ResourceStatus.Manifests, workv1.ManifestCondition{
+		ResourceMeta: workv1.ManifestResourceMeta{
+			Group:     hyp.GroupVersion.Group,
+			Resource:  HostedClusterResource,
+			Name:      testHD.Name,
+			Namespace: helper.GetHostingNamespace(testHD),
+		},
+		StatusFeedbacks: workv1.StatusFeedbackResult{
+			Values: feedbackValues(map[string]string{DesiredImage: hcImage, Progress: hcState, Version: hcVersion}),
+		},
+	})
+
+	for name, version := range npVersions {
+		m.Status.ResourceStatus.Manifests = append(m.Status.ResourceStatus.Manifests, workv1.ManifestCondition{
+			ResourceMeta: workv1.ManifestResourceMeta{
+				Group:     hyp.GroupVersion.Group,
+				Resource:  NodePoolResource,
+				Name:      name,
+				Namespace: helper.GetHostingNamespace(testHD),
+			},
+			StatusFeedbacks: workv1.StatusFeedbackResult{
+				Values: feedbackValues(map[string]string{StatusFlag: "True", Version: version}),
+			},
+		})
+	}
+
+	return m
+}
+
+func TestReconcileUpgrade(t *testing.T) {
+	ctx := context.Background()
+	r := GetHypershiftDeploymentReconciler()
+
+	testHD := getUpgradeHD()
+	testHD.Spec.Upgrade.NodePoolBatchSize = 2
+	assert.Nil(t, r.Client.Create(ctx, testHD), "err is nil when the HypershiftDeployment is created")
+
+	t.Log("Wait for the control plane")
+	m := getUpgradeManifestWork(testHD, testOldRelease, "Completed", "4.11.2", nil)
+	assert.Nil(t, r.reconcileUpgrade(testHD, m), "err is nil when the control plane is upgrading")
+	assert.Equal(t, hyd.UpgradeStageControlPlane, testHD.Status.Upgrade.Stage)
+	assert.Equal(t, testNewRelease, testHD.Status.Upgrade.TargetImage)
+	assert.NotNil(t, testHD.Status.Upgrade.StartTime)
+	c := meta.FindStatusCondition(testHD.Status.Conditions, string(hyd.UpgradeProgressing))
+	assert.Equal(t, hyd.ControlPlaneUpgradingReason, c.Reason)
+	for _, np := range testHD.Spec.NodePools {
+		assert.Equal(t, testOldRelease, np.Spec.Release.Image, "nodepools wait for the control plane")
+	}
+
+	m = getUpgradeManifestWork(testHD, testNewRelease, "Partial", "4.11.5", nil)
+	assert.Nil(t, r.reconcileUpgrade(testHD, m), "err is nil when the control plane is upgrading")
+	assert.Equal(t, hyd.UpgradeStageControlPlane, testHD.Status.Upgrade.Stage, "a partial control plane upgrade is not settled")
+
+	t.Log("Upgrade the first batch of nodepools")
+	m = getUpgradeManifestWork(testHD, testNewRelease, "Completed", "4.11.5",
+		map[string]string{"np1": "4.11.2", "np2": "4.11.2", "np3": "4.11.2"})
+	assert.Nil(t, r.reconcileUpgrade(testHD, m), "err is nil when the nodepools are upgraded")
+	assert.Equal(t, hyd.UpgradeStageNodePools, testHD.Status.Upgrade.Stage)
+	assert.Equal(t, []string{"np1", "np2"}, testHD.Status.Upgrade.UpdatingNodePools)
+	assert.Equal(t, testNewRelease, testHD.Spec.NodePools[0].Spec.Release.Image)
+	assert.Equal(t, testNewRelease, testHD.Spec.NodePools[1].Spec.Release.Image)
+	assert.Equal(t, testOldRelease, testHD.Spec.NodePools[2].Spec.Release.Image)
+
+	storedHD := &hyd.HypershiftDeployment{}
+	assert.Nil(t, r.Client.Get(ctx, genKeyFromObject(testHD), storedHD))
+	assert.Equal(t, testNewRelease, storedHD.Spec.NodePools[0].Spec.Release.Image, "the nodepool release is patched")
+
+	t.Log("Wait for the batch to complete")
+	m = getUpgradeManifestWork(testHD, testNewRelease, "Completed", "4.11.5",
+		map[string]string{"np1": "4.11.5", "np2": "4.11.2", "np3": "4.11.2"})
+	assert.Nil(t, r.reconcileUpgrade(testHD, m))
+	assert.Equal(t, []string{"np2"}, testHD.Status.Upgrade.UpdatingNodePools)
+	assert.Equal(t, []string{"np1"}, testHD.Status.Upgrade.UpdatedNodePools)
+	assert.Equal(t, testOldRelease, testHD.Spec.NodePools[2].Spec.Release.Image, "the next batch waits")
+
+	t.Log("Pause the upgrade")
+	testHD.Spec.Upgrade.Paused = true
+	m = getUpgradeManifestWork(testHD, testNewRelease, "Completed", "4.11.5",
+		map[string]string{"np1": "4.11.5", "np2": "4.11.5", "np3": "4.11.2"})
+	assert.Nil(t, r.reconcileUpgrade(testHD, m))
+	c = meta.FindStatusCondition(testHD.Status.Conditions, string(hyd.UpgradeProgressing))
+	assert.Equal(t, hyd.UpgradePausedReason, c.Reason)
+	assert.Equal(t, testOldRelease, testHD.Spec.NodePools[2].Spec.Release.Image, "a paused upgrade does not start a batch")
+
+	t.Log("Resume the upgrade")
+	testHD.Spec.Upgrade.Paused = false
+	assert.Nil(t, r.reconcileUpgrade(testHD, m))
+	assert.Equal(t, []string{"np3"}, testHD.Status.Upgrade.UpdatingNodePools)
+	assert.Equal(t, testNewRelease, testHD.Spec.NodePools[2].Spec.Release.Image)
+
+	t.Log("Complete the upgrade")
+	m = getUpgradeManifestWork(testHD, testNewRelease, "Completed", "4.11.5",
+		map[string]string{"np1": "4.11.5", "np2": "4.11.5", "np3": "4.11.5"})
+	assert.Nil(t, r.reconcileUpgrade(testHD, m))
+	assert.Equal(t, hyd.UpgradeStageCompleted, testHD.Status.Upgrade.Stage)
+	assert.NotNil(t, testHD.Status.Upgrade.CompletionTime)
+	c = meta.FindStatusCondition(testHD.Status.Conditions, string(hyd.UpgradeProgressing))
+	assert.Equal(t, metav1.ConditionFalse, c.Status)
+	assert.Equal(t, hyd.UpgradeCompletedReason, c.Reason)
+}
+
+func TestReconcileUpgradeDisabled(t *testing.T) {
+	r := GetHypershiftDeploymentReconciler()
+
+	testHD := getUpgradeHD()
+	testHD.Spec.Upgrade = nil
+	m := getUpgradeManifestWork(testHD, testNewRelease, "Completed", "4.11.5", nil)
+	assert.Nil(t, r.reconcileUpgrade(testHD, m))
+	assert.Nil(t, testHD.Status.Upgrade, "no upgrade status without spec.upgrade")
+	assert.Equal(t, testOldRelease, testHD.Spec.NodePools[0].Spec.Release.Image, "nodepools are not changed without spec.upgrade")
+
+	testHD = getUpgradeHD()
+	for _, np := range testHD.Spec.NodePools {
+		np.Spec.Release.Image = testNewRelease
+	}
+	assert.Nil(t, r.reconcileUpgrade(testHD, m))
+	assert.Nil(t, testHD.Status.Upgrade, "no upgrade status when the nodepools match the control plane")
+}
@@ -5,15 +5,28 @@ import (
 
 	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
 	hydclient "github.com/stolostron/hypershift-deployment-controller/pkg/client"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func GetHostingCluster(hyd *hypdeployment.HypershiftDeployment) string {
-	if len(hyd.Spec.HostingCluster) == 0 {
-		return hyd.GetNamespace()
+	if name := GetHostingClusterName(hyd); len(name) != 0 {
+		return name
+	}
+
+	return hyd.GetNamespace()
+}
+
+// GetHostingClusterName returns the hosting cluster recorded in Status.HostingCluster, it stays on the source
+// cluster while a change of Spec.HostingCluster is migrated. Before it is recorded, Spec.HostingCluster is used.
+// It is empty while no cluster is chosen from Spec.HostingClusterPlacement
+func GetHostingClusterName(hyd *hypdeployment.HypershiftDeployment) string {
+	if len(hyd.Status.HostingCluster) != 0 {
+		return hyd.Status.HostingCluster
 	}
 
 	return hyd.Spec.HostingCluster
@@ -30,9 +43,24 @@ func ManagedClusterName(hyd *hypdeployment.HypershiftDeployment) string {
 	return hyd.Name
 }
 
-// TODO(zhujian7) get this from hyd.Status.Kubeconfig
+// HostedKubeconfigName is the admin kubeconfig Secret synced from the hosting namespace to the namespace of the
+// hosting cluster on the hub. The Secret reported in Status.Kubeconfig is used once the HostedCluster has one
 func HostedKubeconfigName(hyd *hypdeployment.HypershiftDeployment) string {
-	return fmt.Sprintf("%s-%s-admin-kubeconfig", GetHostingNamespace(hyd), hyd.GetName())
+	name := hyd.GetName() + "-admin-kubeconfig"
+	if hyd.Status.Kubeconfig != nil && len(hyd.Status.Kubeconfig.Name) != 0 {
+		name = hyd.Status.Kubeconfig.Name
+	}
+	return fmt.Sprintf("%s-%s", GetHostingNamespace(hyd), name)
+}
+
+// HostedKubeadminPasswordName is the kubeadmin password Secret synced next to the admin kubeconfig, see
+// HostedKubeconfigName
+func HostedKubeadminPasswordName(hyd *hypdeployment.HypershiftDeployment) string {
+	name := hyd.GetName() + "-kubeadmin-password"
+	if hyd.Status.KubeadminPassword != nil && len(hyd.Status.KubeadminPassword.Name) != 0 {
+		name = hyd.Status.KubeadminPassword.Name
+	}
+	return fmt.Sprintf("%s-%s", GetHostingNamespace(hyd), name)
 }
 
 func GetClusterSetName(managedCluster clusterv1.ManagedCluster) string {
@@ -90,3 +118,11 @@ func IsClusterInClusterSet(client client.Client,
 
 	return false, nil
 }
+
+// RecordEvent is a no-op without a recorder, as for the reconcilers built by the unit tests
+func RecordEvent(recorder record.EventRecorder, obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
@@ -8,11 +8,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	cliScheme "k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
 )
 
 var (
@@ -330,3 +333,96 @@ func TestIsClusterInClusterSet(t *testing.T) {
 		}
 	}
 }
+
+func TestGetHostingCluster(t *testing.T) {
+	tests := []struct {
+		name           string
+		hyd            *hypdeployment.HypershiftDeployment
+		expectName     string
+		expectFallback string
+	}{
+		{
+			name: "hosting cluster",
+			hyd: &hypdeployment.HypershiftDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns"},
+				Spec:       hypdeployment.HypershiftDeploymentSpec{HostingCluster: "c1"},
+			},
+			expectName:     "c1",
+			expectFallback: "c1",
+		},
+		{
+			name: "placement without a selected cluster",
+			hyd: &hypdeployment.HypershiftDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns"},
+				Spec: hypdeployment.HypershiftDeploymentSpec{
+					HostingClusterPlacement: &corev1.LocalObjectReference{Name: "p1"},
+				},
+			},
+			expectName:     "",
+			expectFallback: "ns",
+		},
+		{
+			name: "placement with a selected cluster",
+			hyd: &hypdeployment.HypershiftDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns"},
+				Spec: hypdeployment.HypershiftDeploymentSpec{
+					HostingClusterPlacement: &corev1.LocalObjectReference{Name: "p1"},
+				},
+				Status: hypdeployment.HypershiftDeploymentStatus{HostingCluster: "c2"},
+			},
+			expectName:     "c2",
+			expectFallback: "c2",
+		},
+		{
+			name: "hosting cluster being migrated",
+			hyd: &hypdeployment.HypershiftDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns"},
+				Spec:       hypdeployment.HypershiftDeploymentSpec{HostingCluster: "c2"},
+				Status:     hypdeployment.HypershiftDeploymentStatus{HostingCluster: "c1"},
+			},
+			expectName:     "c1",
+			expectFallback: "c1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if name := GetHostingClusterName(test.hyd); name != test.expectName {
+				t.Errorf("expected %q, but got %q", test.expectName, name)
+			}
+			if name := GetHostingCluster(test.hyd); name != test.expectFallback {
+				t.Errorf("expected %q, but got %q", test.expectFallback, name)
+			}
+		})
+	}
+}
+
+func TestHostedKubeconfigName(t *testing.T) {
+	hyd := &hypdeployment.HypershiftDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "hd", Namespace: "ns"},
+		Spec:       hypdeployment.HypershiftDeploymentSpec{HostingNamespace: "clusters"},
+	}
+	if name := HostedKubeconfigName(hyd); name != "clusters-hd-admin-kubeconfig" {
+		t.Errorf("expected the default name, but got %q", name)
+	}
+
+	hyd.Status.Kubeconfig = &corev1.LocalObjectReference{Name: "custom-kubeconfig"}
+	if name := HostedKubeconfigName(hyd); name != "clusters-custom-kubeconfig" {
+		t.Errorf("expected the name from the status, but got %q", name)
+	}
+}
+
+func TestHostedKubeadminPasswordName(t *testing.T) {
+	hyd := &hypdeployment.HypershiftDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "hd", Namespace: "ns"},
+		Spec:       hypdeployment.HypershiftDeploymentSpec{HostingNamespace: "clusters"},
+	}
+	if name := HostedKubeadminPasswordName(hyd); name != "clusters-hd-kubeadmin-password" {
+		t.Errorf("expected the default name, but got %q", name)
+	}
+
+	hyd.Status.KubeadminPassword = &corev1.LocalObjectReference{Name: "custom-password"}
+	if name := HostedKubeadminPasswordName(hyd); name != "clusters-custom-password" {
+		t.Errorf("expected the name from the status, but got %q", name)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -30,6 +31,7 @@ import (
 	"github.com/go-logr/zapr"
 	hyp "github.com/openshift/hypershift/api/v1alpha1"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -38,10 +40,14 @@ import (
 	workv1 "open-cluster-management.io/api/work/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	clusteropenclustermanagementiov1alpha1 "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+	clusteropenclustermanagementiov1beta1 "github.com/stolostron/hypershift-deployment-controller/api/v1beta1"
+	"github.com/stolostron/hypershift-deployment-controller/pkg/constant"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/controllers"
 	"github.com/stolostron/hypershift-deployment-controller/pkg/controllers/autoimport"
+	hydmetrics "github.com/stolostron/hypershift-deployment-controller/pkg/metrics"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -53,6 +59,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(clusteropenclustermanagementiov1alpha1.AddToScheme(scheme))
+	utilruntime.Must(clusteropenclustermanagementiov1beta1.AddToScheme(scheme))
 
 	utilruntime.Must(hyp.AddToScheme(scheme))
 
@@ -70,6 +77,19 @@ func main() {
 	var probeAddr string
 	var enableLeaderElection bool
 	var validateClusterSecurity bool
+	var enableWebhooks bool
+	var releaseImageConfigMap string
+	var releaseImageNamespace string
+	var releaseArchitecture string
+	var infraVerifyInterval time.Duration
+	var infraRetryLimit int
+	var awsWebIdentityTokenFile string
+	var azureFederatedTokenFile string
+	var controllerIdentities string
+	var maxHostedClusters int
+	var hostedClusterCPU string
+	var hostedClusterMemory string
+	var rejectOverCapacity bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -78,6 +98,36 @@ func main() {
 	flag.BoolVar(&validateClusterSecurity, "validate-cluster-security", false,
 		"Enable HypershiftDeployment cluster security validation. "+
 			"Enabling this will ensure a HypershiftDeployment CR has the right permission to work on a given hosting cluster.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the HypershiftDeployment defaulting, validating and conversion webhooks. "+
+			"Enabling this requires the webhook serving certificates to be mounted in the controller. "+
+			"The conversion webhook is required to serve v1alpha1 from the v1beta1 storage version.")
+	flag.StringVar(&releaseImageConfigMap, "release-image-configmap", constant.ReleaseImageConfigMapName,
+		"The ConfigMap with the default release images or channels for scaffolded HostedClusters and NodePools.")
+	flag.StringVar(&releaseImageNamespace, "release-image-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace of the release image ConfigMap, defaults to the controller namespace.")
+	flag.StringVar(&releaseArchitecture, "release-architecture", "amd64",
+		"The architecture used to look up the default release image.")
+	flag.DurationVar(&infraVerifyInterval, "infra-verify-interval", time.Hour,
+		"How often the configured AWS and Azure infrastructure is compared with the provider to detect drift, 0 disables it.")
+	flag.IntVar(&infraRetryLimit, "infra-retry-limit", 10,
+		"The number of failed attempts of an infrastructure or IAM operation before it is no longer retried, 0 retries forever.")
+	flag.StringVar(&awsWebIdentityTokenFile, "aws-web-identity-token-file", os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"),
+		"The service account token exchanged for the role of cloud provider secrets with credential_source WebIdentity.")
+	flag.StringVar(&azureFederatedTokenFile, "azure-federated-token-file", os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		"The service account token exchanged for the identity of cloud provider secrets with credential_source WorkloadIdentity.")
+	flag.StringVar(&controllerIdentities, "controller-identities", "",
+		"A comma separated list of namespace=identity, the AWS role ARNs and Azure client IDs the cloud provider secrets of "+
+			"a namespace can use with the identity of the controller. The * namespace applies to every namespace.")
+	flag.IntVar(&maxHostedClusters, "max-hosted-clusters-per-cluster", 0,
+		"The number of HostedClusters scheduled on a hosting cluster, 0 is unlimited. "+
+			"The "+constant.MaxHostedClustersAnnotation+" annotation of a ManagedCluster overrides it.")
+	flag.StringVar(&hostedClusterCPU, "hosted-cluster-cpu", "",
+		"The cpu reserved for a HostedCluster from the allocatable cpu of the hosting cluster, empty is not checked.")
+	flag.StringVar(&hostedClusterMemory, "hosted-cluster-memory", "",
+		"The memory reserved for a HostedCluster from the allocatable memory of the hosting cluster, empty is not checked.")
+	flag.BoolVar(&rejectOverCapacity, "reject-over-capacity", false,
+		"Fail a HypershiftDeployment when its hosting cluster has no capacity, instead of queueing it.")
 
 	flag.Parse()
 
@@ -108,27 +158,80 @@ func main() {
 		os.Exit(1)
 	}
 
+	capacityScheduler := &controllers.CapacityScheduler{
+		Client:        mgr.GetClient(),
+		MaxPerCluster: maxHostedClusters,
+		Reject:        rejectOverCapacity,
+	}
+	if len(hostedClusterCPU) != 0 {
+		if capacityScheduler.CPUPerHostedCluster, err = resource.ParseQuantity(hostedClusterCPU); err != nil {
+			setupLog.Error(err, "invalid --hosted-cluster-cpu")
+			os.Exit(1)
+		}
+	}
+	if len(hostedClusterMemory) != 0 {
+		if capacityScheduler.MemoryPerHostedCluster, err = resource.ParseQuantity(hostedClusterMemory); err != nil {
+			setupLog.Error(err, "invalid --hosted-cluster-memory")
+			os.Exit(1)
+		}
+	}
+
+	allowedIdentities, err := controllers.ParseControllerIdentities(controllerIdentities)
+	if err != nil {
+		setupLog.Error(err, "invalid --controller-identities")
+		os.Exit(1)
+	}
+
 	dynamicClient, _ := dynamic.NewForConfig(ctrl.GetConfigOrDie())
 	if err = (&controllers.HypershiftDeploymentReconciler{
-		Client:                  mgr.GetClient(),
-		DynamicClient:           dynamicClient,
-		Scheme:                  mgr.GetScheme(),
-		InfraHandler:            &controllers.DefaultInfraHandler{},
+		Client:        mgr.GetClient(),
+		DynamicClient: dynamicClient,
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("hypershift-deployment-controller"),
+		InfraHandler: &controllers.DefaultInfraHandler{
+			AWSWebIdentityTokenFile: awsWebIdentityTokenFile,
+			AzureFederatedTokenFile: azureFederatedTokenFile,
+			ControllerIdentities:    allowedIdentities,
+		},
 		ValidateClusterSecurity: validateClusterSecurity,
+		InfraVerifyInterval:     infraVerifyInterval,
+		InfraRetryLimit:         int32(infraRetryLimit),
+		CapacityScheduler:       capacityScheduler,
+		ReleaseImageResolver: &controllers.ReleaseImageResolver{
+			Client:        mgr.GetClient(),
+			Namespace:     releaseImageNamespace,
+			ConfigMapName: releaseImageConfigMap,
+			Architecture:  releaseArchitecture,
+		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HypershiftDeployment")
 		os.Exit(1)
 	}
 
 	if err = (&autoimport.Reconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("hypershift-deployment-autoimport"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AutoImport")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = (&controllers.HypershiftDeploymentWebhook{
+			Log:    logger.WithName("webhook"),
+			Client: mgr.GetClient(),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "HypershiftDeployment")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	metrics.Registry.MustRegister(&hydmetrics.HypershiftDeploymentCollector{
+		Client: mgr.GetClient(),
+		Log:    logger.WithName("metrics"),
+	})
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,115 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+var (
+	conditionDesc = prometheus.NewDesc(
+		"hypershiftdeployment_status_condition",
+		"The current status conditions of a HypershiftDeployment",
+		[]string{"namespace", "name", "type", "status", "reason"}, nil)
+
+	phaseDesc = prometheus.NewDesc(
+		"hypershiftdeployment_status_phase",
+		"The current phase of a HypershiftDeployment",
+		[]string{"namespace", "name", "phase"}, nil)
+
+	phaseTransitionDesc = prometheus.NewDesc(
+		"hypershiftdeployment_status_phase_transition_timestamp_seconds",
+		"Unix timestamp of the last phase transition of a HypershiftDeployment",
+		[]string{"namespace", "name", "phase"}, nil)
+
+	createdDesc = prometheus.NewDesc(
+		"hypershiftdeployment_created_timestamp_seconds",
+		"Unix creation timestamp of a HypershiftDeployment",
+		[]string{"namespace", "name", "platform"}, nil)
+)
+
+// HypershiftDeploymentCollector reports the conditions and phase of the HypershiftDeployments when the metrics
+// are scraped, so a HypershiftDeployment stuck in provisioning can be alerted on
+type HypershiftDeploymentCollector struct {
+	Client client.Reader
+	Log    logr.Logger
+}
+
+var _ prometheus.Collector = &HypershiftDeploymentCollector{}
+
+func (c *HypershiftDeploymentCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- conditionDesc
+	ch <- phaseDesc
+	ch <- phaseTransitionDesc
+	ch <- createdDesc
+}
+
+func (c *HypershiftDeploymentCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hydList := &hypdeployment.HypershiftDeploymentList{}
+	if err := c.Client.List(ctx, hydList); err != nil {
+		c.Log.Error(err, "failed to list HypershiftDeployments for metrics")
+		return
+	}
+
+	for _, hyd := range hydList.Items {
+		ch <- prometheus.MustNewConstMetric(createdDesc, prometheus.GaugeValue,
+			float64(hyd.CreationTimestamp.Unix()), hyd.Namespace, hyd.Name, Platform(&hyd))
+
+		if len(hyd.Status.Phase) != 0 {
+			ch <- prometheus.MustNewConstMetric(phaseDesc, prometheus.GaugeValue, 1,
+				hyd.Namespace, hyd.Name, string(hyd.Status.Phase))
+		}
+
+		if hyd.Status.PhaseTransitionTime != nil {
+			ch <- prometheus.MustNewConstMetric(phaseTransitionDesc, prometheus.GaugeValue,
+				float64(hyd.Status.PhaseTransitionTime.Unix()), hyd.Namespace, hyd.Name, string(hyd.Status.Phase))
+		}
+
+		for _, cond := range hyd.Status.Conditions {
+			ch <- prometheus.MustNewConstMetric(conditionDesc, prometheus.GaugeValue, 1,
+				hyd.Namespace, hyd.Name, cond.Type, string(cond.Status), cond.Reason)
+		}
+	}
+}
+
+// Platform is the platform label value of a HypershiftDeployment
+func Platform(hyd *hypdeployment.HypershiftDeployment) string {
+	switch {
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.AWS != nil:
+		return PlatformAWS
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.Azure != nil:
+		return PlatformAzure
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.KubeVirt != nil:
+		return PlatformKubeVirt
+	case hyd.Spec.Infrastructure.Platform != nil && hyd.Spec.Infrastructure.Platform.Agent != nil:
+		return PlatformAgent
+	case hyd.Spec.HostedClusterSpec != nil && len(hyd.Spec.HostedClusterSpec.Platform.Type) != 0:
+		return strings.ToLower(string(hyd.Spec.HostedClusterSpec.Platform.Type))
+	}
+	return "unknown"
+}
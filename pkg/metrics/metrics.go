@@ -0,0 +1,142 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	PlatformAWS      = "aws"
+	PlatformAzure    = "azure"
+	PlatformKubeVirt = "kubevirt"
+	PlatformAgent    = "agent"
+
+	OperationCreateInfra  = "create-infra"
+	OperationCreateIAM    = "create-iam"
+	OperationDestroyInfra = "destroy-infra"
+	OperationDestroyIAM   = "destroy-iam"
+	OperationVerifyInfra  = "verify-infra"
+)
+
+var (
+	// InfraDuration is the time taken by successful infrastructure operations on the cloud provider
+	InfraDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hypershiftdeployment_infra_duration_seconds",
+		Help:    "Duration of successful infrastructure create and destroy operations, per platform",
+		Buckets: []float64{15, 30, 60, 120, 300, 600, 900, 1800, 3600},
+	}, []string{"platform", "operation"})
+
+	// InfraFailures counts the failed infrastructure operations on the cloud provider
+	InfraFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hypershiftdeployment_infra_failures_total",
+		Help: "Number of failed infrastructure create and destroy operations, per platform",
+	}, []string{"platform", "operation"})
+
+	// ManifestWorkApplyDuration is the latency of creating or updating the ManifestWork on the hub
+	ManifestWorkApplyDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hypershiftdeployment_manifestwork_apply_duration_seconds",
+		Help:    "Latency of creating or updating the ManifestWork of a HypershiftDeployment",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// ManifestWorkApplyFailures counts the failed ManifestWork create or update calls
+	ManifestWorkApplyFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hypershiftdeployment_manifestwork_apply_failures_total",
+		Help: "Number of failed ManifestWork create or update calls",
+	})
+
+	// HostedClusterAvailableDuration is the time from the HypershiftDeployment creation to the HostedCluster being available
+	HostedClusterAvailableDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hypershiftdeployment_hostedcluster_time_to_available_seconds",
+		Help:    "Time from the HypershiftDeployment creation until the HostedCluster is Available, per platform",
+		Buckets: []float64{300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200},
+	}, []string{"platform"})
+
+	// AutoImportDuration is the time from the ManagedCluster creation to the ManagedCluster joining the hub
+	AutoImportDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hypershiftdeployment_autoimport_duration_seconds",
+		Help:    "Time from the ManagedCluster creation until the hosted cluster joined the hub",
+		Buckets: []float64{30, 60, 120, 300, 600, 900, 1800, 3600},
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		InfraDuration,
+		InfraFailures,
+		ManifestWorkApplyDuration,
+		ManifestWorkApplyFailures,
+		HostedClusterAvailableDuration,
+		AutoImportDuration,
+	)
+}
+
+// ObserveInfraOperation records the duration of a successful infrastructure operation, or counts the failure
+func ObserveInfraOperation(platform, operation string, start time.Time, err error) {
+	if err != nil {
+		InfraFailures.WithLabelValues(platform, operation).Inc()
+		return
+	}
+
+	InfraDuration.WithLabelValues(platform, operation).Observe(time.Since(start).Seconds())
+}
+
+// TransitionWindow is how long after a condition transition it is still observed. A resource reconciled again
+// after a controller restart has an older transition, so it is not counted twice
+const TransitionWindow = 2 * time.Minute
+
+// observed tracks the transitions of the last TransitionWindow, a resource reconciled more than once in the
+// window is only counted once
+var observed = struct {
+	sync.Mutex
+	transitions map[string]map[types.UID]time.Time
+}{transitions: map[string]map[types.UID]time.Time{}}
+
+// ObserveTransition records the duration in the histogram when the transition happened in the last
+// TransitionWindow and was not observed yet for the uid
+func ObserveTransition(h prometheus.Observer, metric string, uid types.UID, transition time.Time, d time.Duration) {
+	observed.Lock()
+	defer observed.Unlock()
+
+	if observed.transitions[metric] == nil {
+		observed.transitions[metric] = map[types.UID]time.Time{}
+	}
+	transitions := observed.transitions[metric]
+
+	// Older transitions are rejected by the window, they are pruned so the map stays bounded
+	for u, t := range transitions {
+		if time.Since(t) > TransitionWindow {
+			delete(transitions, u)
+		}
+	}
+
+	if time.Since(transition) > TransitionWindow {
+		return
+	}
+	if t, found := transitions[uid]; found && t.Equal(transition) {
+		return
+	}
+	transitions[uid] = transition
+
+	h.Observe(d.Seconds())
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hypdeployment "github.com/stolostron/hypershift-deployment-controller/api/v1alpha1"
+)
+
+func TestObserveInfraOperation(t *testing.T) {
+	ObserveInfraOperation(PlatformAWS, OperationCreateInfra, time.Now(), errors.New("failed"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(InfraFailures.WithLabelValues(PlatformAWS, OperationCreateInfra)),
+		"a failed operation is counted")
+
+	ObserveInfraOperation(PlatformAWS, OperationCreateInfra, time.Now(), nil)
+	assert.Equal(t, 1, testutil.CollectAndCount(InfraDuration), "a successful operation is observed")
+}
+
+func TestObserveTransition(t *testing.T) {
+	count := 0
+	h := prometheus.ObserverFunc(func(float64) { count++ })
+	uid := types.UID("abcde")
+	now := time.Now()
+
+	ObserveTransition(h, "test", uid, now, time.Minute)
+	ObserveTransition(h, "test", uid, now, time.Minute)
+	assert.Equal(t, 1, count, "a transition is only observed once")
+
+	ObserveTransition(h, "test", types.UID("fghij"), now.Add(-TransitionWindow-time.Second), time.Minute)
+	assert.Equal(t, 1, count, "a transition older than the window is not observed, as after a restart")
+
+	ObserveTransition(h, "test", uid, now.Add(time.Second), time.Minute)
+	assert.Equal(t, 2, count, "a new transition of the uid is observed")
+}
+
+func TestHypershiftDeploymentCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, hypdeployment.AddToScheme(scheme))
+
+	hyd := &hypdeployment.HypershiftDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test1", Namespace: "default"},
+		Spec: hypdeployment.HypershiftDeploymentSpec{
+			Infrastructure: hypdeployment.InfraSpec{
+				Configure: true,
+				Platform:  &hypdeployment.Platforms{AWS: &hypdeployment.AWSPlatform{Region: "us-east-1"}},
+			},
+		},
+		Status: hypdeployment.HypershiftDeploymentStatus{
+			Conditions: []metav1.Condition{
+				{Type: string(hypdeployment.PlatformConfigured), Status: metav1.ConditionFalse, Reason: hypdeployment.BeingConfiguredReason},
+				{Type: string(hypdeployment.HostedClusterAvailable), Status: metav1.ConditionFalse, Reason: "Waiting"},
+			},
+			Phase:               hypdeployment.PhaseConfiguringInfra,
+			PhaseTransitionTime: &metav1.Time{Time: time.Unix(1660000000, 0)},
+		},
+	}
+
+	c := &HypershiftDeploymentCollector{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(hyd).Build(),
+		Log:    logr.Discard(),
+	}
+
+	assert.Equal(t, 5, testutil.CollectAndCount(c), "a creation timestamp, the phase, its transition and two conditions are reported")
+	assert.Nil(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP hypershiftdeployment_status_condition The current status conditions of a HypershiftDeployment
+# TYPE hypershiftdeployment_status_condition gauge
+hypershiftdeployment_status_condition{name="test1",namespace="default",reason="BeingConfigured",status="False",type="PlatformInfrastructureConfigured"} 1
+hypershiftdeployment_status_condition{name="test1",namespace="default",reason="Waiting",status="False",type="HostedClusterAvailable"} 1
+`), "hypershiftdeployment_status_condition"))
+	assert.Nil(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP hypershiftdeployment_status_phase_transition_timestamp_seconds Unix timestamp of the last phase transition of a HypershiftDeployment
+# TYPE hypershiftdeployment_status_phase_transition_timestamp_seconds gauge
+hypershiftdeployment_status_phase_transition_timestamp_seconds{name="test1",namespace="default",phase="ConfiguringInfra"} 1.66e+09
+`), "hypershiftdeployment_status_phase_transition_timestamp_seconds"))
+}